@@ -0,0 +1,194 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"strconv"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const (
+	// DefaultSuccessRatioTolerance is the drop of the success ratio allowed by default, in percentage points
+	DefaultSuccessRatioTolerance = 1
+	// DefaultResponseTimeTolerance is the increase of the response times allowed by default, in percent
+	DefaultResponseTimeTolerance = 10
+	// DefaultCallRateTolerance is the drop of the call rate allowed by default, in percent
+	DefaultCallRateTolerance = 10
+)
+
+// RunMetrics are the key metrics of a run, read from the sipp statistics files of its last attempt
+type RunMetrics struct {
+	// SuccessfulCalls is the number of calls which succeeded
+	SuccessfulCalls int64 `json:"successfulCalls"`
+	// FailedCalls is the number of calls which failed
+	FailedCalls int64 `json:"failedCalls"`
+	// CallRate is the achieved number of calls per second, as a decimal number
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]+)?$`
+	// +optional
+	CallRate string `json:"callRate,omitempty"`
+	// ResponseTimeP50 is the median of the first response time of the scenario.
+	// The response times percentiles are only measured when the scenario
+	// declares a ResponseTimeRepartition.
+	// +optional
+	ResponseTimeP50 *metav1.Duration `json:"responseTimeP50,omitempty"`
+	// ResponseTimeP90 is the 90th percentile of the first response time of the scenario
+	// +optional
+	ResponseTimeP90 *metav1.Duration `json:"responseTimeP90,omitempty"`
+	// ResponseTimeP99 is the 99th percentile of the first response time of the scenario
+	// +optional
+	ResponseTimeP99 *metav1.Duration `json:"responseTimeP99,omitempty"`
+}
+
+// SuccessRatio returns the ratio of successful calls, and false when no call was placed
+func (m *RunMetrics) SuccessRatio() (float64, bool) {
+	total := m.SuccessfulCalls + m.FailedCalls
+	if total == 0 {
+		return 0, false
+	}
+	return float64(m.SuccessfulCalls) / float64(total), true
+}
+
+// Baseline is the reference the metrics of a run are compared to, exactly one of runName or metrics must be set
+type Baseline struct {
+	// RunName is a previous run of the namespace whose metrics are the reference
+	// +optional
+	RunName string `json:"runName,omitempty"`
+	// Metrics are stored reference metrics, such as the metrics of a run which no longer exists
+	// +optional
+	Metrics *RunMetrics `json:"metrics,omitempty"`
+	// Tolerances are the degradations allowed before the run is regressed
+	// +optional
+	Tolerances BaselineTolerances `json:"tolerances,omitempty"`
+}
+
+// BaselineTolerances are the degradations of the metrics allowed compared to the baseline
+type BaselineTolerances struct {
+	// SuccessRatio is the drop of the success ratio allowed, in percentage points. Defaults to 1.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	SuccessRatio *int32 `json:"successRatio,omitempty"`
+	// ResponseTime is the increase of each response time percentile allowed, in percent. Defaults to 10.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	ResponseTime *int32 `json:"responseTime,omitempty"`
+	// CallRate is the drop of the achieved call rate allowed, in percent. Defaults to 10.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	CallRate *int32 `json:"callRate,omitempty"`
+}
+
+func tolerance(value *int32, defaultValue int32) float64 {
+	if value == nil {
+		return float64(defaultValue)
+	}
+	return float64(*value)
+}
+
+// MetricDelta is the comparison of a metric of a run with the baseline
+type MetricDelta struct {
+	// Metric is the name of the metric
+	Metric string `json:"metric"`
+	// Baseline is the value of the baseline
+	Baseline string `json:"baseline"`
+	// Value is the value of the run
+	Value string `json:"value"`
+	// Delta is the difference between the run and the baseline,
+	// in percentage points for the success ratio and in percent otherwise
+	Delta string `json:"delta"`
+	// Regressed tells whether the difference exceeds the tolerance
+	Regressed bool `json:"regressed"`
+}
+
+// String returns the delta as shown in the Regressed condition
+func (d MetricDelta) String() string {
+	return fmt.Sprintf("%s %s (baseline %s, %s)", d.Metric, d.Value, d.Baseline, d.Delta)
+}
+
+// CompareMetrics compares the metrics of a run with the baseline ones.
+// Metrics missing on either side are not compared.
+func CompareMetrics(baseline, metrics *RunMetrics, tolerances BaselineTolerances) []MetricDelta {
+	deltas := []MetricDelta{}
+
+	if baselineRatio, ok := baseline.SuccessRatio(); ok {
+		if ratio, ok := metrics.SuccessRatio(); ok {
+			delta := (ratio - baselineRatio) * 100
+			deltas = append(deltas, MetricDelta{
+				Metric:    "successRatio",
+				Baseline:  fmt.Sprintf("%.2f%%", baselineRatio*100),
+				Value:     fmt.Sprintf("%.2f%%", ratio*100),
+				Delta:     fmt.Sprintf("%+.2fpp", delta),
+				Regressed: -delta > tolerance(tolerances.SuccessRatio, DefaultSuccessRatioTolerance),
+			})
+		}
+	}
+
+	baselineRate, baselineErr := strconv.ParseFloat(baseline.CallRate, 64)
+	rate, err := strconv.ParseFloat(metrics.CallRate, 64)
+	if baselineErr == nil && err == nil && baselineRate > 0 {
+		delta := (rate - baselineRate) / baselineRate * 100
+		deltas = append(deltas, MetricDelta{
+			Metric:    "callRate",
+			Baseline:  baseline.CallRate,
+			Value:     metrics.CallRate,
+			Delta:     fmt.Sprintf("%+.1f%%", delta),
+			Regressed: -delta > tolerance(tolerances.CallRate, DefaultCallRateTolerance),
+		})
+	}
+
+	percentiles := []struct {
+		name              string
+		baseline, current *metav1.Duration
+	}{
+		{"responseTimeP50", baseline.ResponseTimeP50, metrics.ResponseTimeP50},
+		{"responseTimeP90", baseline.ResponseTimeP90, metrics.ResponseTimeP90},
+		{"responseTimeP99", baseline.ResponseTimeP99, metrics.ResponseTimeP99},
+	}
+	for _, percentile := range percentiles {
+		if percentile.baseline == nil || percentile.current == nil || percentile.baseline.Duration <= 0 {
+			continue
+		}
+		delta := float64(percentile.current.Duration-percentile.baseline.Duration) / float64(percentile.baseline.Duration) * 100
+		deltas = append(deltas, MetricDelta{
+			Metric:    percentile.name,
+			Baseline:  percentile.baseline.Duration.String(),
+			Value:     percentile.current.Duration.String(),
+			Delta:     fmt.Sprintf("%+.1f%%", delta),
+			Regressed: delta > tolerance(tolerances.ResponseTime, DefaultResponseTimeTolerance),
+		})
+	}
+
+	return deltas
+}
+
+func (b *Baseline) validate(run *SippScenarioRun, baselinePath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if (b.RunName == "") == (b.Metrics == nil) {
+		allErrs = append(allErrs, field.Invalid(baselinePath, "", "exactly one of runName or metrics must be set"))
+	}
+	if b.RunName == run.Name && b.RunName != "" {
+		allErrs = append(allErrs, field.Invalid(baselinePath.Child("runName"), b.RunName, "a run can't be its own baseline"))
+	}
+	if run.Spec.Traces == nil || !run.Spec.Traces.Statistics {
+		allErrs = append(allErrs, field.Required(field.NewPath("spec", "traces", "statistics"), "the metrics compared to the baseline are read from the statistics traces"))
+	}
+
+	return allErrs
+}
@@ -0,0 +1,112 @@
+package v1alpha1_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+func TestCompareMetrics(t *testing.T) {
+	duration := func(d time.Duration) *metav1.Duration {
+		return &metav1.Duration{Duration: d}
+	}
+
+	baseline := &v1alpha1.RunMetrics{
+		SuccessfulCalls: 995,
+		FailedCalls:     5,
+		CallRate:        "10.00",
+		ResponseTimeP50: duration(10 * time.Millisecond),
+		ResponseTimeP99: duration(100 * time.Millisecond),
+	}
+	metrics := &v1alpha1.RunMetrics{
+		SuccessfulCalls: 980,
+		FailedCalls:     20,
+		CallRate:        "9.50",
+		ResponseTimeP50: duration(10 * time.Millisecond),
+		ResponseTimeP90: duration(50 * time.Millisecond),
+		ResponseTimeP99: duration(150 * time.Millisecond),
+	}
+
+	deltas := v1alpha1.CompareMetrics(baseline, metrics, v1alpha1.BaselineTolerances{})
+	assert.Equal(t, []v1alpha1.MetricDelta{
+		{Metric: "successRatio", Baseline: "99.50%", Value: "98.00%", Delta: "-1.50pp", Regressed: true},
+		{Metric: "callRate", Baseline: "10.00", Value: "9.50", Delta: "-5.0%", Regressed: false},
+		{Metric: "responseTimeP50", Baseline: "10ms", Value: "10ms", Delta: "+0.0%", Regressed: false},
+		{Metric: "responseTimeP99", Baseline: "100ms", Value: "150ms", Delta: "+50.0%", Regressed: true},
+	}, deltas)
+	assert.Equal(t, "successRatio 98.00% (baseline 99.50%, -1.50pp)", deltas[0].String())
+
+	tolerances := v1alpha1.BaselineTolerances{
+		SuccessRatio: pointer.Int32Ptr(2),
+		ResponseTime: pointer.Int32Ptr(50),
+		CallRate:     pointer.Int32Ptr(0),
+	}
+	regressed := []string{}
+	for _, delta := range v1alpha1.CompareMetrics(baseline, metrics, tolerances) {
+		if delta.Regressed {
+			regressed = append(regressed, delta.Metric)
+		}
+	}
+	assert.Equal(t, []string{"callRate"}, regressed)
+
+	assert.Empty(t, v1alpha1.CompareMetrics(&v1alpha1.RunMetrics{}, metrics, tolerances))
+}
+
+func TestValidateBaseline(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Baseline v1alpha1.Baseline
+		Traces   *v1alpha1.TraceOptions
+		Valid    bool
+	}{
+		{
+			Name:     "baseline run",
+			Baseline: v1alpha1.Baseline{RunName: "nightly-1"},
+			Traces:   &v1alpha1.TraceOptions{Statistics: true},
+			Valid:    true,
+		},
+		{
+			Name:     "stored metrics",
+			Baseline: v1alpha1.Baseline{Metrics: &v1alpha1.RunMetrics{SuccessfulCalls: 10}},
+			Traces:   &v1alpha1.TraceOptions{Statistics: true},
+			Valid:    true,
+		},
+		{
+			Name:     "without statistics",
+			Baseline: v1alpha1.Baseline{RunName: "nightly-1"},
+			Traces:   &v1alpha1.TraceOptions{Errors: true},
+		},
+		{
+			Name:     "no reference",
+			Baseline: v1alpha1.Baseline{},
+			Traces:   &v1alpha1.TraceOptions{Statistics: true},
+		},
+		{
+			Name:     "itself",
+			Baseline: v1alpha1.Baseline{RunName: "nightly-2"},
+			Traces:   &v1alpha1.TraceOptions{Statistics: true},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			run := &v1alpha1.SippScenarioRun{
+				ObjectMeta: metav1.ObjectMeta{Name: "nightly-2"},
+				Spec: v1alpha1.SippScenarioRunSpec{
+					Baseline: &test.Baseline,
+					Traces:   test.Traces,
+				},
+			}
+			err := run.Validate()
+			if test.Valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
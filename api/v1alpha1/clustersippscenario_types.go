@@ -0,0 +1,104 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// +kubebuilder:object:root=true
+
+// ClusterSippScenario is the Schema for the clustersippscenarios API,
+// a scenario runs of any namespace can use.
+// It has no namespace, so its files are inline or fetched from URLs.
+// Its media assets are looked up in the namespace of the run using it.
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Valid",type="string",JSONPath=".status.conditions[?(@.type==\"Valid\")].status"
+// +kubebuilder:printcolumn:name="Scenario",type="string",JSONPath=".status.summary.name",priority=1
+// +kubebuilder:printcolumn:name="Messages",type="integer",JSONPath=".status.summary.messageCount"
+// +kubebuilder:printcolumn:name="Fields",type="integer",JSONPath=".status.summary.fieldCount"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:resource:scope=Cluster,shortName={"css"}
+type ClusterSippScenario struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SippScenarioSpec   `json:"spec,omitempty"`
+	Status SippScenarioStatus `json:"status,omitempty"`
+}
+
+// ToSippScenario returns the scenario as used by a run of the namespace
+func (f *ClusterSippScenario) ToSippScenario(namespace string) *SippScenario {
+	return &SippScenario{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       f.Name,
+			Namespace:  namespace,
+			Generation: f.Generation,
+		},
+		Spec:   *f.Spec.DeepCopy(),
+		Status: *f.Status.DeepCopy(),
+	}
+}
+
+// Validate checks the Spec like the one of a SippScenario,
+// and rejects the files stored in namespaced resources
+func (f *ClusterSippScenario) Validate() error {
+	allErrs := field.ErrorList{}
+	specPath := field.NewPath("spec")
+
+	if err := f.ToSippScenario("").Validate(); err != nil {
+		return err
+	}
+
+	if f.Spec.ScenarioFrom != nil {
+		allErrs = append(allErrs, f.Spec.ScenarioFrom.validateClusterScoped(specPath.Child("scenarioFrom"))...)
+	}
+	for i := range f.Spec.InjectValues {
+		if f.Spec.InjectValues[i].From != nil {
+			allErrs = append(allErrs, f.Spec.InjectValues[i].From.validateClusterScoped(specPath.Child("injectValues").Index(i).Child("from"))...)
+		}
+	}
+
+	return allErrs.ToAggregate()
+}
+
+func (s *ContentSource) validateClusterScoped(sourcePath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if s.ConfigMapKeyRef != nil {
+		allErrs = append(allErrs, field.Forbidden(sourcePath.Child("configMapKeyRef"), "a ClusterSippScenario has no namespace to read ConfigMaps from"))
+	}
+	if s.SecretKeyRef != nil {
+		allErrs = append(allErrs, field.Forbidden(sourcePath.Child("secretKeyRef"), "a ClusterSippScenario has no namespace to read Secrets from"))
+	}
+
+	return allErrs
+}
+
+// +kubebuilder:object:root=true
+
+// ClusterSippScenarioList contains a list of ClusterSippScenario
+type ClusterSippScenarioList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterSippScenario `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterSippScenario{}, &ClusterSippScenarioList{})
+}
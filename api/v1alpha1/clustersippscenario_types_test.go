@@ -0,0 +1,54 @@
+package v1alpha1_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestClusterSippScenario(t *testing.T) {
+	checksum := "sha256:" + strings.Repeat("a", 64)
+
+	clusterScenario := &v1alpha1.ClusterSippScenario{
+		ObjectMeta: metav1.ObjectMeta{Name: "options-ping"},
+		Spec: v1alpha1.SippScenarioSpec{
+			ScenarioFrom: &v1alpha1.ContentSource{
+				URL: &v1alpha1.URLSource{URL: "https://example.com/options.xml", Checksum: checksum},
+			},
+		},
+	}
+	assert.NoError(t, clusterScenario.Validate())
+
+	scenario := clusterScenario.ToSippScenario("team-a")
+	assert.Equal(t, "options-ping", scenario.Name)
+	assert.Equal(t, "team-a", scenario.Namespace)
+	assert.Equal(t, clusterScenario.Spec, scenario.Spec)
+
+	// The scenario of the run is a copy
+	scenario.Spec.ScenarioFrom.URL.URL = "https://example.com/other.xml"
+	assert.Equal(t, "https://example.com/options.xml", clusterScenario.Spec.ScenarioFrom.URL.URL)
+
+	clusterScenario.Spec.InjectValues = []v1alpha1.InjectionFile{
+		{From: &v1alpha1.ContentSource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "users"},
+			Key:                  "users.csv",
+		}}},
+	}
+	assert.NoError(t, clusterScenario.ToSippScenario("team-a").Validate())
+	assert.Error(t, clusterScenario.Validate())
+}
+
+func TestScenarioReference(t *testing.T) {
+	var ref *v1alpha1.ScenarioReference
+	assert.False(t, ref.IsClusterScoped())
+
+	ref = &v1alpha1.ScenarioReference{Name: "uac"}
+	assert.False(t, ref.IsClusterScoped())
+
+	ref.Kind = v1alpha1.ScenarioKindClusterSippScenario
+	assert.True(t, ref.IsClusterScoped())
+}
@@ -0,0 +1,116 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConditionType defines the type of a condition
+type ConditionType string
+
+const (
+	// ConditionValid reports whether the resource spec has been accepted
+	ConditionValid ConditionType = "Valid"
+	// ConditionExtraArgsConflict reports whether extra arguments repeat flags
+	// already generated by the operator, in which case sipp uses the last value
+	ConditionExtraArgsConflict ConditionType = "ExtraArgsConflict"
+	// ConditionUploaded reports whether the artifacts of a finished run
+	// have been uploaded to the object storage
+	ConditionUploaded ConditionType = "Uploaded"
+	// ConditionFinished reports whether the run is over,
+	// its reason tells whether it succeeded or failed
+	ConditionFinished ConditionType = "Finished"
+	// ConditionPruned reports whether the run resources have been
+	// garbage-collected by the namespace history limits
+	ConditionPruned ConditionType = "Pruned"
+	// ConditionSuspended reports whether the traffic of the run is paused
+	ConditionSuspended ConditionType = "Suspended"
+	// ConditionStopped reports whether the run has been asked to stop gracefully
+	ConditionStopped ConditionType = "Stopped"
+	// ConditionReferenceGranted reports whether a SippScenarioGrant allows the run
+	// to use the scenario of another namespace
+	ConditionReferenceGranted ConditionType = "ReferenceGranted"
+	// ConditionMetricsCollected reports whether the metrics of a finished run
+	// have been read from its statistics traces
+	ConditionMetricsCollected ConditionType = "MetricsCollected"
+	// ConditionRegressed reports whether the metrics of the run degraded
+	// beyond the tolerances compared to its baseline
+	ConditionRegressed ConditionType = "Regressed"
+	// ConditionUnschedulable reports whether sipp instances of the run can't be
+	// scheduled, such as host network instances beyond the available nodes
+	ConditionUnschedulable ConditionType = "Unschedulable"
+)
+
+// Condition describes the state of a resource at a certain point
+type Condition struct {
+	// Type of the condition
+	Type ConditionType `json:"type"`
+	// Status of the condition, one of True, False, Unknown
+	Status corev1.ConditionStatus `json:"status"`
+	// Last time the condition transitioned from one status to another
+	// +optional
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	// Reason is a one-word CamelCase reason for the condition's last transition
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// Message is a human readable message indicating details about the transition
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// FindCondition returns the condition of the given type
+// or nil if it is not in the list
+func FindCondition(conditions []Condition, conditionType ConditionType) *Condition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+// SetCondition adds the condition to the list, or updates the existing one
+// with the same type. LastTransitionTime only changes when the status does.
+func SetCondition(conditions *[]Condition, condition Condition) {
+	existing := FindCondition(*conditions, condition.Type)
+	if existing == nil {
+		if condition.LastTransitionTime.IsZero() {
+			condition.LastTransitionTime = metav1.Now()
+		}
+		*conditions = append(*conditions, condition)
+		return
+	}
+
+	if existing.Status != condition.Status {
+		existing.Status = condition.Status
+		existing.LastTransitionTime = condition.LastTransitionTime
+		if existing.LastTransitionTime.IsZero() {
+			existing.LastTransitionTime = metav1.Now()
+		}
+	}
+	existing.Reason = condition.Reason
+	existing.Message = condition.Message
+}
+
+// IsConditionTrue returns whether the condition of the given type is
+// present and has a True status
+func IsConditionTrue(conditions []Condition, conditionType ConditionType) bool {
+	condition := FindCondition(conditions, conditionType)
+	return condition != nil && condition.Status == corev1.ConditionTrue
+}
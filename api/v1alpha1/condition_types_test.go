@@ -0,0 +1,52 @@
+package v1alpha1_test
+
+import (
+	"testing"
+
+	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestSetCondition(t *testing.T) {
+	conditions := []v1alpha1.Condition{}
+
+	v1alpha1.SetCondition(&conditions, v1alpha1.Condition{
+		Type:   v1alpha1.ConditionValid,
+		Status: corev1.ConditionTrue,
+	})
+	assert.Len(t, conditions, 1)
+	assert.True(t, v1alpha1.IsConditionTrue(conditions, v1alpha1.ConditionValid))
+
+	transition := conditions[0].LastTransitionTime
+	assert.False(t, transition.IsZero())
+
+	// Same status: only reason and message are updated
+	v1alpha1.SetCondition(&conditions, v1alpha1.Condition{
+		Type:    v1alpha1.ConditionValid,
+		Status:  corev1.ConditionTrue,
+		Reason:  "Accepted",
+		Message: "ok",
+	})
+	assert.Len(t, conditions, 1)
+	assert.Equal(t, "Accepted", conditions[0].Reason)
+	assert.Equal(t, transition, conditions[0].LastTransitionTime)
+
+	v1alpha1.SetCondition(&conditions, v1alpha1.Condition{
+		Type:   v1alpha1.ConditionValid,
+		Status: corev1.ConditionFalse,
+		Reason: "ValidationFailed",
+	})
+	assert.Len(t, conditions, 1)
+	assert.False(t, v1alpha1.IsConditionTrue(conditions, v1alpha1.ConditionValid))
+	assert.Equal(t, "", conditions[0].Message)
+}
+
+func TestFindCondition(t *testing.T) {
+	conditions := []v1alpha1.Condition{
+		{Type: v1alpha1.ConditionValid, Status: corev1.ConditionFalse},
+	}
+
+	assert.NotNil(t, v1alpha1.FindCondition(conditions, v1alpha1.ConditionValid))
+	assert.Nil(t, v1alpha1.FindCondition(conditions, "Unknown"))
+}
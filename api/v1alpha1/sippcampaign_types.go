@@ -0,0 +1,287 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// DefaultCampaignConcurrency is the number of runs of a campaign executed at once by default
+const DefaultCampaignConcurrency = 1
+
+// CampaignMatrix defines the axes a campaign expands its template over.
+// A run is created for each combination of the values of the axes which are set.
+type CampaignMatrix struct {
+	// ScenarioRefs replace the scenarioRef of the template
+	// +optional
+	ScenarioRefs []ScenarioReference `json:"scenarioRefs,omitempty"`
+	// Transports replace the transport of the template
+	// +optional
+	Transports []Transport `json:"transports,omitempty"`
+	// Rates replace the rate of the template, in calls per second
+	// +optional
+	Rates []int32 `json:"rates,omitempty"`
+	// Destinations replace the destination of the template
+	// +optional
+	Destinations []string `json:"destinations,omitempty"`
+}
+
+// SippCampaignSpec defines the desired state of SippCampaign
+type SippCampaignSpec struct {
+	// Template is the spec of the runs of the campaign, before the matrix is applied
+	Template SippScenarioRunSpec `json:"template"`
+	// Matrix is the set of values the runs are expanded over
+	Matrix CampaignMatrix `json:"matrix"`
+	// Concurrency is the maximum number of runs executed at once, defaults to 1
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Concurrency *int32 `json:"concurrency,omitempty"`
+}
+
+// CampaignEntry is a combination of the matrix values, applied to the template
+type CampaignEntry struct {
+	ScenarioRef *ScenarioReference
+	Transport   *Transport
+	Rate        *int32
+	Destination *string
+}
+
+// CampaignPhase is the phase of a campaign or of one of its runs
+type CampaignPhase string
+
+const (
+	// CampaignPhasePending is the phase of a run waiting for a free slot
+	CampaignPhasePending CampaignPhase = "Pending"
+	// CampaignPhaseRunning is the phase of a run in progress
+	CampaignPhaseRunning CampaignPhase = "Running"
+	// CampaignPhaseSucceeded is the phase of a run which succeeded
+	CampaignPhaseSucceeded CampaignPhase = "Succeeded"
+	// CampaignPhaseFailed is the phase of a run which failed
+	CampaignPhaseFailed CampaignPhase = "Failed"
+)
+
+// CampaignResult is the row of a run in the comparison table of a campaign
+type CampaignResult struct {
+	// Run is the name of the run
+	Run string `json:"run"`
+	// Scenario is the name of the scenario used by the run
+	Scenario string `json:"scenario"`
+	// Transport is the protocol and socket mode of the run
+	// +optional
+	Transport string `json:"transport,omitempty"`
+	// Rate is the call rate of the run
+	// +optional
+	Rate *int32 `json:"rate,omitempty"`
+	// Destination is the destination of the run
+	// +optional
+	Destination string `json:"destination,omitempty"`
+	// Phase of the run
+	Phase CampaignPhase `json:"phase"`
+	// Succeeded is the number of sipp instances which succeeded
+	// +optional
+	Succeeded int32 `json:"succeeded,omitempty"`
+	// Failed is the number of sipp instances which failed
+	// +optional
+	Failed int32 `json:"failed,omitempty"`
+	// Attempts is the number of executions of the run
+	// +optional
+	Attempts int32 `json:"attempts,omitempty"`
+	// ExitCode is the sipp exit code of the last attempt, when it failed
+	// +optional
+	ExitCode *int32 `json:"exitCode,omitempty"`
+	// Metrics are the key metrics of the run, collected when its statistics are traced
+	// +optional
+	Metrics *RunMetrics `json:"metrics,omitempty"`
+	// Message explains why the run failed
+	// +optional
+	Message string `json:"message,omitempty"`
+	// StartTime is the time the run was created at
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// CompletionTime is the time the run finished at
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// SippCampaignStatus defines the observed state of SippCampaign
+type SippCampaignStatus struct {
+	// Phase of the campaign
+	// +optional
+	Phase CampaignPhase `json:"phase,omitempty"`
+	// Total is the number of runs of the campaign
+	// +optional
+	Total int32 `json:"total,omitempty"`
+	// Running is the number of runs in progress
+	// +optional
+	Running int32 `json:"running,omitempty"`
+	// Succeeded is the number of runs which succeeded
+	// +optional
+	Succeeded int32 `json:"succeeded,omitempty"`
+	// Failed is the number of runs which failed
+	// +optional
+	Failed int32 `json:"failed,omitempty"`
+	// Results is the comparison table of the runs, in the order of the matrix
+	// +optional
+	Results []CampaignResult `json:"results,omitempty"`
+	// Conditions holds the latest observations of the campaign's state
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// SippCampaign is the Schema for the sippcampaigns API
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Total",type="integer",JSONPath=".status.total"
+// +kubebuilder:printcolumn:name="Running",type="integer",JSONPath=".status.running"
+// +kubebuilder:printcolumn:name="Succeeded",type="integer",JSONPath=".status.succeeded"
+// +kubebuilder:printcolumn:name="Failed",type="integer",JSONPath=".status.failed"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:resource:shortName={"sc"}
+type SippCampaign struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SippCampaignSpec   `json:"spec,omitempty"`
+	Status SippCampaignStatus `json:"status,omitempty"`
+}
+
+// GetConcurrency returns the maximum number of runs executed at once
+func (c *SippCampaign) GetConcurrency() int32 {
+	if c.Spec.Concurrency == nil {
+		return DefaultCampaignConcurrency
+	}
+	return *c.Spec.Concurrency
+}
+
+// Entries returns the combinations of the matrix values,
+// ordered by scenario, transport, rate and destination
+func (c *SippCampaign) Entries() []CampaignEntry {
+	matrix := c.Spec.Matrix
+	entries := []CampaignEntry{{}}
+
+	expand := func(count int, set func(entry *CampaignEntry, index int)) {
+		if count == 0 {
+			return
+		}
+		expanded := make([]CampaignEntry, 0, len(entries)*count)
+		for _, entry := range entries {
+			for i := 0; i < count; i++ {
+				next := entry
+				set(&next, i)
+				expanded = append(expanded, next)
+			}
+		}
+		entries = expanded
+	}
+
+	expand(len(matrix.ScenarioRefs), func(entry *CampaignEntry, i int) { entry.ScenarioRef = &matrix.ScenarioRefs[i] })
+	expand(len(matrix.Transports), func(entry *CampaignEntry, i int) { entry.Transport = &matrix.Transports[i] })
+	expand(len(matrix.Rates), func(entry *CampaignEntry, i int) { entry.Rate = &matrix.Rates[i] })
+	expand(len(matrix.Destinations), func(entry *CampaignEntry, i int) { entry.Destination = &matrix.Destinations[i] })
+
+	return entries
+}
+
+// RunName returns the name of the run of the entry at the index
+func (c *SippCampaign) RunName(index int) string {
+	return fmt.Sprintf("%s-%d", c.Name, index)
+}
+
+// RunSpec returns the template with the values of the entry applied
+func (c *SippCampaign) RunSpec(entry CampaignEntry) *SippScenarioRunSpec {
+	spec := c.Spec.Template.DeepCopy()
+	if entry.ScenarioRef != nil {
+		spec.ScenarioRef = entry.ScenarioRef.DeepCopy()
+	}
+	if entry.Transport != nil {
+		spec.Transport = entry.Transport.DeepCopy()
+	}
+	if entry.Rate != nil {
+		rate := *entry.Rate
+		spec.Rate = &rate
+	}
+	if entry.Destination != nil {
+		spec.Destination = *entry.Destination
+	}
+	return spec
+}
+
+// Result returns the row of the entry in the comparison table, before its run starts
+func (c *SippCampaign) Result(index int, entry CampaignEntry) CampaignResult {
+	spec := c.RunSpec(entry)
+	result := CampaignResult{
+		Run:         c.RunName(index),
+		Destination: spec.Destination,
+		Phase:       CampaignPhasePending,
+	}
+	if spec.ScenarioRef != nil {
+		result.Scenario = spec.ScenarioRef.Name
+	}
+	if spec.Transport != nil {
+		result.Transport = spec.Transport.String()
+	}
+	if spec.Rate != nil {
+		rate := *spec.Rate
+		result.Rate = &rate
+	}
+	return result
+}
+
+// Validate checks each run of the campaign is valid
+func (c *SippCampaign) Validate() error {
+	allErrs := field.ErrorList{}
+	matrixPath := field.NewPath("spec", "matrix")
+
+	for i, rate := range c.Spec.Matrix.Rates {
+		if rate < 0 {
+			allErrs = append(allErrs, field.Invalid(matrixPath.Child("rates").Index(i), rate, "must not be negative"))
+		}
+	}
+	if len(allErrs) > 0 {
+		return allErrs.ToAggregate()
+	}
+
+	for i, entry := range c.Entries() {
+		run := &SippScenarioRun{
+			ObjectMeta: metav1.ObjectMeta{Name: c.RunName(i), Namespace: c.Namespace},
+			Spec:       *c.RunSpec(entry),
+		}
+		if err := run.Validate(); err != nil {
+			allErrs = append(allErrs, field.Invalid(matrixPath, run.Name, err.Error()))
+		}
+	}
+
+	return allErrs.ToAggregate()
+}
+
+// +kubebuilder:object:root=true
+
+// SippCampaignList contains a list of SippCampaign
+type SippCampaignList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SippCampaign `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SippCampaign{}, &SippCampaignList{})
+}
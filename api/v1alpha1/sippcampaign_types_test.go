@@ -0,0 +1,81 @@
+package v1alpha1_test
+
+import (
+	"testing"
+
+	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+func TestSippCampaignEntries(t *testing.T) {
+	campaign := &v1alpha1.SippCampaign{
+		ObjectMeta: metav1.ObjectMeta{Name: "qualification", Namespace: "default"},
+		Spec: v1alpha1.SippCampaignSpec{
+			Template: v1alpha1.SippScenarioRunSpec{
+				ScenarioRef: &v1alpha1.ScenarioReference{Name: "uac"},
+				Destination: "sbc:5060",
+				Rate:        pointer.Int32Ptr(1),
+			},
+			Matrix: v1alpha1.CampaignMatrix{
+				Transports: []v1alpha1.Transport{
+					{Protocol: v1alpha1.ProtocolUDP, Socket: v1alpha1.SocketOne},
+					{Protocol: v1alpha1.ProtocolTCP, Socket: v1alpha1.SocketOne},
+					{Protocol: v1alpha1.ProtocolTLS, Socket: v1alpha1.SocketOnePerCall},
+				},
+				Rates: []int32{10, 50, 100},
+			},
+		},
+	}
+
+	entries := campaign.Entries()
+	assert.Len(t, entries, 9)
+	assert.Equal(t, v1alpha1.DefaultCampaignConcurrency, int(campaign.GetConcurrency()))
+
+	spec := campaign.RunSpec(entries[4])
+	assert.Equal(t, v1alpha1.ProtocolTCP, spec.Transport.Protocol)
+	assert.Equal(t, int32(50), *spec.Rate)
+	assert.Equal(t, "sbc:5060", spec.Destination)
+	assert.Equal(t, "uac", spec.ScenarioRef.Name)
+	assert.Equal(t, int32(1), *campaign.Spec.Template.Rate, "the template must be left untouched")
+
+	result := campaign.Result(8, entries[8])
+	assert.Equal(t, v1alpha1.CampaignResult{
+		Run:         "qualification-8",
+		Scenario:    "uac",
+		Transport:   "TLS/OnePerCall",
+		Rate:        pointer.Int32Ptr(100),
+		Destination: "sbc:5060",
+		Phase:       v1alpha1.CampaignPhasePending,
+	}, result)
+	assert.NoError(t, campaign.Validate())
+
+	// Without axes, the campaign runs the template once
+	campaign.Spec.Matrix = v1alpha1.CampaignMatrix{}
+	assert.Len(t, campaign.Entries(), 1)
+}
+
+func TestValidateSippCampaign(t *testing.T) {
+	campaign := &v1alpha1.SippCampaign{
+		ObjectMeta: metav1.ObjectMeta{Name: "qualification", Namespace: "default"},
+		Spec: v1alpha1.SippCampaignSpec{
+			Template: v1alpha1.SippScenarioRunSpec{
+				ScenarioRef: &v1alpha1.ScenarioReference{Name: "uac"},
+			},
+			Matrix: v1alpha1.CampaignMatrix{
+				ScenarioRefs: []v1alpha1.ScenarioReference{
+					{Name: "uac"},
+					{Kind: v1alpha1.ScenarioKindClusterSippScenario, Name: "register", Namespace: "sipp"},
+				},
+			},
+		},
+	}
+	assert.Error(t, campaign.Validate())
+
+	campaign.Spec.Matrix.ScenarioRefs[1].Namespace = ""
+	assert.NoError(t, campaign.Validate())
+
+	campaign.Spec.Matrix.Rates = []int32{-1}
+	assert.Error(t, campaign.Validate())
+}
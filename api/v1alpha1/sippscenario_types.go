@@ -17,31 +17,388 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 )
 
+const (
+	// ScenarioFilename is the filename of the scenario file once mounted
+	ScenarioFilename = "scenario.xml"
+)
+
+var (
+	checksumRegexp      = regexp.MustCompile(`^sha256:[0-9a-f]{64}$`)
+	parameterNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// PersistentVolumeClaimFileSelector selects a file stored in a PersistentVolumeClaim
+type PersistentVolumeClaimFileSelector struct {
+	// ClaimName is the name of a PersistentVolumeClaim in the scenario's namespace
+	ClaimName string `json:"claimName"`
+	// Path of the file, relative to the root of the volume
+	Path string `json:"path"`
+}
+
+// MediaAsset is a media file (pcap, wav) played by the scenario
+// using the play_pcap_audio, play_pcap_video or rtp_stream actions.
+// Exactly one source must be set.
+type MediaAsset struct {
+	// Name of the file, as referenced by the scenario actions
+	Name string `json:"name"`
+	// ConfigMapKeyRef selects a key of a ConfigMap,
+	// binary files should be stored in its binaryData field
+	// +optional
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+	// SecretKeyRef selects a key of a Secret
+	// +optional
+	SecretKeyRef *corev1.SecretKeySelector `json:"secretKeyRef,omitempty"`
+	// PersistentVolumeClaim selects a file stored in a PersistentVolumeClaim
+	// +optional
+	PersistentVolumeClaim *PersistentVolumeClaimFileSelector `json:"persistentVolumeClaim,omitempty"`
+}
+
+// URLSource selects file content served over HTTP(S)
+type URLSource struct {
+	// URL of the file, only the http and https schemes are supported
+	URL string `json:"url"`
+	// Checksum of the file, formatted as sha256:<hex digest>
+	// The content is rejected if it doesn't match
+	Checksum string `json:"checksum"`
+}
+
+// ContentSource selects file content stored outside of the SippScenario.
+// Exactly one source must be set.
+type ContentSource struct {
+	// ConfigMapKeyRef selects a key of a ConfigMap in the scenario's namespace
+	// +optional
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+	// SecretKeyRef selects a key of a Secret in the scenario's namespace
+	// +optional
+	SecretKeyRef *corev1.SecretKeySelector `json:"secretKeyRef,omitempty"`
+	// URL selects a file served over HTTP(S)
+	// +optional
+	URL *URLSource `json:"url,omitempty"`
+}
+
+// InjectionMode defines the order in which sipp uses the rows of an injection file
+// +kubebuilder:validation:Enum=Sequential;Random;User
+type InjectionMode string
+
+const (
+	// InjectionModeSequential uses the rows in order, one per call
+	InjectionModeSequential InjectionMode = "Sequential"
+	// InjectionModeRandom picks a random row for each call
+	InjectionModeRandom InjectionMode = "Random"
+	// InjectionModeUser uses the rows in order, one per user of a -users run
+	InjectionModeUser InjectionMode = "User"
+)
+
+// InjectionFile is a CSV file injecting values into the scenario,
+// its fields are referenced in the scenario as [fieldN]
+type InjectionFile struct {
+	// Mode defines the order in which the rows are used, defaults to Sequential
+	// +optional
+	Mode InjectionMode `json:"mode,omitempty"`
+	// Columns names the fields of the rows, all the rows must have this number of fields
+	// +optional
+	Columns []string `json:"columns,omitempty"`
+	// Rows holds the fields of each row
+	// +optional
+	Rows [][]string `json:"rows,omitempty"`
+	// From loads the rows from another resource or an URL, as a sipp injection file.
+	// The mode of its header line is used unless Mode is set, the header may be omitted.
+	// It is an alternative to Rows.
+	// +optional
+	From *ContentSource `json:"from,omitempty"`
+	// Index is the field sipp indexes the rows by, for the lookup action.
+	// See the -infindex parameter documentation
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Index *int32 `json:"index,omitempty"`
+
+	// legacyOptions are the header options of the raw content the file was read from,
+	// they are checked as they can't all be stored with the rows
+	legacyOptions string
+}
+
+// ColumnCount returns the number of fields of the rows,
+// or 0 if it isn't known yet
+func (i *InjectionFile) ColumnCount() int {
+	if len(i.Columns) > 0 {
+		return len(i.Columns)
+	}
+	if len(i.Rows) > 0 {
+		return len(i.Rows[0])
+	}
+	return 0
+}
+
+// Render returns the content of the file read by sipp:
+// the mode header followed by the semicolon separated rows
+func (i *InjectionFile) Render() string {
+	mode := i.Mode
+	if mode == "" {
+		mode = InjectionModeSequential
+	}
+
+	content := &strings.Builder{}
+	content.WriteString(strings.ToUpper(string(mode)))
+	content.WriteString("\n")
+	for _, row := range i.Rows {
+		content.WriteString(strings.Join(row, ";"))
+		content.WriteString("\n")
+	}
+
+	return content.String()
+}
+
+// injectionModes are the modes of the header line of the sipp injection files
+var injectionModes = map[string]InjectionMode{
+	"SEQUENTIAL": InjectionModeSequential,
+	"RANDOM":     InjectionModeRandom,
+	"USER":       InjectionModeUser,
+}
+
+// ParseInjectionContent returns the injection file of the content of a sipp CSV file,
+// and its header line: the mode, optionally followed by comma separated options.
+// The header is empty when the content starts with a row.
+// Blank lines and comments are skipped, like sipp does.
+func ParseInjectionContent(content string) (*InjectionFile, string) {
+	file := &InjectionFile{Rows: [][]string{}}
+	header := ""
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if header == "" && len(file.Rows) == 0 {
+			options := strings.Split(strings.TrimSpace(line), ",")
+			if mode, ok := injectionModes[strings.ToUpper(options[0])]; ok {
+				header = strings.TrimSpace(line)
+				file.Mode = mode
+				continue
+			}
+		}
+
+		// sipp accepts a trailing separator at the end of the lines
+		file.Rows = append(file.Rows, strings.Split(strings.TrimSuffix(line, ";"), ";"))
+	}
+
+	return file, header
+}
+
+// CheckInjectionHeader rejects the header options the rows can't be stored with
+func CheckInjectionHeader(header string) error {
+	// The PRINTF options generate rows sipp side, the rows are stored as is instead
+	if strings.Contains(strings.ToUpper(header), "PRINTF") {
+		return fmt.Errorf("the PRINTF option is not supported")
+	}
+	return nil
+}
+
+// UnmarshalJSON reads an injection file, or the raw content of a sipp CSV file
+// the injectValues of the SippScenarios created before structured injection files held
+func (i *InjectionFile) UnmarshalJSON(data []byte) error {
+	var content string
+	if err := json.Unmarshal(data, &content); err == nil {
+		file, header := ParseInjectionContent(content)
+		*i = *file
+		if parts := strings.SplitN(header, ",", 2); len(parts) == 2 {
+			i.legacyOptions = parts[1]
+		}
+		return nil
+	}
+
+	type injectionFile InjectionFile
+	return json.Unmarshal(data, (*injectionFile)(i))
+}
+
+func (i *InjectionFile) validate(path *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	switch {
+	case len(i.Rows) > 0 && i.From != nil:
+		allErrs = append(allErrs, field.Forbidden(path.Child("from"), "rows and from are mutually exclusive"))
+	case len(i.Rows) == 0 && i.From == nil:
+		allErrs = append(allErrs, field.Required(path.Child("rows"), "rows or from must be set"))
+	}
+
+	if i.From != nil {
+		allErrs = append(allErrs, i.From.validate(path.Child("from"))...)
+	}
+
+	if err := CheckInjectionHeader(i.legacyOptions); err != nil {
+		allErrs = append(allErrs, field.Invalid(path, i.legacyOptions, err.Error()))
+	}
+
+	columns := i.ColumnCount()
+	for r, row := range i.Rows {
+		rowPath := path.Child("rows").Index(r)
+		if len(row) != columns {
+			allErrs = append(allErrs, field.Invalid(rowPath, strings.Join(row, ";"), fmt.Sprintf("has %d fields, expected %d", len(row), columns)))
+		}
+		for c, value := range row {
+			if strings.ContainsAny(value, ";\r\n") {
+				allErrs = append(allErrs, field.Invalid(rowPath.Index(c), value, "must not contain semicolons or line breaks"))
+			}
+		}
+	}
+
+	if i.Index != nil && columns > 0 && int(*i.Index) >= columns {
+		allErrs = append(allErrs, field.Invalid(path.Child("index"), *i.Index, fmt.Sprintf("must be lower than the number of fields (%d)", columns)))
+	}
+
+	return allErrs
+}
+
+// ParameterType defines the type of a scenario parameter
+// +kubebuilder:validation:Enum=String;Integer;Boolean
+type ParameterType string
+
+const (
+	// ParameterTypeString is a free form parameter
+	ParameterTypeString ParameterType = "String"
+	// ParameterTypeInteger is an integer parameter
+	ParameterTypeInteger ParameterType = "Integer"
+	// ParameterTypeBoolean is a true or false parameter
+	ParameterTypeBoolean ParameterType = "Boolean"
+)
+
+// ScenarioParameter is a value supplied by each SippScenarioRun,
+// used in the scenario file as a {{ .name }} template placeholder
+type ScenarioParameter struct {
+	// Name of the parameter, it must be a valid template identifier
+	Name string `json:"name"`
+	// Type of the parameter, defaults to String
+	// +optional
+	Type ParameterType `json:"type,omitempty"`
+	// Default is the value used when the run doesn't supply one.
+	// Parameters without default are required.
+	// +optional
+	Default *string `json:"default,omitempty"`
+	// Description of the parameter
+	// +optional
+	Description string `json:"description,omitempty"`
+	// Raw inserts the value in the scenario file as is, so that it can hold XML markup.
+	// Other values are XML-escaped, raw values fit CDATA sections where escaping would show.
+	// +optional
+	Raw bool `json:"raw,omitempty"`
+}
+
 // SippScenarioSpec defines the desired state of SippScenario
 type SippScenarioSpec struct {
 	// ScenarioFileContent
 	// See the -sf parameter documentation
-	ScenarioFileContent string `json:"scenarioFileContent"`
-	// InjectValues is the file content which allow to values from an external CSV file during calls into the scenarios.
+	// +optional
+	ScenarioFileContent string `json:"scenarioFileContent,omitempty"`
+	// ScenarioFrom loads the scenario file content from another resource or an URL,
+	// it is an alternative to ScenarioFileContent
+	// +optional
+	ScenarioFrom *ContentSource `json:"scenarioFrom,omitempty"`
+	// InjectValues are the CSV files injecting values into the scenario during calls.
 	// See the -inf parameter documentation
 	// +optional
-	InjectValues []string `json:"injectValues"`
+	InjectValues []InjectionFile `json:"injectValues,omitempty"`
+	// Parameters are declared to render the scenario file as a template,
+	// with the values supplied by each SippScenarioRun
+	// +optional
+	Parameters []ScenarioParameter `json:"parameters,omitempty"`
+	// MediaAssets are mounted beside the scenario file, and sipp runs from this directory
+	// so that the scenario actions can reference them by name
+	// +optional
+	MediaAssets []MediaAsset `json:"mediaAssets,omitempty"`
+}
+
+// MessageDirection defines whether a message is sent or received by sipp
+type MessageDirection string
+
+const (
+	// MessageDirectionSend is a message sent by sipp
+	MessageDirectionSend MessageDirection = "Send"
+	// MessageDirectionRecv is a message received by sipp
+	MessageDirectionRecv MessageDirection = "Recv"
+)
+
+// ScenarioMessage is a SIP message sent or received by the scenario
+type ScenarioMessage struct {
+	Direction MessageDirection `json:"direction"`
+	// Method of the request, empty for responses
+	// +optional
+	Method string `json:"method,omitempty"`
+	// ResponseCode of the response, empty for requests
+	// +optional
+	ResponseCode int32 `json:"responseCode,omitempty"`
+	// Optional is set for messages which may not be received
+	// +optional
+	Optional bool `json:"optional,omitempty"`
+}
+
+// ScenarioSummary describes what a scenario does
+type ScenarioSummary struct {
+	// Name of the scenario, as declared in the scenario file
+	// +optional
+	Name string `json:"name,omitempty"`
+	// Messages is the sequence of SIP messages of the scenario
+	// +optional
+	Messages []ScenarioMessage `json:"messages,omitempty"`
+	// MessageCount is the number of messages in the sequence
+	MessageCount int32 `json:"messageCount"`
+	// Variables are the names of the variables used by the scenario
+	// +optional
+	Variables []string `json:"variables,omitempty"`
+	// Fields are the [fieldN] indices referenced by the scenario
+	// +optional
+	Fields []int32 `json:"fields,omitempty"`
+	// FieldCount is the number of distinct [fieldN] indices referenced by the scenario
+	FieldCount int32 `json:"fieldCount"`
+	// Keywords are the sipp keywords used by the scenario, such as service or remote_ip
+	// +optional
+	Keywords []string `json:"keywords,omitempty"`
+	// InjectValuesColumns is the number of columns of each inject values file
+	// +optional
+	InjectValuesColumns []int32 `json:"injectValuesColumns,omitempty"`
+}
+
+// SippScenarioStatus defines the observed state of SippScenario
+type SippScenarioStatus struct {
+	// ObservedGeneration is the generation of the spec observed by the controller
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions holds the latest observations of the scenario's state
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
+	// Summary describes the scenario file content
+	// +optional
+	Summary *ScenarioSummary `json:"summary,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 
 // SippScenario is the Schema for the sippscenarios API
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Valid",type="string",JSONPath=".status.conditions[?(@.type==\"Valid\")].status"
+// +kubebuilder:printcolumn:name="Scenario",type="string",JSONPath=".status.summary.name",priority=1
+// +kubebuilder:printcolumn:name="Messages",type="integer",JSONPath=".status.summary.messageCount"
+// +kubebuilder:printcolumn:name="Fields",type="integer",JSONPath=".status.summary.fieldCount"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 // +kubebuilder:resource:shortName={"ss"}
 type SippScenario struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	Spec SippScenarioSpec `json:"spec,omitempty"`
+	Spec   SippScenarioSpec   `json:"spec,omitempty"`
+	Status SippScenarioStatus `json:"status,omitempty"`
 }
 
 // GetInjectedValueFilename returns the filename of the inject values file
@@ -50,28 +407,287 @@ func (f *SippScenario) GetInjectedValueFilename(i int) string {
 	return fmt.Sprintf("values_%d.csv", i)
 }
 
+// HasScenarioFile returns whether the scenario file is set,
+// either inline or from another source
+func (f *SippScenario) HasScenarioFile() bool {
+	return f.Spec.ScenarioFileContent != "" || f.Spec.ScenarioFrom != nil
+}
+
 // ToSippArgs returns the Sipp Args from the Spec
 // This function asserts that the Spec is clean (no unknown values)
 func (f *SippScenario) ToSippArgs(basePath string) []string {
 	result := []string{}
 
-	if f.Spec.ScenarioFileContent != "" {
+	if f.HasScenarioFile() {
 		result = append(result, "-sf")
-		result = append(result, fmt.Sprintf("%s/scenario.xml", basePath))
+		result = append(result, fmt.Sprintf("%s/%s", basePath, ScenarioFilename))
 	}
 
-	if len(f.Spec.InjectValues) > 0 {
-		for i := range f.Spec.InjectValues {
-			path := fmt.Sprintf("%s/%s", basePath, f.GetInjectedValueFilename(i))
+	for i, values := range f.Spec.InjectValues {
+		path := fmt.Sprintf("%s/%s", basePath, f.GetInjectedValueFilename(i))
+
+		result = append(result, "-inf")
+		result = append(result, path)
 
-			result = append(result, "-inf")
+		if values.Index != nil {
+			result = append(result, "-infindex")
 			result = append(result, path)
+			result = append(result, strconv.Itoa(int(*values.Index)))
+		}
+	}
+
+	return result
+}
+
+// PathPlaceholders returns the paths of the mounted files,
+// by the placeholder names a command override can reference
+func (f *SippScenario) PathPlaceholders(basePath string) map[string]string {
+	placeholders := map[string]string{
+		"CONFIG_DIR": basePath,
+	}
+
+	if f.HasScenarioFile() {
+		placeholders["SCENARIO_FILE"] = fmt.Sprintf("%s/%s", basePath, ScenarioFilename)
+	}
+
+	for i := range f.Spec.InjectValues {
+		placeholders[fmt.Sprintf("INJECT_VALUES_FILE_%d", i)] = fmt.Sprintf("%s/%s", basePath, f.GetInjectedValueFilename(i))
+	}
+
+	return placeholders
+}
+
+// Validate checks the Spec for values that can't be mounted for sipp
+func (f *SippScenario) Validate() error {
+	allErrs := field.ErrorList{}
+	specPath := field.NewPath("spec")
+
+	if f.Spec.ScenarioFileContent != "" && f.Spec.ScenarioFrom != nil {
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("scenarioFrom"), "scenarioFileContent and scenarioFrom are mutually exclusive"))
+	}
+
+	if f.Spec.ScenarioFrom != nil {
+		allErrs = append(allErrs, f.Spec.ScenarioFrom.validate(specPath.Child("scenarioFrom"))...)
+	}
+
+	for i := range f.Spec.InjectValues {
+		allErrs = append(allErrs, f.Spec.InjectValues[i].validate(specPath.Child("injectValues").Index(i))...)
+	}
+
+	allErrs = append(allErrs, f.validateParameters(specPath.Child("parameters"))...)
+	allErrs = append(allErrs, f.validateMediaAssets(specPath.Child("mediaAssets"))...)
+
+	return allErrs.ToAggregate()
+}
+
+func (f *SippScenario) validateParameters(parametersPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	names := map[string]bool{}
+	for i, parameter := range f.Spec.Parameters {
+		parameterPath := parametersPath.Index(i)
+
+		if !parameterNameRegexp.MatchString(parameter.Name) {
+			allErrs = append(allErrs, field.Invalid(parameterPath.Child("name"), parameter.Name, "must start with a letter or an underscore, followed by letters, digits or underscores"))
+		} else if names[parameter.Name] {
+			allErrs = append(allErrs, field.Duplicate(parameterPath.Child("name"), parameter.Name))
+		}
+		names[parameter.Name] = true
+
+		if parameter.Default != nil {
+			if _, err := parameter.typedValue(*parameter.Default); err != nil {
+				allErrs = append(allErrs, field.Invalid(parameterPath.Child("default"), *parameter.Default, err.Error()))
+			}
+		}
+	}
+
+	return allErrs
+}
+
+// typedValue converts the value to the parameter type
+func (p *ScenarioParameter) typedValue(value string) (interface{}, error) {
+	switch p.Type {
+	case ParameterTypeInteger:
+		result, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("must be an integer")
+		}
+		return result, nil
+	case ParameterTypeBoolean:
+		result, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("must be a boolean")
+		}
+		return result, nil
+	case ParameterTypeString, "":
+		return value, nil
+	}
+
+	return nil, fmt.Errorf("unknown parameter type %s", p.Type)
+}
+
+// ParameterValues returns the typed values of the scenario parameters,
+// from the values supplied by a run and the parameters defaults.
+// Missing, unknown and malformed values are reported as errors.
+func (f *SippScenario) ParameterValues(values map[string]string) (map[string]interface{}, error) {
+	allErrs := field.ErrorList{}
+	parametersPath := field.NewPath("spec", "parameters")
+	result := map[string]interface{}{}
+
+	declared := map[string]bool{}
+	for _, parameter := range f.Spec.Parameters {
+		declared[parameter.Name] = true
+
+		value, ok := values[parameter.Name]
+		if !ok {
+			if parameter.Default == nil {
+				allErrs = append(allErrs, field.Required(parametersPath.Key(parameter.Name), "parameter has no default value"))
+				continue
+			}
+			value = *parameter.Default
+		}
+
+		typed, err := parameter.typedValue(value)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(parametersPath.Key(parameter.Name), value, err.Error()))
+			continue
 		}
+		result[parameter.Name] = typed
+	}
+
+	unknown := []string{}
+	for name := range values {
+		if !declared[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		allErrs = append(allErrs, field.NotSupported(parametersPath.Key(name), name, nil))
 	}
 
+	if len(allErrs) > 0 {
+		return nil, allErrs.ToAggregate()
+	}
+	return result, nil
+}
+
+// RawParameters returns the names of the parameters inserted in the scenario file as is
+func (f *SippScenario) RawParameters() map[string]bool {
+	result := map[string]bool{}
+	for _, parameter := range f.Spec.Parameters {
+		if parameter.Raw {
+			result[parameter.Name] = true
+		}
+	}
 	return result
 }
 
+// DefaultParameterValues returns the typed defaults of the scenario parameters,
+// parameters without default get the zero value of their type
+func (f *SippScenario) DefaultParameterValues() map[string]interface{} {
+	result := map[string]interface{}{}
+
+	for _, parameter := range f.Spec.Parameters {
+		var typed interface{}
+		switch parameter.Type {
+		case ParameterTypeInteger:
+			typed = int64(0)
+		case ParameterTypeBoolean:
+			typed = false
+		default:
+			typed = ""
+		}
+
+		if parameter.Default != nil {
+			if value, err := parameter.typedValue(*parameter.Default); err == nil {
+				typed = value
+			}
+		}
+		result[parameter.Name] = typed
+	}
+
+	return result
+}
+
+func (s *ContentSource) validate(sourcePath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	sources := 0
+	if s.ConfigMapKeyRef != nil {
+		sources++
+	}
+	if s.SecretKeyRef != nil {
+		sources++
+	}
+	if s.URL != nil {
+		sources++
+		allErrs = append(allErrs, s.URL.validate(sourcePath.Child("url"))...)
+	}
+	if sources != 1 {
+		allErrs = append(allErrs, field.Invalid(sourcePath, "", "exactly one of configMapKeyRef, secretKeyRef or url must be set"))
+	}
+
+	return allErrs
+}
+
+func (s *URLSource) validate(sourcePath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	u, err := url.Parse(s.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		allErrs = append(allErrs, field.Invalid(sourcePath.Child("url"), s.URL, "must be an absolute http or https URL"))
+	}
+
+	if !checksumRegexp.MatchString(s.Checksum) {
+		allErrs = append(allErrs, field.Invalid(sourcePath.Child("checksum"), s.Checksum, "must be formatted as sha256:<hex digest>"))
+	}
+
+	return allErrs
+}
+
+func (f *SippScenario) validateMediaAssets(assetsPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	reserved := map[string]bool{ScenarioFilename: true}
+	for i := range f.Spec.InjectValues {
+		reserved[f.GetInjectedValueFilename(i)] = true
+	}
+
+	names := map[string]bool{}
+	for i, asset := range f.Spec.MediaAssets {
+		assetPath := assetsPath.Index(i)
+
+		switch {
+		case asset.Name == "":
+			allErrs = append(allErrs, field.Required(assetPath.Child("name"), ""))
+		case asset.Name == "." || asset.Name == ".." || strings.Contains(asset.Name, "/"):
+			allErrs = append(allErrs, field.Invalid(assetPath.Child("name"), asset.Name, "must be a plain filename"))
+		case reserved[asset.Name]:
+			allErrs = append(allErrs, field.Invalid(assetPath.Child("name"), asset.Name, "is reserved for the scenario files"))
+		case names[asset.Name]:
+			allErrs = append(allErrs, field.Duplicate(assetPath.Child("name"), asset.Name))
+		}
+		names[asset.Name] = true
+
+		sources := 0
+		if asset.ConfigMapKeyRef != nil {
+			sources++
+		}
+		if asset.SecretKeyRef != nil {
+			sources++
+		}
+		if asset.PersistentVolumeClaim != nil {
+			sources++
+		}
+		if sources != 1 {
+			allErrs = append(allErrs, field.Invalid(assetPath, asset.Name, "exactly one of configMapKeyRef, secretKeyRef or persistentVolumeClaim must be set"))
+		}
+	}
+
+	return allErrs
+}
+
 // +kubebuilder:object:root=true
 
 // SippScenarioList contains a list of SippScenario
@@ -1,23 +1,22 @@
 package v1alpha1_test
 
 import (
+	"encoding/json"
 	"strings"
 	"testing"
 
 	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
 	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/pointer"
 )
 
 func TestToSippArgs(t *testing.T) {
 	scenario := &v1alpha1.SippScenario{
 		Spec: v1alpha1.SippScenarioSpec{
 			ScenarioFileContent: `<scenario name="Basic Sipstone UAC"></scenario>`,
-			InjectValues: []string{
-				`
-				SEQUENTIAL
-				#This line will be ignored
-				Sarah;sipphone32
-				`,
+			InjectValues: []v1alpha1.InjectionFile{
+				{Rows: [][]string{{"Sarah", "sipphone32"}}},
 			},
 		},
 	}
@@ -25,3 +24,334 @@ func TestToSippArgs(t *testing.T) {
 	args := scenario.ToSippArgs("/etc/test")
 	assert.Equal(t, "-sf /etc/test/scenario.xml -inf /etc/test/values_0.csv", strings.Join(args, " "))
 }
+
+func TestValidateMediaAssets(t *testing.T) {
+	configMapRef := &corev1.ConfigMapKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "media"},
+		Key:                  "g711a.pcap",
+	}
+
+	tests := []struct {
+		Name   string
+		Assets []v1alpha1.MediaAsset
+		Valid  bool
+	}{
+		{
+			Name: "configmap and pvc assets",
+			Assets: []v1alpha1.MediaAsset{
+				{Name: "g711a.pcap", ConfigMapKeyRef: configMapRef},
+				{Name: "hello.wav", PersistentVolumeClaim: &v1alpha1.PersistentVolumeClaimFileSelector{ClaimName: "media", Path: "wav/hello.wav"}},
+			},
+			Valid: true,
+		},
+		{
+			Name:   "no source",
+			Assets: []v1alpha1.MediaAsset{{Name: "g711a.pcap"}},
+			Valid:  false,
+		},
+		{
+			Name: "many sources",
+			Assets: []v1alpha1.MediaAsset{
+				{
+					Name:            "g711a.pcap",
+					ConfigMapKeyRef: configMapRef,
+					SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "media"},
+						Key:                  "g711a.pcap",
+					},
+				},
+			},
+			Valid: false,
+		},
+		{
+			Name: "duplicated name",
+			Assets: []v1alpha1.MediaAsset{
+				{Name: "g711a.pcap", ConfigMapKeyRef: configMapRef},
+				{Name: "g711a.pcap", ConfigMapKeyRef: configMapRef},
+			},
+			Valid: false,
+		},
+		{
+			Name:   "reserved name",
+			Assets: []v1alpha1.MediaAsset{{Name: "values_0.csv", ConfigMapKeyRef: configMapRef}},
+			Valid:  false,
+		},
+		{
+			Name:   "path as name",
+			Assets: []v1alpha1.MediaAsset{{Name: "../g711a.pcap", ConfigMapKeyRef: configMapRef}},
+			Valid:  false,
+		},
+	}
+
+	for _, test := range tests {
+		scenario := &v1alpha1.SippScenario{
+			Spec: v1alpha1.SippScenarioSpec{
+				ScenarioFileContent: `<scenario name="Basic Sipstone UAC"></scenario>`,
+				InjectValues:        []v1alpha1.InjectionFile{{Rows: [][]string{{"Sarah"}}}},
+				MediaAssets:         test.Assets,
+			},
+		}
+
+		err := scenario.Validate()
+		if test.Valid {
+			assert.NoError(t, err, test.Name)
+		} else {
+			assert.Error(t, err, test.Name)
+		}
+	}
+}
+
+func TestValidateContentSources(t *testing.T) {
+	checksum := "sha256:" + strings.Repeat("a", 64)
+
+	tests := []struct {
+		Name  string
+		Spec  v1alpha1.SippScenarioSpec
+		Valid bool
+	}{
+		{
+			Name: "url with checksum",
+			Spec: v1alpha1.SippScenarioSpec{
+				ScenarioFrom: &v1alpha1.ContentSource{
+					URL: &v1alpha1.URLSource{URL: "https://example.com/uac.xml", Checksum: checksum},
+				},
+			},
+			Valid: true,
+		},
+		{
+			Name: "inline and from",
+			Spec: v1alpha1.SippScenarioSpec{
+				ScenarioFileContent: `<scenario name="Basic Sipstone UAC"></scenario>`,
+				ScenarioFrom: &v1alpha1.ContentSource{
+					URL: &v1alpha1.URLSource{URL: "https://example.com/uac.xml", Checksum: checksum},
+				},
+			},
+			Valid: false,
+		},
+		{
+			Name: "unsupported scheme",
+			Spec: v1alpha1.SippScenarioSpec{
+				InjectValues: []v1alpha1.InjectionFile{
+					{From: &v1alpha1.ContentSource{URL: &v1alpha1.URLSource{URL: "ftp://example.com/users.csv", Checksum: checksum}}},
+				},
+			},
+			Valid: false,
+		},
+		{
+			Name: "malformed checksum",
+			Spec: v1alpha1.SippScenarioSpec{
+				InjectValues: []v1alpha1.InjectionFile{
+					{From: &v1alpha1.ContentSource{URL: &v1alpha1.URLSource{URL: "https://example.com/users.csv", Checksum: "md5:abc"}}},
+				},
+			},
+			Valid: false,
+		},
+		{
+			Name: "empty source",
+			Spec: v1alpha1.SippScenarioSpec{
+				InjectValues: []v1alpha1.InjectionFile{{From: &v1alpha1.ContentSource{}}},
+			},
+			Valid: false,
+		},
+	}
+
+	for _, test := range tests {
+		scenario := &v1alpha1.SippScenario{Spec: test.Spec}
+		err := scenario.Validate()
+		if test.Valid {
+			assert.NoError(t, err, test.Name)
+		} else {
+			assert.Error(t, err, test.Name)
+		}
+	}
+}
+
+func TestToSippArgsWithSources(t *testing.T) {
+	scenario := &v1alpha1.SippScenario{
+		Spec: v1alpha1.SippScenarioSpec{
+			ScenarioFrom: &v1alpha1.ContentSource{
+				ConfigMapKeyRef: &corev1.ConfigMapKeySelector{Key: "uac.xml"},
+			},
+			InjectValues: []v1alpha1.InjectionFile{
+				{Rows: [][]string{{"Sarah", "sipphone32"}}},
+				{From: &v1alpha1.ContentSource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{Key: "users.csv"}}, Index: pointer.Int32Ptr(0)},
+			},
+		},
+	}
+
+	args := scenario.ToSippArgs("/etc/test")
+	assert.Equal(t, "-sf /etc/test/scenario.xml -inf /etc/test/values_0.csv -inf /etc/test/values_1.csv -infindex /etc/test/values_1.csv 0", strings.Join(args, " "))
+}
+
+func TestValidateParameters(t *testing.T) {
+	tests := []struct {
+		Name       string
+		Parameters []v1alpha1.ScenarioParameter
+		Valid      bool
+	}{
+		{
+			Name: "typed parameters",
+			Parameters: []v1alpha1.ScenarioParameter{
+				{Name: "codec", Default: pointer.StringPtr("PCMA")},
+				{Name: "pause_ms", Type: v1alpha1.ParameterTypeInteger, Default: pointer.StringPtr("2000")},
+				{Name: "auth", Type: v1alpha1.ParameterTypeBoolean},
+			},
+			Valid: true,
+		},
+		{
+			Name: "invalid name",
+			Parameters: []v1alpha1.ScenarioParameter{
+				{Name: "pause-ms"},
+			},
+			Valid: false,
+		},
+		{
+			Name: "duplicate name",
+			Parameters: []v1alpha1.ScenarioParameter{
+				{Name: "codec"},
+				{Name: "codec"},
+			},
+			Valid: false,
+		},
+		{
+			Name: "default of the wrong type",
+			Parameters: []v1alpha1.ScenarioParameter{
+				{Name: "pause_ms", Type: v1alpha1.ParameterTypeInteger, Default: pointer.StringPtr("2s")},
+			},
+			Valid: false,
+		},
+	}
+
+	for _, test := range tests {
+		scenario := &v1alpha1.SippScenario{
+			Spec: v1alpha1.SippScenarioSpec{
+				ScenarioFileContent: `<scenario name="Basic Sipstone UAC"></scenario>`,
+				Parameters:          test.Parameters,
+			},
+		}
+		err := scenario.Validate()
+		if test.Valid {
+			assert.NoError(t, err, test.Name)
+		} else {
+			assert.Error(t, err, test.Name)
+		}
+	}
+}
+
+func TestParameterValues(t *testing.T) {
+	scenario := &v1alpha1.SippScenario{
+		Spec: v1alpha1.SippScenarioSpec{
+			Parameters: []v1alpha1.ScenarioParameter{
+				{Name: "codec", Default: pointer.StringPtr("PCMA")},
+				{Name: "pause_ms", Type: v1alpha1.ParameterTypeInteger},
+				{Name: "auth", Type: v1alpha1.ParameterTypeBoolean, Default: pointer.StringPtr("false")},
+			},
+		},
+	}
+
+	values, err := scenario.ParameterValues(map[string]string{"pause_ms": "500", "auth": "true"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"codec": "PCMA", "pause_ms": int64(500), "auth": true}, values)
+
+	_, err = scenario.ParameterValues(map[string]string{"auth": "yes", "codecs": "PCMU"})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "spec.parameters[pause_ms]: Required value")
+		assert.Contains(t, err.Error(), "spec.parameters[auth]: Invalid value")
+		assert.Contains(t, err.Error(), "spec.parameters[codecs]: Unsupported value")
+	}
+
+	assert.Equal(t, map[string]interface{}{"codec": "PCMA", "pause_ms": int64(0), "auth": false}, scenario.DefaultParameterValues())
+}
+
+func TestValidateInjectValues(t *testing.T) {
+	tests := []struct {
+		Name   string
+		Values v1alpha1.InjectionFile
+		Valid  bool
+	}{
+		{
+			Name: "named columns with index",
+			Values: v1alpha1.InjectionFile{
+				Mode:    v1alpha1.InjectionModeRandom,
+				Columns: []string{"user", "password"},
+				Rows:    [][]string{{"Sarah", "sipphone32"}, {"Bob", "sipphone42"}},
+				Index:   pointer.Int32Ptr(0),
+			},
+			Valid: true,
+		},
+		{
+			Name:   "ragged rows",
+			Values: v1alpha1.InjectionFile{Rows: [][]string{{"Sarah", "sipphone32"}, {"Bob"}}},
+			Valid:  false,
+		},
+		{
+			Name: "rows not matching the columns",
+			Values: v1alpha1.InjectionFile{
+				Columns: []string{"user"},
+				Rows:    [][]string{{"Sarah", "sipphone32"}},
+			},
+			Valid: false,
+		},
+		{
+			Name:   "separator in a field",
+			Values: v1alpha1.InjectionFile{Rows: [][]string{{"Sarah;sipphone32"}}},
+			Valid:  false,
+		},
+		{
+			Name:   "index out of the rows",
+			Values: v1alpha1.InjectionFile{Rows: [][]string{{"Sarah", "sipphone32"}}, Index: pointer.Int32Ptr(2)},
+			Valid:  false,
+		},
+		{
+			Name:   "no rows",
+			Values: v1alpha1.InjectionFile{Mode: v1alpha1.InjectionModeUser},
+			Valid:  false,
+		},
+	}
+
+	for _, test := range tests {
+		scenario := &v1alpha1.SippScenario{
+			Spec: v1alpha1.SippScenarioSpec{
+				ScenarioFileContent: `<scenario name="Basic Sipstone UAC"></scenario>`,
+				InjectValues:        []v1alpha1.InjectionFile{test.Values},
+			},
+		}
+		err := scenario.Validate()
+		if test.Valid {
+			assert.NoError(t, err, test.Name)
+		} else {
+			assert.Error(t, err, test.Name)
+		}
+	}
+}
+
+func TestInjectionFileRender(t *testing.T) {
+	values := v1alpha1.InjectionFile{
+		Rows: [][]string{{"Sarah", "sipphone32"}, {"Bob", "sipphone42"}},
+	}
+	assert.Equal(t, "SEQUENTIAL\nSarah;sipphone32\nBob;sipphone42\n", values.Render())
+
+	values.Mode = v1alpha1.InjectionModeUser
+	assert.Equal(t, "USER\nSarah;sipphone32\nBob;sipphone42\n", values.Render())
+}
+
+func TestUnmarshalLegacyInjectValues(t *testing.T) {
+	// SippScenarios created before structured injection files stored the raw CSV content
+	spec := v1alpha1.SippScenarioSpec{}
+	err := json.Unmarshal([]byte(`{"injectValues":["RANDOM\nSarah;sipphone32\nBob;sipphone42;\n",{"mode":"User","rows":[["alice"]]}]}`), &spec)
+	assert.NoError(t, err)
+	assert.Equal(t, []v1alpha1.InjectionFile{
+		{Mode: v1alpha1.InjectionModeRandom, Rows: [][]string{{"Sarah", "sipphone32"}, {"Bob", "sipphone42"}}},
+		{Mode: v1alpha1.InjectionModeUser, Rows: [][]string{{"alice"}}},
+	}, spec.InjectValues)
+
+	err = json.Unmarshal([]byte(`{"injectValues":[42]}`), &spec)
+	assert.Error(t, err)
+
+	// The rows generated by the PRINTF options can't be stored, the scenario is rejected
+	// instead of passing the template row to sipp as is
+	scenario := &v1alpha1.SippScenario{}
+	err = json.Unmarshal([]byte(`{"spec":{"scenarioFileContent":"<scenario/>","injectValues":["SEQUENTIAL,PRINTF=10\nuser%04d;secret\n"]}}`), scenario)
+	assert.NoError(t, err)
+	assert.EqualError(t, scenario.DeepCopy().Validate(), `spec.injectValues[0]: Invalid value: "PRINTF=10": the PRINTF option is not supported`)
+}
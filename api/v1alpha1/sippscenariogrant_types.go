@@ -0,0 +1,92 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SippScenarioGrantSpec defines the runs allowed to use the scenarios of the grant namespace
+type SippScenarioGrantSpec struct {
+	// From are the namespaces whose runs may use the scenarios
+	// +kubebuilder:validation:MinItems=1
+	From []ScenarioGrantFrom `json:"from"`
+	// To are the scenarios the runs may use, all the scenarios of the namespace when empty
+	// +optional
+	To []ScenarioGrantTo `json:"to,omitempty"`
+}
+
+// ScenarioGrantFrom identifies a namespace allowed to use the scenarios
+type ScenarioGrantFrom struct {
+	// Namespace of the runs
+	Namespace string `json:"namespace"`
+}
+
+// ScenarioGrantTo identifies a scenario of the grant namespace
+type ScenarioGrantTo struct {
+	// Name of the SippScenario
+	Name string `json:"name"`
+}
+
+// +kubebuilder:object:root=true
+
+// SippScenarioGrant allows the runs of other namespaces to use the scenarios
+// of its namespace, modelled on the Gateway API ReferenceGrant.
+// Runs can't use the scenarios of other namespaces without a grant.
+// +kubebuilder:resource:shortName={"ssg"}
+type SippScenarioGrant struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec SippScenarioGrantSpec `json:"spec,omitempty"`
+}
+
+// Allows returns whether the runs of the namespace may use the scenario
+func (g *SippScenarioGrant) Allows(namespace, scenarioName string) bool {
+	allowed := false
+	for _, from := range g.Spec.From {
+		if from.Namespace == namespace {
+			allowed = true
+		}
+	}
+	if !allowed {
+		return false
+	}
+
+	if len(g.Spec.To) == 0 {
+		return true
+	}
+	for _, to := range g.Spec.To {
+		if to.Name == scenarioName {
+			return true
+		}
+	}
+	return false
+}
+
+// +kubebuilder:object:root=true
+
+// SippScenarioGrantList contains a list of SippScenarioGrant
+type SippScenarioGrantList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SippScenarioGrant `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SippScenarioGrant{}, &SippScenarioGrantList{})
+}
@@ -0,0 +1,23 @@
+package v1alpha1_test
+
+import (
+	"testing"
+
+	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSippScenarioGrantAllows(t *testing.T) {
+	grant := &v1alpha1.SippScenarioGrant{
+		Spec: v1alpha1.SippScenarioGrantSpec{
+			From: []v1alpha1.ScenarioGrantFrom{{Namespace: "team-a"}, {Namespace: "team-b"}},
+		},
+	}
+	assert.True(t, grant.Allows("team-a", "uac"))
+	assert.True(t, grant.Allows("team-b", "uas"))
+	assert.False(t, grant.Allows("team-c", "uac"))
+
+	grant.Spec.To = []v1alpha1.ScenarioGrantTo{{Name: "uac"}}
+	assert.True(t, grant.Allows("team-a", "uac"))
+	assert.False(t, grant.Allows("team-a", "uas"))
+}
@@ -17,13 +17,62 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 )
 
+const (
+	// SucceededRunsHistoryLimitAnnotation is set on a namespace to limit
+	// the number of finished runs which succeeded kept in the namespace
+	SucceededRunsHistoryLimitAnnotation = "sipp.alexandrevilain.dev/succeeded-runs-history-limit"
+	// FailedRunsHistoryLimitAnnotation is set on a namespace to limit
+	// the number of finished runs which failed kept in the namespace
+	FailedRunsHistoryLimitAnnotation = "sipp.alexandrevilain.dev/failed-runs-history-limit"
+	// RunsHistoryKeepAnnotation is set on a namespace to keep part of
+	// the runs garbage-collected by the history limits, see HistoryKeep
+	RunsHistoryKeepAnnotation = "sipp.alexandrevilain.dev/runs-history-keep"
+	// AttemptAnnotation is set on the run jobs to the number of the attempt they execute
+	AttemptAnnotation = "sipp.alexandrevilain.dev/attempt"
+	// PausedAnnotation is set on the run pods whose traffic is paused
+	PausedAnnotation = "sipp.alexandrevilain.dev/paused"
+	// StoppedAnnotation is set on the run pods asked to stop gracefully
+	StoppedAnnotation = "sipp.alexandrevilain.dev/stopped"
+)
+
+// HistoryKeep defines what is kept of the runs garbage-collected by the history limits
+type HistoryKeep string
+
+const (
+	// HistoryKeepNothing deletes the runs, which is the default
+	HistoryKeepNothing HistoryKeep = "Nothing"
+	// HistoryKeepResults keeps the runs, their status and their report,
+	// their jobs, ConfigMaps and artifacts are deleted
+	HistoryKeepResults HistoryKeep = "Results"
+	// HistoryKeepArtifacts keeps the runs, their status, their report and their artifacts claim,
+	// their jobs and ConfigMaps are deleted
+	HistoryKeepArtifacts HistoryKeep = "Artifacts"
+)
+
+var flagRegexp = regexp.MustCompile(`^-[A-Za-z]`)
+
+// repeatableFlags are the sipp flags which can be passed several times
+var repeatableFlags = map[string]bool{
+	"-inf":      true,
+	"-infindex": true,
+	"-key":      true,
+	"-set":      true,
+}
+
 // Protocol defines the protocol used in the scenario run
 type Protocol string
 
@@ -56,6 +105,226 @@ type Transport struct {
 	Compression *bool `json:"compression"`
 }
 
+// String returns the protocol and socket mode of the transport
+func (t *Transport) String() string {
+	result := fmt.Sprintf("%s/%s", t.Protocol, t.Socket)
+	if t.Compression != nil && *t.Compression {
+		result += "+compression"
+	}
+	return result
+}
+
+// PortRange defines an inclusive range of ports
+type PortRange struct {
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Min int32 `json:"min"`
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Max int32 `json:"max"`
+}
+
+// Size returns the number of ports in the range
+func (r PortRange) Size() int32 {
+	return r.Max - r.Min + 1
+}
+
+// ArtifactsStorage defines the PersistentVolumeClaim keeping the files of a run
+type ArtifactsStorage struct {
+	// ClaimName of an existing PersistentVolumeClaim.
+	// When empty, a claim is created for the run and deleted with it.
+	// +optional
+	ClaimName string `json:"claimName,omitempty"`
+	// Size of the claim created for the run, defaults to 1Gi
+	// +optional
+	Size *resource.Quantity `json:"size,omitempty"`
+	// StorageClassName of the claim created for the run
+	// +optional
+	StorageClassName *string `json:"storageClassName,omitempty"`
+}
+
+// ObjectStorage defines the S3 compatible bucket the run artifacts are uploaded to,
+// the fields override the operator level settings
+type ObjectStorage struct {
+	// Endpoint of the object storage, such as https://s3.eu-west-1.amazonaws.com
+	// or http://minio.minio.svc:9000
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+	// Region of the bucket, defaults to us-east-1
+	// +optional
+	Region string `json:"region,omitempty"`
+	// Bucket the artifacts are uploaded to
+	// +optional
+	Bucket string `json:"bucket,omitempty"`
+	// Prefix is the template of the object keys prefix, referencing the
+	// {{ .Namespace }}, {{ .Run }}, {{ .Scenario }} and {{ .Date }} fields.
+	// Defaults to {{ .Namespace }}/{{ .Run }}
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+	// PathStyle puts the bucket in the URL path instead of the host name, as MinIO expects
+	// +optional
+	PathStyle *bool `json:"pathStyle,omitempty"`
+	// CredentialsSecret holds the accessKeyID and secretAccessKey keys, and optionally sessionToken.
+	// It is looked up in the namespace of the run, and is required when the operator
+	// credentials Secret lives in another namespace
+	// +optional
+	CredentialsSecret *corev1.LocalObjectReference `json:"credentialsSecret,omitempty"`
+}
+
+// ScenarioKind is the kind of the scenario used by a run
+// +kubebuilder:validation:Enum=SippScenario;ClusterSippScenario
+type ScenarioKind string
+
+const (
+	// ScenarioKindSippScenario references a SippScenario in the namespace of the run
+	ScenarioKindSippScenario ScenarioKind = "SippScenario"
+	// ScenarioKindClusterSippScenario references a ClusterSippScenario
+	ScenarioKindClusterSippScenario ScenarioKind = "ClusterSippScenario"
+)
+
+// ScenarioReference identifies the scenario used by a run
+type ScenarioReference struct {
+	// Kind of the scenario, defaults to SippScenario
+	// +optional
+	Kind ScenarioKind `json:"kind,omitempty"`
+	// Name of the scenario
+	Name string `json:"name"`
+	// Namespace of the SippScenario, defaults to the namespace of the run.
+	// A SippScenarioGrant in the scenario namespace must allow the run namespace,
+	// the media assets of the scenario are still mounted from the run namespace.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// IsClusterScoped returns whether the reference is to a ClusterSippScenario
+func (r *ScenarioReference) IsClusterScoped() bool {
+	return r != nil && r.Kind == ScenarioKindClusterSippScenario
+}
+
+// ScenarioNamespace returns the namespace of the SippScenario used by the run
+func (run *SippScenarioRun) ScenarioNamespace() string {
+	if run.Spec.ScenarioRef != nil && run.Spec.ScenarioRef.Namespace != "" {
+		return run.Spec.ScenarioRef.Namespace
+	}
+	return run.Namespace
+}
+
+// IsCrossNamespace returns whether the run uses a SippScenario of another namespace
+func (run *SippScenarioRun) IsCrossNamespace() bool {
+	return !run.Spec.ScenarioRef.IsClusterScoped() && run.ScenarioNamespace() != run.Namespace
+}
+
+// RetryPolicy defines when a failed run is started again
+type RetryPolicy struct {
+	// Retries is the number of times a failed run is started again
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Retries int32 `json:"retries,omitempty"`
+	// ExitCodes are the sipp exit codes which are retried, any failure is retried when empty.
+	// sipp exits with 1 when calls failed, 255 on fatal errors
+	// and 254 when it can't bind its sockets.
+	// +optional
+	ExitCodes []int32 `json:"exitCodes,omitempty"`
+	// BackoffSeconds is the delay before the first retry, doubled on each following retry.
+	// Defaults to 10 seconds.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	BackoffSeconds *int32 `json:"backoffSeconds,omitempty"`
+	// MaxBackoffSeconds caps the delay between retries. Defaults to 300 seconds.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxBackoffSeconds *int32 `json:"maxBackoffSeconds,omitempty"`
+}
+
+// RunAttempt records an execution of the run
+type RunAttempt struct {
+	// Attempt is the number of the attempt, starting from 1
+	Attempt int32 `json:"attempt"`
+	// StartTime is the time the attempt job started at
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// CompletionTime is the time the attempt job finished at
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// Succeeded tells whether the attempt succeeded
+	Succeeded bool `json:"succeeded"`
+	// ExitCode is the exit code of the first failed sipp instance
+	// +optional
+	ExitCode *int32 `json:"exitCode,omitempty"`
+	// Reason is the reason of the attempt failure
+	// +optional
+	Reason string `json:"reason,omitempty"`
+}
+
+// TraceOptions selects the sipp trace files kept as artifacts of the run
+type TraceOptions struct {
+	// Errors traces the unexpected messages
+	// See the -trace_err parameter documentation
+	// +optional
+	Errors bool `json:"errors,omitempty"`
+	// Messages traces all the messages sent and received
+	// See the -trace_msg parameter documentation
+	// +optional
+	Messages bool `json:"messages,omitempty"`
+	// Statistics dumps the statistics periodically
+	// See the -trace_stat parameter documentation
+	// +optional
+	Statistics bool `json:"statistics,omitempty"`
+	// ResponseTimes traces all the response times
+	// See the -trace_rtt parameter documentation
+	// +optional
+	ResponseTimes bool `json:"responseTimes,omitempty"`
+	// Screen dumps the screens when sipp exits
+	// See the -trace_screen parameter documentation
+	// +optional
+	Screen bool `json:"screen,omitempty"`
+	// Counts dumps the messages counts periodically
+	// See the -trace_counts parameter documentation
+	// +optional
+	Counts bool `json:"counts,omitempty"`
+	// Logs traces the log actions of the scenario
+	// See the -trace_logs parameter documentation
+	// +optional
+	Logs bool `json:"logs,omitempty"`
+	// MaxFileSize rotates the error, messages and logs files when they reach this size
+	// See the -ringbuffer_size parameter documentation
+	// +optional
+	MaxFileSize *resource.Quantity `json:"maxFileSize,omitempty"`
+	// MaxFiles is the number of rotated files kept
+	// See the -ringbuffer_files parameter documentation
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxFiles *int32 `json:"maxFiles,omitempty"`
+	// Storage keeps the trace files of each pod in the <run name>/<pod name> directory of a claim,
+	// along with the scenario files
+	// +optional
+	Storage ArtifactsStorage `json:"storage,omitempty"`
+}
+
+// RTPOptions defines the RTP configuration of the scenario run
+type RTPOptions struct {
+	// Echo echoes the RTP packets received on the media port back to their sender
+	// See the -rtp_echo parameter documentation
+	// +optional
+	Echo bool `json:"echo,omitempty"`
+	// Payload is the default RTP payload type used by rtp_stream actions
+	// See the -rtp_payload parameter documentation
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=127
+	// +optional
+	Payload *int32 `json:"payload,omitempty"`
+	// ThreadTasks is the number of rtp_stream tasks handled by each thread
+	// See the -rtp_threadtasks parameter documentation
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ThreadTasks *int32 `json:"threadTasks,omitempty"`
+	// BufferSize is the size of the RTP audio buffer, in bytes
+	// See the -rtp_buffsize parameter documentation
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	BufferSize *int32 `json:"bufferSize,omitempty"`
+}
+
 // SippScenarioRunSpec defines the desired state of SippScenarioRun
 // TODO(alexandrevilain): Implement a Validating Admission Webhook
 // If the CommandOverride is empty and the destination too, we should throw an error
@@ -63,7 +332,7 @@ type SippScenarioRunSpec struct {
 	// ParallelismsSpecifies the maximum desired number of sipp instance you want to run at the same time
 	// +optional
 	Parallelism *int32 `json:"parallelism,omitempty"`
-	// Sipp docker image
+	// Sipp docker image, which provides sh and sipp in its PATH.
 	// Defaults to ctaloi/sipp
 	// +optional
 	Image string `json:"image,omitempty"`
@@ -76,14 +345,58 @@ type SippScenarioRunSpec struct {
 	// +optional
 	JobAnnotations map[string]string `json:"annotations,omitempty"`
 
+	// Suspend pauses the traffic of the running sipp instances through their
+	// remote control port, the calls in progress go on. Setting it back to false
+	// resumes the traffic.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+	// Stop gracefully stops the run through the remote control port of the sipp
+	// instances: they stop placing new calls, wait for the calls in progress and
+	// write their final statistics before exiting.
+	// +optional
+	Stop bool `json:"stop,omitempty"`
+	// Duration of the run, once elapsed the run is stopped gracefully like with stop
+	// +optional
+	Duration *metav1.Duration `json:"duration,omitempty"`
+	// StopGracePeriodSeconds is how long the calls in progress may go on once the run
+	// is stopped, sipp is then asked to quit immediately. Defaults to 30 seconds.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	StopGracePeriodSeconds *int32 `json:"stopGracePeriodSeconds,omitempty"`
+	// RetryPolicy starts a failed run again, it is never retried by default
+	// so a failed load test doesn't hit the tested system again unintentionally
+	// +optional
+	RetryPolicy *RetryPolicy `json:"retryPolicy,omitempty"`
+
+	// TTLSecondsAfterFinished deletes the run and its resources this number of seconds
+	// after it finished, once its artifacts are uploaded
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+
 	// ScenarioRef holds the fields to identify the scenario used for this run
-	ScenarioRef *corev1.LocalObjectReference `json:"scenarioRef"`
+	ScenarioRef *ScenarioReference `json:"scenarioRef"`
+	// Parameters holds the values of the parameters declared by the scenario,
+	// the scenario defaults are used for the missing ones
+	// +optional
+	Parameters map[string]string `json:"parameters,omitempty"`
 
 	// CommandOverride allows to bypass all configuration fields
 	// If set, all fields are ignored
+	// It is split into arguments with the shell quoting rules, and can reference
+	// the mounted files with the $(SCENARIO_FILE), $(INJECT_VALUES_FILE_<i>) and $(CONFIG_DIR) placeholders.
+	// When no placeholder is used, the scenario files arguments are appended to the command.
 	// +optional
 	CommandOverride string `json:"commandOverride,omitempty"`
-	// Destination
+	// Args is an alternative to CommandOverride, as a list of arguments which need no quoting
+	// +optional
+	Args []string `json:"args,omitempty"`
+	// ExtraArgs are appended to the arguments generated from the run and scenario fields,
+	// for the sipp flags which have no field
+	// +optional
+	ExtraArgs []string `json:"extraArgs,omitempty"`
+	// Destination is the remote host sipp sends its calls to, as host[:port].
+	// It is passed to sipp as its positional argument.
 	// +optional
 	Destination string `json:"destination,omitempty"`
 
@@ -97,10 +410,71 @@ type SippScenarioRunSpec struct {
 	// +optional
 	CallLength *int32 `json:"callLength,omitempty"`
 
+	// Rate is the number of calls started per second
+	// See the -r parameter documentation
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Rate *int32 `json:"rate,omitempty"`
+
 	// ExitWhenCallsProcessed sets sipp to stop the test and exit
 	// when 'calls' calls are processed
 	// +optional
 	ExitWhenCallsProcessed *bool `json:"exitWhenCallsProcessed,omitempty"`
+
+	// HostNetwork runs the sipp instances in the node's network namespace,
+	// so that SIP and RTP traffic uses the node addresses instead of going through pod NAT.
+	// Each instance reserves the remote control port of its node, so the instances run on distinct nodes,
+	// and the ones beyond the available nodes stay pending, as reported by the Unschedulable condition
+	// +optional
+	HostNetwork bool `json:"hostNetwork,omitempty"`
+
+	// LocalIP sets the local IP address for the Contact:, Via: and From: headers
+	// See the -i parameter documentation
+	// +optional
+	LocalIP string `json:"localIP,omitempty"`
+
+	// LocalPort sets the local port number
+	// See the -p parameter documentation
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	LocalPort *int32 `json:"localPort,omitempty"`
+
+	// MediaIP sets the local media IP address
+	// See the -mi parameter documentation
+	// +optional
+	MediaIP string `json:"mediaIP,omitempty"`
+
+	// MediaPort sets the local RTP echo port number
+	// See the -mp parameter documentation
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	MediaPort *int32 `json:"mediaPort,omitempty"`
+
+	// RTPPortRange sets the range of ports used for RTP streams
+	// See the -min_rtp_port and -max_rtp_port parameters documentation
+	// +optional
+	RTPPortRange *PortRange `json:"rtpPortRange,omitempty"`
+
+	// RTP holds the RTP echo and rtp_stream options
+	// +optional
+	RTP *RTPOptions `json:"rtp,omitempty"`
+
+	// Traces selects the sipp trace files kept once the pods are gone
+	// +optional
+	Traces *TraceOptions `json:"traces,omitempty"`
+
+	// Upload overrides the operator settings of the object storage
+	// the artifacts and a results summary are uploaded to once the run is finished
+	// +optional
+	Upload *ObjectStorage `json:"upload,omitempty"`
+
+	// Baseline compares the metrics of the finished run to a previous run or to stored metrics,
+	// and reports the regressions in the Regressed condition.
+	// It requires the statistics traces, the metrics are read from.
+	// +optional
+	Baseline *Baseline `json:"baseline,omitempty"`
 }
 
 // SippScenarioRunStatus defines the observed state of SippScenarioRun
@@ -114,6 +488,41 @@ type SippScenarioRunStatus struct {
 	// The number of sipp instances which reached phase Failed.
 	// +optional
 	Failed int32 `json:"failed,omitempty"`
+	// ScenarioDigest is the sha256 digest of the scenario file used by the run
+	// +optional
+	ScenarioDigest string `json:"scenarioDigest,omitempty"`
+	// InjectValuesDigests are the sha256 digests of the inject values files used by the run,
+	// in the order they are passed to sipp
+	// +optional
+	InjectValuesDigests []string `json:"injectValuesDigests,omitempty"`
+	// Conditions holds the latest observations of the run's state
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
+	// Artifacts are the directories holding the trace files of each pod,
+	// as <claim name>:<path>
+	// +optional
+	Artifacts []string `json:"artifacts,omitempty"`
+	// Uploads are the URLs of the objects uploaded once the run is finished
+	// +optional
+	Uploads []string `json:"uploads,omitempty"`
+	// PausedPods are the pods whose traffic is paused
+	// +optional
+	PausedPods []string `json:"pausedPods,omitempty"`
+	// StoppedPods are the pods asked to stop gracefully
+	// +optional
+	StoppedPods []string `json:"stoppedPods,omitempty"`
+	// Attempts are the finished executions of the run
+	// +optional
+	Attempts []RunAttempt `json:"attempts,omitempty"`
+	// Metrics are the key metrics of the last attempt, collected when the statistics are traced
+	// +optional
+	Metrics *RunMetrics `json:"metrics,omitempty"`
+	// BaselineDeltas compare the metrics with the baseline ones
+	// +optional
+	BaselineDeltas []MetricDelta `json:"baselineDeltas,omitempty"`
+	// Report is the name of the ConfigMap holding the JUnit report of the finished run
+	// +optional
+	Report string `json:"report,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -124,6 +533,7 @@ type SippScenarioRunStatus struct {
 // +kubebuilder:printcolumn:name="Active",type="integer",JSONPath=".status.active"
 // +kubebuilder:printcolumn:name="Succeeded",type="integer",JSONPath=".status.succeeded"
 // +kubebuilder:printcolumn:name="Failed",type="integer",JSONPath=".status.failed"
+// +kubebuilder:printcolumn:name="Regressed",type="string",JSONPath=".status.conditions[?(@.type==\"Regressed\")].status",priority=1
 // +kubebuilder:resource:shortName={"ssr"}
 type SippScenarioRun struct {
 	metav1.TypeMeta   `json:",inline"`
@@ -152,13 +562,40 @@ func (run SippScenarioRun) ChildResourceName(name string) string {
 	return strings.TrimSuffix(strings.Join([]string{run.Name, name}, "-"), "-")
 }
 
-// ToSippArgs returns the Sipp Args from the Spec
-// This function asserts that the Spec is clean (no unknown values)
-func (run *SippScenarioRun) ToSippArgs() []string {
-	if run.Spec.CommandOverride != "" {
-		return strings.Split(run.Spec.CommandOverride, " ")
+// HasCommandOverride returns whether the configuration fields are bypassed
+// by the CommandOverride or Args fields
+func (run *SippScenarioRun) HasCommandOverride() bool {
+	return run.Spec.CommandOverride != "" || len(run.Spec.Args) > 0
+}
+
+// ConflictingExtraArgs returns the flags of the extra arguments which are
+// already generated from the run and scenario fields.
+// The flags sipp accepts several times are not reported.
+func (run *SippScenarioRun) ConflictingExtraArgs(scenario *SippScenario) []string {
+	// The remote control port is set on the job
+	generated := map[string]bool{"-cp": true}
+	for _, arg := range append(run.ToSippArgs(), scenario.ToSippArgs("")...) {
+		if flagRegexp.MatchString(arg) {
+			generated[arg] = true
+		}
 	}
 
+	conflicts := []string{}
+	reported := map[string]bool{}
+	for _, arg := range run.Spec.ExtraArgs {
+		if generated[arg] && !repeatableFlags[arg] && !reported[arg] {
+			conflicts = append(conflicts, arg)
+			reported[arg] = true
+		}
+	}
+
+	return conflicts
+}
+
+// ToSippArgs returns the Sipp Args from the Spec
+// This function asserts that the Spec is clean (no unknown values).
+// The command override replaces these arguments when it is set.
+func (run *SippScenarioRun) ToSippArgs() []string {
 	result := []string{}
 
 	if run.Spec.ExitWhenCallsProcessed != nil && *run.Spec.ExitWhenCallsProcessed {
@@ -173,9 +610,302 @@ func (run *SippScenarioRun) ToSippArgs() []string {
 		result = append(result, "-d", strconv.FormatInt(int64(*run.Spec.CallLength), 10))
 	}
 
+	if run.Spec.Rate != nil {
+		result = append(result, "-r", strconv.FormatInt(int64(*run.Spec.Rate), 10))
+	}
+
+	result = append(result, run.AddressingToSippArgs()...)
+
+	if run.Spec.RTP != nil {
+		result = append(result, run.RTPToSippArgs()...)
+	}
+
+	if run.Spec.Traces != nil {
+		result = append(result, run.TracesToSippArgs()...)
+	}
+
+	// sipp reads the remote host from its only positional argument
+	if run.Spec.Destination != "" {
+		result = append(result, run.Spec.Destination)
+	}
+
+	return result
+}
+
+// TracesToSippArgs returns Spec.Traces to Sipp args
+func (run *SippScenarioRun) TracesToSippArgs() []string {
+	traces := run.Spec.Traces
+	result := []string{}
+
+	flags := []struct {
+		enabled bool
+		flag    string
+	}{
+		{traces.Errors, "-trace_err"},
+		{traces.Messages, "-trace_msg"},
+		{traces.Statistics, "-trace_stat"},
+		{traces.ResponseTimes, "-trace_rtt"},
+		{traces.Screen, "-trace_screen"},
+		{traces.Counts, "-trace_counts"},
+		{traces.Logs, "-trace_logs"},
+	}
+	for _, f := range flags {
+		if f.enabled {
+			result = append(result, f.flag)
+		}
+	}
+
+	if traces.MaxFileSize != nil {
+		result = append(result, "-ringbuffer_size", strconv.FormatInt(traces.MaxFileSize.Value(), 10))
+	}
+
+	if traces.MaxFiles != nil {
+		result = append(result, "-ringbuffer_files", strconv.FormatInt(int64(*traces.MaxFiles), 10))
+	}
+
+	return result
+}
+
+// ArtifactsClaimName returns the name of the claim keeping the trace files
+func (run *SippScenarioRun) ArtifactsClaimName() string {
+	if run.Spec.Traces != nil && run.Spec.Traces.Storage.ClaimName != "" {
+		return run.Spec.Traces.Storage.ClaimName
+	}
+	return run.ChildResourceName("artifacts")
+}
+
+// DefaultStopGracePeriod is how long the calls in progress may go on once a run is stopped
+const DefaultStopGracePeriod = 30 * time.Second
+
+// StopGracePeriod returns how long the calls in progress may go on once the run is stopped
+func (run *SippScenarioRun) StopGracePeriod() time.Duration {
+	if run.Spec.StopGracePeriodSeconds == nil {
+		return DefaultStopGracePeriod
+	}
+	return time.Duration(*run.Spec.StopGracePeriodSeconds) * time.Second
+}
+
+// Deadline returns the time the run must be stopped at given its start time,
+// and false when it has no duration
+func (run *SippScenarioRun) Deadline(startTime time.Time) (time.Time, bool) {
+	if run.Spec.Duration == nil {
+		return time.Time{}, false
+	}
+	return startTime.Add(run.Spec.Duration.Duration), true
+}
+
+const (
+	// DefaultRetryBackoff is the delay before the first retry of a failed run
+	DefaultRetryBackoff = 10 * time.Second
+	// DefaultMaxRetryBackoff caps the delay between retries of a failed run
+	DefaultMaxRetryBackoff = 300 * time.Second
+)
+
+// CanRetry returns whether the failed attempt may be started again
+// given the exit code of sipp, which is nil when it is unknown
+func (run *SippScenarioRun) CanRetry(attempt int32, exitCode *int32) bool {
+	policy := run.Spec.RetryPolicy
+	if policy == nil || attempt > policy.Retries {
+		return false
+	}
+
+	if len(policy.ExitCodes) == 0 {
+		return true
+	}
+	if exitCode == nil {
+		return false
+	}
+	for _, code := range policy.ExitCodes {
+		if code == *exitCode {
+			return true
+		}
+	}
+	return false
+}
+
+// RetryBackoff returns the delay before starting the failed attempt again
+func (run *SippScenarioRun) RetryBackoff(attempt int32) time.Duration {
+	backoff := DefaultRetryBackoff
+	maxBackoff := DefaultMaxRetryBackoff
+	if policy := run.Spec.RetryPolicy; policy != nil {
+		if policy.BackoffSeconds != nil {
+			backoff = time.Duration(*policy.BackoffSeconds) * time.Second
+		}
+		if policy.MaxBackoffSeconds != nil {
+			maxBackoff = time.Duration(*policy.MaxBackoffSeconds) * time.Second
+		}
+	}
+
+	for i := int32(1); i < attempt && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	return backoff
+}
+
+// CollectsMetrics returns whether the metrics of the run are read from its statistics traces
+func (run *SippScenarioRun) CollectsMetrics() bool {
+	return run.Spec.Traces != nil && run.Spec.Traces.Statistics
+}
+
+// NextAttempt returns the number of the attempt to start
+func (run *SippScenarioRun) NextAttempt() int32 {
+	return int32(len(run.Status.Attempts)) + 1
+}
+
+// RTPToSippArgs returns Spec.RTP to Sipp args
+func (run *SippScenarioRun) RTPToSippArgs() []string {
+	result := []string{}
+
+	if run.Spec.RTP.Echo {
+		result = append(result, "-rtp_echo")
+	}
+
+	if run.Spec.RTP.Payload != nil {
+		result = append(result, "-rtp_payload", strconv.FormatInt(int64(*run.Spec.RTP.Payload), 10))
+	}
+
+	if run.Spec.RTP.ThreadTasks != nil {
+		result = append(result, "-rtp_threadtasks", strconv.FormatInt(int64(*run.Spec.RTP.ThreadTasks), 10))
+	}
+
+	if run.Spec.RTP.BufferSize != nil {
+		result = append(result, "-rtp_buffsize", strconv.FormatInt(int64(*run.Spec.RTP.BufferSize), 10))
+	}
+
+	return result
+}
+
+// AddressingToSippArgs returns the local and media addressing fields to Sipp args
+func (run *SippScenarioRun) AddressingToSippArgs() []string {
+	result := []string{}
+
+	if run.Spec.LocalIP != "" {
+		result = append(result, "-i", run.Spec.LocalIP)
+	}
+
+	if run.Spec.LocalPort != nil {
+		result = append(result, "-p", strconv.FormatInt(int64(*run.Spec.LocalPort), 10))
+	}
+
+	if run.Spec.MediaIP != "" {
+		result = append(result, "-mi", run.Spec.MediaIP)
+	}
+
+	if run.Spec.MediaPort != nil {
+		result = append(result, "-mp", strconv.FormatInt(int64(*run.Spec.MediaPort), 10))
+	}
+
+	if run.Spec.RTPPortRange != nil {
+		result = append(result,
+			"-min_rtp_port", strconv.FormatInt(int64(run.Spec.RTPPortRange.Min), 10),
+			"-max_rtp_port", strconv.FormatInt(int64(run.Spec.RTPPortRange.Max), 10),
+		)
+	}
+
 	return result
 }
 
+// GetParallelism returns the number of sipp instances run at the same time
+func (run *SippScenarioRun) GetParallelism() int32 {
+	if run.Spec.Parallelism == nil {
+		return 1
+	}
+	return *run.Spec.Parallelism
+}
+
+// Validate checks the Spec for values that sipp would reject
+// or that would make the sipp instances collide at runtime
+func (run *SippScenarioRun) Validate() error {
+	allErrs := field.ErrorList{}
+	specPath := field.NewPath("spec")
+
+	if run.Spec.ScenarioRef.IsClusterScoped() && run.Spec.ScenarioRef.Namespace != "" {
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("scenarioRef", "namespace"), "a ClusterSippScenario has no namespace"))
+	}
+
+	allErrs = append(allErrs, run.validateCommandOverride(specPath)...)
+	allErrs = append(allErrs, run.validateAddressing(specPath)...)
+
+	if run.Spec.Duration != nil && run.Spec.Duration.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("duration"), run.Spec.Duration.Duration.String(), "must be positive"))
+	}
+
+	if run.Spec.Traces != nil {
+		allErrs = append(allErrs, run.validateTraces(specPath.Child("traces"))...)
+	}
+
+	if run.Spec.Baseline != nil {
+		allErrs = append(allErrs, run.Spec.Baseline.validate(run, specPath.Child("baseline"))...)
+	}
+
+	if run.Spec.Upload != nil && run.Spec.Upload.Endpoint != "" {
+		endpoint, err := url.Parse(run.Spec.Upload.Endpoint)
+		if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("upload", "endpoint"), run.Spec.Upload.Endpoint, "must be an http or https URL"))
+		}
+	}
+
+	return allErrs.ToAggregate()
+}
+
+func (run *SippScenarioRun) validateTraces(tracesPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	traces := run.Spec.Traces
+
+	if traces.MaxFileSize != nil && traces.MaxFileSize.Sign() <= 0 {
+		allErrs = append(allErrs, field.Invalid(tracesPath.Child("maxFileSize"), traces.MaxFileSize.String(), "must be positive"))
+	}
+
+	storage := traces.Storage
+	storagePath := tracesPath.Child("storage")
+	if storage.ClaimName != "" && (storage.Size != nil || storage.StorageClassName != nil) {
+		allErrs = append(allErrs, field.Forbidden(storagePath.Child("claimName"), "size and storageClassName only apply to the claim created for the run"))
+	}
+	if storage.Size != nil && storage.Size.Sign() <= 0 {
+		allErrs = append(allErrs, field.Invalid(storagePath.Child("size"), storage.Size.String(), "must be positive"))
+	}
+
+	return allErrs
+}
+
+func (run *SippScenarioRun) validateCommandOverride(specPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if run.Spec.CommandOverride != "" && len(run.Spec.Args) > 0 {
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("args"), "commandOverride and args are mutually exclusive"))
+	}
+
+	if run.HasCommandOverride() && len(run.Spec.ExtraArgs) > 0 {
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("extraArgs"), "extraArgs can't be used with a command override"))
+	}
+
+	return allErrs
+}
+
+func (run *SippScenarioRun) validateAddressing(specPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if run.Spec.LocalIP != "" && net.ParseIP(run.Spec.LocalIP) == nil {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("localIP"), run.Spec.LocalIP, "must be a valid IP address"))
+	}
+
+	if run.Spec.MediaIP != "" && net.ParseIP(run.Spec.MediaIP) == nil {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("mediaIP"), run.Spec.MediaIP, "must be a valid IP address"))
+	}
+
+	portRange := run.Spec.RTPPortRange
+	if portRange != nil && portRange.Min > portRange.Max {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("rtpPortRange"), *portRange, "min must be lower or equal to max"))
+	}
+
+	// Fixed ports aren't checked against the parallelism as they never collide: without host
+	// networking each sipp instance has its own network namespace, and with it its own node
+	return allErrs
+}
+
 // TransportToSippArgs returns Spec.Transport to Sipp args
 // This function asserts that the transport is clean (no unknown values)
 func (run *SippScenarioRun) TransportToSippArgs() []string {
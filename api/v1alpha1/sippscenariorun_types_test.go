@@ -3,25 +3,45 @@ package v1alpha1_test
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
 	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/pointer"
 )
 
-func TestComputeArgsOverride(t *testing.T) {
-	override := "-sf scenario.xml DEST_IP -s DEST_NUMBER"
+func TestDestinationToSippArgs(t *testing.T) {
+	run := &v1alpha1.SippScenarioRun{
+		Spec: v1alpha1.SippScenarioRunSpec{
+			Destination: "sbc.voice.svc:5060",
+			Rate:        pointer.Int32Ptr(10),
+		},
+	}
+
+	// The destination is the trailing positional argument
+	assert.Equal(t, []string{"-r", "10", "sbc.voice.svc:5060"}, run.ToSippArgs())
+
+	run.Spec.Destination = ""
+	assert.Equal(t, []string{"-r", "10"}, run.ToSippArgs())
+}
 
+func TestValidateCommandOverride(t *testing.T) {
 	run := &v1alpha1.SippScenarioRun{
 		Spec: v1alpha1.SippScenarioRunSpec{
-			Destination:     "121.0.0.1",
-			CommandOverride: override,
+			Args: []string{"-key", "name", `say "hi"`},
 		},
 	}
+	assert.True(t, run.HasCommandOverride())
+	assert.NoError(t, run.Validate())
+
+	run.Spec.CommandOverride = "-sn uac"
+	assert.Error(t, run.Validate())
 
-	// Transport and destination should be ignored, as command Override is set
-	res := run.ToSippArgs()
-	assert.Equal(t, override, strings.Join(res, " "))
+	run.Spec.Args = nil
+	run.Spec.ExtraArgs = []string{"-aa"}
+	assert.Error(t, run.Validate())
 }
 
 func TestTransportToSippArgs(t *testing.T) {
@@ -72,3 +92,217 @@ func TestTransportToSippArgs(t *testing.T) {
 		assert.Equal(t, test.Expected, result)
 	}
 }
+
+func TestAddressingToSippArgs(t *testing.T) {
+	run := &v1alpha1.SippScenarioRun{
+		Spec: v1alpha1.SippScenarioRunSpec{
+			LocalIP:   "10.0.0.1",
+			LocalPort: pointer.Int32Ptr(5070),
+			MediaIP:   "10.0.0.2",
+			MediaPort: pointer.Int32Ptr(6000),
+			RTPPortRange: &v1alpha1.PortRange{
+				Min: 10000,
+				Max: 20000,
+			},
+		},
+	}
+
+	result := run.AddressingToSippArgs()
+	assert.Equal(t, "-i 10.0.0.1 -p 5070 -mi 10.0.0.2 -mp 6000 -min_rtp_port 10000 -max_rtp_port 20000", strings.Join(result, " "))
+}
+
+func TestValidateAddressing(t *testing.T) {
+	tests := []struct {
+		Name  string
+		Spec  v1alpha1.SippScenarioRunSpec
+		Valid bool
+	}{
+		{
+			Name:  "empty spec",
+			Spec:  v1alpha1.SippScenarioRunSpec{},
+			Valid: true,
+		},
+		{
+			Name: "invalid local ip",
+			Spec: v1alpha1.SippScenarioRunSpec{
+				LocalIP: "not-an-ip",
+			},
+			Valid: false,
+		},
+		{
+			Name: "inverted rtp port range",
+			Spec: v1alpha1.SippScenarioRunSpec{
+				RTPPortRange: &v1alpha1.PortRange{Min: 20000, Max: 10000},
+			},
+			Valid: false,
+		},
+		{
+			Name: "fixed ports without host network",
+			Spec: v1alpha1.SippScenarioRunSpec{
+				Parallelism: pointer.Int32Ptr(3),
+				LocalPort:   pointer.Int32Ptr(5060),
+				MediaPort:   pointer.Int32Ptr(6000),
+			},
+			Valid: true,
+		},
+		{
+			Name: "fixed port with host network and a single instance",
+			Spec: v1alpha1.SippScenarioRunSpec{
+				HostNetwork: true,
+				LocalPort:   pointer.Int32Ptr(5060),
+			},
+			Valid: true,
+		},
+		{
+			Name: "fixed ports with host network and many instances",
+			Spec: v1alpha1.SippScenarioRunSpec{
+				HostNetwork:  true,
+				Parallelism:  pointer.Int32Ptr(4),
+				LocalPort:    pointer.Int32Ptr(5060),
+				MediaPort:    pointer.Int32Ptr(6000),
+				RTPPortRange: &v1alpha1.PortRange{Min: 10000, Max: 10001},
+			},
+			Valid: true,
+		},
+	}
+
+	for _, test := range tests {
+		run := &v1alpha1.SippScenarioRun{Spec: test.Spec}
+		err := run.Validate()
+		if test.Valid {
+			assert.NoError(t, err, test.Name)
+		} else {
+			assert.Error(t, err, test.Name)
+		}
+	}
+}
+
+func TestRTPToSippArgs(t *testing.T) {
+	run := &v1alpha1.SippScenarioRun{
+		Spec: v1alpha1.SippScenarioRunSpec{
+			RTP: &v1alpha1.RTPOptions{
+				Echo:    true,
+				Payload: pointer.Int32Ptr(8),
+			},
+		},
+	}
+
+	assert.Equal(t, []string{"-rtp_echo", "-rtp_payload", "8"}, run.RTPToSippArgs())
+}
+
+func TestConflictingExtraArgs(t *testing.T) {
+	scenario := &v1alpha1.SippScenario{
+		Spec: v1alpha1.SippScenarioSpec{
+			ScenarioFileContent: `<scenario name="Basic Sipstone UAC"></scenario>`,
+			InjectValues:        []v1alpha1.InjectionFile{{Rows: [][]string{{"Sarah"}}}},
+		},
+	}
+
+	run := &v1alpha1.SippScenarioRun{
+		Spec: v1alpha1.SippScenarioRunSpec{
+			Transport: &v1alpha1.Transport{Protocol: "TCP", Socket: "One"},
+			ExtraArgs: []string{"-aa", "-inf", "/data/users.csv", "-key", "domain", "example.com", "-t", "u1", "-sf", "uas.xml", "-t", "l1", "-cp", "9999"},
+		},
+	}
+
+	assert.NoError(t, run.Validate())
+	assert.Equal(t, []string{"-t", "-sf", "-cp"}, run.ConflictingExtraArgs(scenario))
+
+	run.Spec.ExtraArgs = []string{"-aa", "-trace_err"}
+	assert.Empty(t, run.ConflictingExtraArgs(scenario))
+
+	run.Spec.CommandOverride = "-sn uac"
+	assert.Error(t, run.Validate())
+}
+
+func TestTracesToSippArgs(t *testing.T) {
+	size := resource.MustParse("10Mi")
+	run := &v1alpha1.SippScenarioRun{
+		Spec: v1alpha1.SippScenarioRunSpec{
+			Traces: &v1alpha1.TraceOptions{
+				Errors:      true,
+				Messages:    true,
+				Screen:      true,
+				MaxFileSize: &size,
+				MaxFiles:    pointer.Int32Ptr(3),
+			},
+		},
+	}
+
+	assert.NoError(t, run.Validate())
+	assert.Equal(t, []string{"-trace_err", "-trace_msg", "-trace_screen", "-ringbuffer_size", "10485760", "-ringbuffer_files", "3"}, run.ToSippArgs())
+
+	run.Name = "load"
+	assert.Equal(t, "load-artifacts", run.ArtifactsClaimName())
+
+	run.Spec.Traces.Storage.ClaimName = "traces"
+	assert.Equal(t, "traces", run.ArtifactsClaimName())
+
+	run.Spec.Traces.Storage.Size = &size
+	assert.Error(t, run.Validate())
+}
+
+func TestDuration(t *testing.T) {
+	run := &v1alpha1.SippScenarioRun{}
+	start := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	_, ok := run.Deadline(start)
+	assert.False(t, ok)
+	assert.Equal(t, v1alpha1.DefaultStopGracePeriod, run.StopGracePeriod())
+
+	run.Spec.Duration = &metav1.Duration{Duration: 30 * time.Minute}
+	run.Spec.StopGracePeriodSeconds = pointer.Int32Ptr(10)
+	deadline, ok := run.Deadline(start)
+	assert.True(t, ok)
+	assert.Equal(t, start.Add(30*time.Minute), deadline)
+	assert.Equal(t, 10*time.Second, run.StopGracePeriod())
+	assert.NoError(t, run.Validate())
+
+	run.Spec.Duration.Duration = 0
+	assert.Error(t, run.Validate())
+}
+
+func TestRetryPolicy(t *testing.T) {
+	run := &v1alpha1.SippScenarioRun{}
+	assert.False(t, run.CanRetry(1, pointer.Int32Ptr(1)))
+
+	run.Spec.RetryPolicy = &v1alpha1.RetryPolicy{
+		Retries:   2,
+		ExitCodes: []int32{254},
+	}
+	assert.True(t, run.CanRetry(1, pointer.Int32Ptr(254)))
+	assert.True(t, run.CanRetry(2, pointer.Int32Ptr(254)))
+	assert.False(t, run.CanRetry(3, pointer.Int32Ptr(254)))
+	assert.False(t, run.CanRetry(1, pointer.Int32Ptr(1)))
+	assert.False(t, run.CanRetry(1, nil))
+
+	run.Spec.RetryPolicy.ExitCodes = nil
+	assert.True(t, run.CanRetry(1, nil))
+
+	assert.Equal(t, 10*time.Second, run.RetryBackoff(1))
+	assert.Equal(t, 40*time.Second, run.RetryBackoff(3))
+	assert.Equal(t, 300*time.Second, run.RetryBackoff(10))
+
+	run.Spec.RetryPolicy.BackoffSeconds = pointer.Int32Ptr(0)
+	assert.Equal(t, time.Duration(0), run.RetryBackoff(4))
+}
+
+func TestScenarioNamespace(t *testing.T) {
+	run := &v1alpha1.SippScenarioRun{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team-a"},
+		Spec: v1alpha1.SippScenarioRunSpec{
+			ScenarioRef: &v1alpha1.ScenarioReference{Name: "uac"},
+		},
+	}
+	assert.Equal(t, "team-a", run.ScenarioNamespace())
+	assert.False(t, run.IsCrossNamespace())
+
+	run.Spec.ScenarioRef.Namespace = "voice-scenarios"
+	assert.Equal(t, "voice-scenarios", run.ScenarioNamespace())
+	assert.True(t, run.IsCrossNamespace())
+	assert.NoError(t, run.Validate())
+
+	run.Spec.ScenarioRef.Kind = v1alpha1.ScenarioKindClusterSippScenario
+	assert.False(t, run.IsCrossNamespace())
+	assert.Error(t, run.Validate())
+}
@@ -0,0 +1,396 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// SippTestSuiteSpec defines the steps of a test plan
+type SippTestSuiteSpec struct {
+	// Steps of the test plan. They run in order, unless a step sets dependsOn:
+	// the steps then form a graph, and the steps without dependencies start at once.
+	// Run specs reference the outputs of previous steps as $(steps.<name>.<output>).
+	// +kubebuilder:validation:MinItems=1
+	Steps []TestStep `json:"steps"`
+}
+
+// TestStep is a step of a test suite, exactly one of uas, run, wait or assert must be set
+type TestStep struct {
+	// Name of the step, unique in the suite
+	Name string `json:"name"`
+	// DependsOn are the steps which must succeed before this one starts
+	// +optional
+	DependsOn []string `json:"dependsOn,omitempty"`
+
+	// UAS starts a run answering calls, exposed by a Service.
+	// The step succeeds once each of its instances is ready behind the Service,
+	// and the run is stopped when the suite is over.
+	// Its outputs are the address and the port of the Service.
+	// +optional
+	UAS *SippScenarioRunSpec `json:"uas,omitempty"`
+	// Run runs a scenario until it is finished, the step fails if the run fails.
+	// Its output is the name of the run.
+	// +optional
+	Run *SippScenarioRunSpec `json:"run,omitempty"`
+	// Wait waits for a duration, or for a condition of the run of a previous step
+	// +optional
+	Wait *WaitStep `json:"wait,omitempty"`
+	// Assert checks the results of the run of a previous step
+	// +optional
+	Assert *AssertStep `json:"assert,omitempty"`
+}
+
+// WaitStep waits for a duration or a condition, exactly one of them must be set
+type WaitStep struct {
+	// Duration to wait for
+	// +optional
+	Duration *metav1.Duration `json:"duration,omitempty"`
+	// Condition of the run of a previous step to wait for
+	// +optional
+	Condition *StepCondition `json:"condition,omitempty"`
+}
+
+// ExpectedCondition is a condition a run is expected to have
+type ExpectedCondition struct {
+	// Type of the condition
+	Type ConditionType `json:"type"`
+	// Status of the condition, defaults to True
+	// +optional
+	Status corev1.ConditionStatus `json:"status,omitempty"`
+}
+
+// Matches returns whether the conditions hold the expected one
+func (c ExpectedCondition) Matches(conditions []Condition) bool {
+	status := c.Status
+	if status == "" {
+		status = corev1.ConditionTrue
+	}
+
+	condition := FindCondition(conditions, c.Type)
+	return condition != nil && condition.Status == status
+}
+
+// StepCondition is a condition of the run of a step
+type StepCondition struct {
+	// Step whose run is checked
+	Step              string `json:"step"`
+	ExpectedCondition `json:",inline"`
+}
+
+// AssertStep checks the results of the run of a previous step
+type AssertStep struct {
+	// Step whose run is checked
+	Step string `json:"step"`
+	// Succeeded expects the run to succeed or to fail
+	// +optional
+	Succeeded *bool `json:"succeeded,omitempty"`
+	// ExitCodes are the expected sipp exit codes of the last attempt of the run,
+	// 0 when it succeeded
+	// +optional
+	ExitCodes []int32 `json:"exitCodes,omitempty"`
+	// Conditions are the expected conditions of the run
+	// +optional
+	Conditions []ExpectedCondition `json:"conditions,omitempty"`
+}
+
+// TestSuitePhase is the phase of a test suite or of one of its steps
+type TestSuitePhase string
+
+const (
+	// TestSuitePhasePending is the phase of a step waiting for its dependencies
+	TestSuitePhasePending TestSuitePhase = "Pending"
+	// TestSuitePhaseRunning is the phase of a step in progress
+	TestSuitePhaseRunning TestSuitePhase = "Running"
+	// TestSuitePhaseSucceeded is the phase of a step which succeeded
+	TestSuitePhaseSucceeded TestSuitePhase = "Succeeded"
+	// TestSuitePhaseFailed is the phase of a step which failed
+	TestSuitePhaseFailed TestSuitePhase = "Failed"
+	// TestSuitePhaseSkipped is the phase of a step whose dependencies didn't succeed
+	TestSuitePhaseSkipped TestSuitePhase = "Skipped"
+)
+
+// TestStepStatus is the observed state of a step
+type TestStepStatus struct {
+	// Name of the step
+	Name string `json:"name"`
+	// Phase of the step
+	Phase TestSuitePhase `json:"phase"`
+	// RunName is the name of the run started by the step
+	// +optional
+	RunName string `json:"runName,omitempty"`
+	// Outputs of the step, referenced by the next steps
+	// +optional
+	Outputs map[string]string `json:"outputs,omitempty"`
+	// Message explains the phase of the step
+	// +optional
+	Message string `json:"message,omitempty"`
+	// StartTime is the time the step started at
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// CompletionTime is the time the step finished at
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// SippTestSuiteStatus defines the observed state of SippTestSuite
+type SippTestSuiteStatus struct {
+	// Phase of the suite
+	// +optional
+	Phase TestSuitePhase `json:"phase,omitempty"`
+	// Steps are the states of the steps, in the order of the spec
+	// +optional
+	Steps []TestStepStatus `json:"steps,omitempty"`
+	// Conditions holds the latest observations of the suite's state
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// SippTestSuite is the Schema for the sipptestsuites API
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:resource:shortName={"sts"}
+type SippTestSuite struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SippTestSuiteSpec   `json:"spec,omitempty"`
+	Status SippTestSuiteStatus `json:"status,omitempty"`
+}
+
+// IsGraph returns whether the steps form a graph instead of running in order
+func (s *SippTestSuite) IsGraph() bool {
+	for _, step := range s.Spec.Steps {
+		if len(step.DependsOn) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Dependencies returns the names of the steps the step depends on
+func (s *SippTestSuite) Dependencies(index int) []string {
+	if s.IsGraph() {
+		return s.Spec.Steps[index].DependsOn
+	}
+	if index == 0 {
+		return nil
+	}
+	return []string{s.Spec.Steps[index-1].Name}
+}
+
+// Outputs returns the names of the outputs of the step,
+// which the run specs of the following steps reference
+func (step TestStep) Outputs() []string {
+	switch {
+	case step.UAS != nil:
+		return []string{"address", "port"}
+	case step.Run != nil:
+		return []string{"name"}
+	}
+	return nil
+}
+
+// stepReferenceRegexp matches the $(steps.<name>.<output>) placeholders
+var stepReferenceRegexp = regexp.MustCompile(`\$\(steps\.([^)]*)\)`)
+
+// stepReferenceFields returns the fields of the run spec where the outputs of steps are expanded
+func stepReferenceFields(spec *SippScenarioRunSpec, path *field.Path) map[string]string {
+	fields := map[string]string{
+		path.Child("destination").String():     spec.Destination,
+		path.Child("commandOverride").String(): spec.CommandOverride,
+	}
+	for i, arg := range spec.Args {
+		fields[path.Child("args").Index(i).String()] = arg
+	}
+	for i, arg := range spec.ExtraArgs {
+		fields[path.Child("extraArgs").Index(i).String()] = arg
+	}
+	for name, value := range spec.Parameters {
+		fields[path.Child("parameters").Key(name).String()] = value
+	}
+	return fields
+}
+
+// StepRunName returns the name of the run started by the step
+func (s *SippTestSuite) StepRunName(step string) string {
+	return fmt.Sprintf("%s-%s", s.Name, step)
+}
+
+// Validate checks the steps form a graph without cycles,
+// whose steps only reference the runs and the outputs of the steps they depend on
+func (s *SippTestSuite) Validate() error {
+	allErrs := field.ErrorList{}
+	stepsPath := field.NewPath("spec", "steps")
+
+	indexes := map[string]int{}
+	for i, step := range s.Spec.Steps {
+		stepPath := stepsPath.Index(i)
+
+		if errs := validation.IsDNS1123Label(step.Name); len(errs) > 0 {
+			allErrs = append(allErrs, field.Invalid(stepPath.Child("name"), step.Name, errs[0]))
+		} else if _, ok := indexes[step.Name]; ok {
+			allErrs = append(allErrs, field.Duplicate(stepPath.Child("name"), step.Name))
+		}
+		indexes[step.Name] = i
+
+		kinds := 0
+		for _, set := range []bool{step.UAS != nil, step.Run != nil, step.Wait != nil, step.Assert != nil} {
+			if set {
+				kinds++
+			}
+		}
+		if kinds != 1 {
+			allErrs = append(allErrs, field.Invalid(stepPath, step.Name, "exactly one of uas, run, wait or assert must be set"))
+		}
+
+		if step.Wait != nil && (step.Wait.Duration == nil) == (step.Wait.Condition == nil) {
+			allErrs = append(allErrs, field.Invalid(stepPath.Child("wait"), "", "exactly one of duration or condition must be set"))
+		}
+	}
+	if len(allErrs) > 0 {
+		return allErrs.ToAggregate()
+	}
+
+	for i, step := range s.Spec.Steps {
+		for j, dependency := range step.DependsOn {
+			if _, ok := indexes[dependency]; !ok {
+				allErrs = append(allErrs, field.NotFound(stepsPath.Index(i).Child("dependsOn").Index(j), dependency))
+			}
+		}
+	}
+	if len(allErrs) > 0 {
+		return allErrs.ToAggregate()
+	}
+
+	// Depth-first search of the cycles, and of the ancestors of each step
+	ancestors := make([]map[string]bool, len(s.Spec.Steps))
+	visiting := make([]bool, len(s.Spec.Steps))
+	var visit func(i int) bool
+	visit = func(i int) bool {
+		if ancestors[i] != nil {
+			return true
+		}
+		if visiting[i] {
+			return false
+		}
+		visiting[i] = true
+
+		result := map[string]bool{}
+		for _, dependency := range s.Dependencies(i) {
+			if !visit(indexes[dependency]) {
+				return false
+			}
+			result[dependency] = true
+			for ancestor := range ancestors[indexes[dependency]] {
+				result[ancestor] = true
+			}
+		}
+		ancestors[i] = result
+		return true
+	}
+
+	for i, step := range s.Spec.Steps {
+		stepPath := stepsPath.Index(i)
+		if !visit(i) {
+			return field.ErrorList{field.Invalid(stepPath.Child("dependsOn"), step.DependsOn, "steps can't depend on themselves")}.ToAggregate()
+		}
+
+		runStep := func(path *field.Path, name string) {
+			switch {
+			case !ancestors[i][name]:
+				allErrs = append(allErrs, field.Invalid(path, name, "must be a step this step depends on"))
+			case s.Spec.Steps[indexes[name]].UAS == nil && s.Spec.Steps[indexes[name]].Run == nil:
+				allErrs = append(allErrs, field.Invalid(path, name, "must be a uas or run step"))
+			}
+		}
+		if step.Wait != nil && step.Wait.Condition != nil {
+			runStep(stepPath.Child("wait", "condition", "step"), step.Wait.Condition.Step)
+		}
+		if step.Assert != nil {
+			runStep(stepPath.Child("assert", "step"), step.Assert.Step)
+		}
+
+		// Unresolved placeholders would be passed to sipp as is
+		spec, specPath := step.Run, stepPath.Child("run")
+		if step.UAS != nil {
+			spec, specPath = step.UAS, stepPath.Child("uas")
+		}
+		if spec == nil {
+			continue
+		}
+		fields := stepReferenceFields(spec, specPath)
+		paths := make([]string, 0, len(fields))
+		for path := range fields {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			for _, match := range stepReferenceRegexp.FindAllStringSubmatch(fields[path], -1) {
+				if err := s.checkStepReference(ancestors[i], indexes, match[1]); err != "" {
+					allErrs = append(allErrs, &field.Error{Type: field.ErrorTypeInvalid, Field: path, BadValue: match[0], Detail: err})
+				}
+			}
+		}
+	}
+
+	return allErrs.ToAggregate()
+}
+
+// checkStepReference returns why the <name>.<output> reference to the output
+// of a step can't be resolved, or an empty string when it can
+func (s *SippTestSuite) checkStepReference(ancestors map[string]bool, indexes map[string]int, reference string) string {
+	parts := strings.SplitN(reference, ".", 2)
+	if len(parts) != 2 {
+		return "must be $(steps.<name>.<output>)"
+	}
+
+	name, output := parts[0], parts[1]
+	if !ancestors[name] {
+		return fmt.Sprintf("step %s must be a step this step depends on", name)
+	}
+	for _, known := range s.Spec.Steps[indexes[name]].Outputs() {
+		if output == known {
+			return ""
+		}
+	}
+	return fmt.Sprintf("step %s has no output %s", name, output)
+}
+
+// +kubebuilder:object:root=true
+
+// SippTestSuiteList contains a list of SippTestSuite
+type SippTestSuiteList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SippTestSuite `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SippTestSuite{}, &SippTestSuiteList{})
+}
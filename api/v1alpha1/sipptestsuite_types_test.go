@@ -0,0 +1,164 @@
+package v1alpha1_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+func TestSippTestSuiteDependencies(t *testing.T) {
+	suite := &v1alpha1.SippTestSuite{
+		Spec: v1alpha1.SippTestSuiteSpec{
+			Steps: []v1alpha1.TestStep{
+				{Name: "callee", UAS: &v1alpha1.SippScenarioRunSpec{}},
+				{Name: "caller", Run: &v1alpha1.SippScenarioRunSpec{}},
+				{Name: "check", Assert: &v1alpha1.AssertStep{Step: "caller", Succeeded: pointer.BoolPtr(true)}},
+			},
+		},
+	}
+
+	assert.False(t, suite.IsGraph())
+	assert.Empty(t, suite.Dependencies(0))
+	assert.Equal(t, []string{"callee"}, suite.Dependencies(1))
+	assert.NoError(t, suite.Validate())
+
+	suite.Spec.Steps[2].DependsOn = []string{"caller"}
+	assert.True(t, suite.IsGraph())
+	assert.Empty(t, suite.Dependencies(1))
+	assert.NoError(t, suite.Validate())
+}
+
+func TestValidateSippTestSuite(t *testing.T) {
+	tests := []struct {
+		Name  string
+		Steps []v1alpha1.TestStep
+		Valid bool
+	}{
+		{
+			Name: "dag",
+			Steps: []v1alpha1.TestStep{
+				{Name: "callee", UAS: &v1alpha1.SippScenarioRunSpec{}},
+				{Name: "caller-a", DependsOn: []string{"callee"}, Run: &v1alpha1.SippScenarioRunSpec{}},
+				{Name: "caller-b", DependsOn: []string{"callee"}, Run: &v1alpha1.SippScenarioRunSpec{}},
+				{Name: "check", DependsOn: []string{"caller-a", "caller-b"}, Assert: &v1alpha1.AssertStep{Step: "callee"}},
+			},
+			Valid: true,
+		},
+		{
+			Name: "no kind",
+			Steps: []v1alpha1.TestStep{
+				{Name: "empty"},
+			},
+			Valid: false,
+		},
+		{
+			Name: "many kinds",
+			Steps: []v1alpha1.TestStep{
+				{Name: "both", Run: &v1alpha1.SippScenarioRunSpec{}, Wait: &v1alpha1.WaitStep{Duration: &metav1.Duration{Duration: time.Second}}},
+			},
+			Valid: false,
+		},
+		{
+			Name: "duplicate names",
+			Steps: []v1alpha1.TestStep{
+				{Name: "caller", Run: &v1alpha1.SippScenarioRunSpec{}},
+				{Name: "caller", Run: &v1alpha1.SippScenarioRunSpec{}},
+			},
+			Valid: false,
+		},
+		{
+			Name: "unknown dependency",
+			Steps: []v1alpha1.TestStep{
+				{Name: "caller", DependsOn: []string{"callee"}, Run: &v1alpha1.SippScenarioRunSpec{}},
+			},
+			Valid: false,
+		},
+		{
+			Name: "cycle",
+			Steps: []v1alpha1.TestStep{
+				{Name: "a", DependsOn: []string{"b"}, Run: &v1alpha1.SippScenarioRunSpec{}},
+				{Name: "b", DependsOn: []string{"a"}, Run: &v1alpha1.SippScenarioRunSpec{}},
+			},
+			Valid: false,
+		},
+		{
+			Name: "assertion on a step not depended on",
+			Steps: []v1alpha1.TestStep{
+				{Name: "caller", Run: &v1alpha1.SippScenarioRunSpec{}},
+				{Name: "check", DependsOn: []string{}, Assert: &v1alpha1.AssertStep{Step: "caller"}},
+				{Name: "wait", DependsOn: []string{"caller"}, Wait: &v1alpha1.WaitStep{Duration: &metav1.Duration{Duration: time.Second}}},
+			},
+			Valid: false,
+		},
+		{
+			Name: "step outputs",
+			Steps: []v1alpha1.TestStep{
+				{Name: "callee", UAS: &v1alpha1.SippScenarioRunSpec{}},
+				{Name: "caller", Run: &v1alpha1.SippScenarioRunSpec{
+					Destination: "$(steps.callee.address):$(steps.callee.port)",
+					Parameters:  map[string]string{"callee": "$(steps.callee.address)"},
+				}},
+				{Name: "report", Run: &v1alpha1.SippScenarioRunSpec{ExtraArgs: []string{"-set", "caller", "$(steps.caller.name)"}}},
+			},
+			Valid: true,
+		},
+		{
+			Name: "unknown step output",
+			Steps: []v1alpha1.TestStep{
+				{Name: "callee", UAS: &v1alpha1.SippScenarioRunSpec{}},
+				{Name: "caller", Run: &v1alpha1.SippScenarioRunSpec{Destination: "$(steps.callee.adress)"}},
+			},
+			Valid: false,
+		},
+		{
+			Name: "output of a step not depended on",
+			Steps: []v1alpha1.TestStep{
+				{Name: "callee", UAS: &v1alpha1.SippScenarioRunSpec{}},
+				{Name: "caller", DependsOn: []string{}, Run: &v1alpha1.SippScenarioRunSpec{}},
+				{Name: "other", DependsOn: []string{"caller"}, Run: &v1alpha1.SippScenarioRunSpec{Args: []string{"$(steps.callee.address)"}}},
+			},
+			Valid: false,
+		},
+		{
+			Name: "malformed step reference",
+			Steps: []v1alpha1.TestStep{
+				{Name: "callee", UAS: &v1alpha1.SippScenarioRunSpec{}},
+				{Name: "caller", Run: &v1alpha1.SippScenarioRunSpec{CommandOverride: "-sn uac $(steps.callee)"}},
+			},
+			Valid: false,
+		},
+		{
+			Name: "wait for a condition of a wait step",
+			Steps: []v1alpha1.TestStep{
+				{Name: "pause", Wait: &v1alpha1.WaitStep{Duration: &metav1.Duration{Duration: time.Second}}},
+				{Name: "wait", Wait: &v1alpha1.WaitStep{Condition: &v1alpha1.StepCondition{Step: "pause"}}},
+			},
+			Valid: false,
+		},
+	}
+
+	for _, test := range tests {
+		suite := &v1alpha1.SippTestSuite{Spec: v1alpha1.SippTestSuiteSpec{Steps: test.Steps}}
+		err := suite.Validate()
+		if test.Valid {
+			assert.NoError(t, err, test.Name)
+		} else {
+			assert.Error(t, err, test.Name)
+		}
+	}
+}
+
+func TestValidateStepReferences(t *testing.T) {
+	suite := &v1alpha1.SippTestSuite{Spec: v1alpha1.SippTestSuiteSpec{Steps: []v1alpha1.TestStep{
+		{Name: "callee", UAS: &v1alpha1.SippScenarioRunSpec{}},
+		{Name: "caller", Run: &v1alpha1.SippScenarioRunSpec{Destination: "$(steps.callee.adress)", ExtraArgs: []string{"$(steps.caller.name)"}}},
+	}}}
+
+	err := suite.Validate()
+	assert.EqualError(t, err, `[spec.steps[1].run.destination: Invalid value: "$(steps.callee.adress)": step callee has no output adress, `+
+		`spec.steps[1].run.extraArgs[0]: Invalid value: "$(steps.caller.name)": step caller must be a step this step depends on]`)
+}
@@ -21,89 +21,1319 @@ limitations under the License.
 package v1alpha1
 
 import (
-	"k8s.io/api/core/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArtifactsStorage) DeepCopyInto(out *ArtifactsStorage) {
+	*out = *in
+	if in.Size != nil {
+		in, out := &in.Size, &out.Size
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.StorageClassName != nil {
+		in, out := &in.StorageClassName, &out.StorageClassName
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArtifactsStorage.
+func (in *ArtifactsStorage) DeepCopy() *ArtifactsStorage {
+	if in == nil {
+		return nil
+	}
+	out := new(ArtifactsStorage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AssertStep) DeepCopyInto(out *AssertStep) {
+	*out = *in
+	if in.Succeeded != nil {
+		in, out := &in.Succeeded, &out.Succeeded
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ExitCodes != nil {
+		in, out := &in.ExitCodes, &out.ExitCodes
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]ExpectedCondition, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AssertStep.
+func (in *AssertStep) DeepCopy() *AssertStep {
+	if in == nil {
+		return nil
+	}
+	out := new(AssertStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Baseline) DeepCopyInto(out *Baseline) {
+	*out = *in
+	if in.Metrics != nil {
+		in, out := &in.Metrics, &out.Metrics
+		*out = new(RunMetrics)
+		(*in).DeepCopyInto(*out)
+	}
+	in.Tolerances.DeepCopyInto(&out.Tolerances)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Baseline.
+func (in *Baseline) DeepCopy() *Baseline {
+	if in == nil {
+		return nil
+	}
+	out := new(Baseline)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BaselineTolerances) DeepCopyInto(out *BaselineTolerances) {
+	*out = *in
+	if in.SuccessRatio != nil {
+		in, out := &in.SuccessRatio, &out.SuccessRatio
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ResponseTime != nil {
+		in, out := &in.ResponseTime, &out.ResponseTime
+		*out = new(int32)
+		**out = **in
+	}
+	if in.CallRate != nil {
+		in, out := &in.CallRate, &out.CallRate
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BaselineTolerances.
+func (in *BaselineTolerances) DeepCopy() *BaselineTolerances {
+	if in == nil {
+		return nil
+	}
+	out := new(BaselineTolerances)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CampaignEntry) DeepCopyInto(out *CampaignEntry) {
+	*out = *in
+	if in.ScenarioRef != nil {
+		in, out := &in.ScenarioRef, &out.ScenarioRef
+		*out = new(ScenarioReference)
+		**out = **in
+	}
+	if in.Transport != nil {
+		in, out := &in.Transport, &out.Transport
+		*out = new(Transport)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Rate != nil {
+		in, out := &in.Rate, &out.Rate
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Destination != nil {
+		in, out := &in.Destination, &out.Destination
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CampaignEntry.
+func (in *CampaignEntry) DeepCopy() *CampaignEntry {
+	if in == nil {
+		return nil
+	}
+	out := new(CampaignEntry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CampaignMatrix) DeepCopyInto(out *CampaignMatrix) {
+	*out = *in
+	if in.ScenarioRefs != nil {
+		in, out := &in.ScenarioRefs, &out.ScenarioRefs
+		*out = make([]ScenarioReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.Transports != nil {
+		in, out := &in.Transports, &out.Transports
+		*out = make([]Transport, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Rates != nil {
+		in, out := &in.Rates, &out.Rates
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
+	if in.Destinations != nil {
+		in, out := &in.Destinations, &out.Destinations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CampaignMatrix.
+func (in *CampaignMatrix) DeepCopy() *CampaignMatrix {
+	if in == nil {
+		return nil
+	}
+	out := new(CampaignMatrix)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CampaignResult) DeepCopyInto(out *CampaignResult) {
+	*out = *in
+	if in.Rate != nil {
+		in, out := &in.Rate, &out.Rate
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ExitCode != nil {
+		in, out := &in.ExitCode, &out.ExitCode
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Metrics != nil {
+		in, out := &in.Metrics, &out.Metrics
+		*out = new(RunMetrics)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CampaignResult.
+func (in *CampaignResult) DeepCopy() *CampaignResult {
+	if in == nil {
+		return nil
+	}
+	out := new(CampaignResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterSippScenario) DeepCopyInto(out *ClusterSippScenario) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterSippScenario.
+func (in *ClusterSippScenario) DeepCopy() *ClusterSippScenario {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterSippScenario)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterSippScenario) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterSippScenarioList) DeepCopyInto(out *ClusterSippScenarioList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterSippScenario, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterSippScenarioList.
+func (in *ClusterSippScenarioList) DeepCopy() *ClusterSippScenarioList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterSippScenarioList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterSippScenarioList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Condition) DeepCopyInto(out *Condition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Condition.
+func (in *Condition) DeepCopy() *Condition {
+	if in == nil {
+		return nil
+	}
+	out := new(Condition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ContentSource) DeepCopyInto(out *ContentSource) {
+	*out = *in
+	if in.ConfigMapKeyRef != nil {
+		in, out := &in.ConfigMapKeyRef, &out.ConfigMapKeyRef
+		*out = new(corev1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SecretKeyRef != nil {
+		in, out := &in.SecretKeyRef, &out.SecretKeyRef
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.URL != nil {
+		in, out := &in.URL, &out.URL
+		*out = new(URLSource)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContentSource.
+func (in *ContentSource) DeepCopy() *ContentSource {
+	if in == nil {
+		return nil
+	}
+	out := new(ContentSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExpectedCondition) DeepCopyInto(out *ExpectedCondition) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExpectedCondition.
+func (in *ExpectedCondition) DeepCopy() *ExpectedCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(ExpectedCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InjectionFile) DeepCopyInto(out *InjectionFile) {
+	*out = *in
+	if in.Columns != nil {
+		in, out := &in.Columns, &out.Columns
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Rows != nil {
+		in, out := &in.Rows, &out.Rows
+		*out = make([][]string, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = make([]string, len(*in))
+				copy(*out, *in)
+			}
+		}
+	}
+	if in.From != nil {
+		in, out := &in.From, &out.From
+		*out = new(ContentSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Index != nil {
+		in, out := &in.Index, &out.Index
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InjectionFile.
+func (in *InjectionFile) DeepCopy() *InjectionFile {
+	if in == nil {
+		return nil
+	}
+	out := new(InjectionFile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MediaAsset) DeepCopyInto(out *MediaAsset) {
+	*out = *in
+	if in.ConfigMapKeyRef != nil {
+		in, out := &in.ConfigMapKeyRef, &out.ConfigMapKeyRef
+		*out = new(corev1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SecretKeyRef != nil {
+		in, out := &in.SecretKeyRef, &out.SecretKeyRef
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PersistentVolumeClaim != nil {
+		in, out := &in.PersistentVolumeClaim, &out.PersistentVolumeClaim
+		*out = new(PersistentVolumeClaimFileSelector)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MediaAsset.
+func (in *MediaAsset) DeepCopy() *MediaAsset {
+	if in == nil {
+		return nil
+	}
+	out := new(MediaAsset)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricDelta) DeepCopyInto(out *MetricDelta) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricDelta.
+func (in *MetricDelta) DeepCopy() *MetricDelta {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricDelta)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectStorage) DeepCopyInto(out *ObjectStorage) {
+	*out = *in
+	if in.PathStyle != nil {
+		in, out := &in.PathStyle, &out.PathStyle
+		*out = new(bool)
+		**out = **in
+	}
+	if in.CredentialsSecret != nil {
+		in, out := &in.CredentialsSecret, &out.CredentialsSecret
+		*out = new(corev1.LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectStorage.
+func (in *ObjectStorage) DeepCopy() *ObjectStorage {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectStorage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PersistentVolumeClaimFileSelector) DeepCopyInto(out *PersistentVolumeClaimFileSelector) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PersistentVolumeClaimFileSelector.
+func (in *PersistentVolumeClaimFileSelector) DeepCopy() *PersistentVolumeClaimFileSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(PersistentVolumeClaimFileSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PortRange) DeepCopyInto(out *PortRange) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PortRange.
+func (in *PortRange) DeepCopy() *PortRange {
+	if in == nil {
+		return nil
+	}
+	out := new(PortRange)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RTPOptions) DeepCopyInto(out *RTPOptions) {
+	*out = *in
+	if in.Payload != nil {
+		in, out := &in.Payload, &out.Payload
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ThreadTasks != nil {
+		in, out := &in.ThreadTasks, &out.ThreadTasks
+		*out = new(int32)
+		**out = **in
+	}
+	if in.BufferSize != nil {
+		in, out := &in.BufferSize, &out.BufferSize
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RTPOptions.
+func (in *RTPOptions) DeepCopy() *RTPOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(RTPOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RetryPolicy) DeepCopyInto(out *RetryPolicy) {
+	*out = *in
+	if in.ExitCodes != nil {
+		in, out := &in.ExitCodes, &out.ExitCodes
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
+	if in.BackoffSeconds != nil {
+		in, out := &in.BackoffSeconds, &out.BackoffSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxBackoffSeconds != nil {
+		in, out := &in.MaxBackoffSeconds, &out.MaxBackoffSeconds
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RetryPolicy.
+func (in *RetryPolicy) DeepCopy() *RetryPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(RetryPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunAttempt) DeepCopyInto(out *RunAttempt) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.ExitCode != nil {
+		in, out := &in.ExitCode, &out.ExitCode
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunAttempt.
+func (in *RunAttempt) DeepCopy() *RunAttempt {
+	if in == nil {
+		return nil
+	}
+	out := new(RunAttempt)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunMetrics) DeepCopyInto(out *RunMetrics) {
+	*out = *in
+	if in.ResponseTimeP50 != nil {
+		in, out := &in.ResponseTimeP50, &out.ResponseTimeP50
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ResponseTimeP90 != nil {
+		in, out := &in.ResponseTimeP90, &out.ResponseTimeP90
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ResponseTimeP99 != nil {
+		in, out := &in.ResponseTimeP99, &out.ResponseTimeP99
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunMetrics.
+func (in *RunMetrics) DeepCopy() *RunMetrics {
+	if in == nil {
+		return nil
+	}
+	out := new(RunMetrics)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScenarioGrantFrom) DeepCopyInto(out *ScenarioGrantFrom) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScenarioGrantFrom.
+func (in *ScenarioGrantFrom) DeepCopy() *ScenarioGrantFrom {
+	if in == nil {
+		return nil
+	}
+	out := new(ScenarioGrantFrom)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScenarioGrantTo) DeepCopyInto(out *ScenarioGrantTo) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScenarioGrantTo.
+func (in *ScenarioGrantTo) DeepCopy() *ScenarioGrantTo {
+	if in == nil {
+		return nil
+	}
+	out := new(ScenarioGrantTo)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScenarioMessage) DeepCopyInto(out *ScenarioMessage) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScenarioMessage.
+func (in *ScenarioMessage) DeepCopy() *ScenarioMessage {
+	if in == nil {
+		return nil
+	}
+	out := new(ScenarioMessage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScenarioParameter) DeepCopyInto(out *ScenarioParameter) {
+	*out = *in
+	if in.Default != nil {
+		in, out := &in.Default, &out.Default
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScenarioParameter.
+func (in *ScenarioParameter) DeepCopy() *ScenarioParameter {
+	if in == nil {
+		return nil
+	}
+	out := new(ScenarioParameter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScenarioReference) DeepCopyInto(out *ScenarioReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScenarioReference.
+func (in *ScenarioReference) DeepCopy() *ScenarioReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ScenarioReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScenarioSummary) DeepCopyInto(out *ScenarioSummary) {
+	*out = *in
+	if in.Messages != nil {
+		in, out := &in.Messages, &out.Messages
+		*out = make([]ScenarioMessage, len(*in))
+		copy(*out, *in)
+	}
+	if in.Variables != nil {
+		in, out := &in.Variables, &out.Variables
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Fields != nil {
+		in, out := &in.Fields, &out.Fields
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
+	if in.Keywords != nil {
+		in, out := &in.Keywords, &out.Keywords
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.InjectValuesColumns != nil {
+		in, out := &in.InjectValuesColumns, &out.InjectValuesColumns
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScenarioSummary.
+func (in *ScenarioSummary) DeepCopy() *ScenarioSummary {
+	if in == nil {
+		return nil
+	}
+	out := new(ScenarioSummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SippCampaign) DeepCopyInto(out *SippCampaign) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SippCampaign.
+func (in *SippCampaign) DeepCopy() *SippCampaign {
+	if in == nil {
+		return nil
+	}
+	out := new(SippCampaign)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SippCampaign) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SippCampaignList) DeepCopyInto(out *SippCampaignList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SippCampaign, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SippCampaignList.
+func (in *SippCampaignList) DeepCopy() *SippCampaignList {
+	if in == nil {
+		return nil
+	}
+	out := new(SippCampaignList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SippCampaignList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SippCampaignSpec) DeepCopyInto(out *SippCampaignSpec) {
+	*out = *in
+	in.Template.DeepCopyInto(&out.Template)
+	in.Matrix.DeepCopyInto(&out.Matrix)
+	if in.Concurrency != nil {
+		in, out := &in.Concurrency, &out.Concurrency
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SippCampaignSpec.
+func (in *SippCampaignSpec) DeepCopy() *SippCampaignSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SippCampaignSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SippCampaignStatus) DeepCopyInto(out *SippCampaignStatus) {
+	*out = *in
+	if in.Results != nil {
+		in, out := &in.Results, &out.Results
+		*out = make([]CampaignResult, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SippCampaignStatus.
+func (in *SippCampaignStatus) DeepCopy() *SippCampaignStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SippCampaignStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SippScenario) DeepCopyInto(out *SippScenario) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SippScenario.
+func (in *SippScenario) DeepCopy() *SippScenario {
+	if in == nil {
+		return nil
+	}
+	out := new(SippScenario)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SippScenario) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SippScenarioGrant) DeepCopyInto(out *SippScenarioGrant) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SippScenarioGrant.
+func (in *SippScenarioGrant) DeepCopy() *SippScenarioGrant {
+	if in == nil {
+		return nil
+	}
+	out := new(SippScenarioGrant)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SippScenarioGrant) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SippScenarioGrantList) DeepCopyInto(out *SippScenarioGrantList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SippScenarioGrant, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SippScenarioGrantList.
+func (in *SippScenarioGrantList) DeepCopy() *SippScenarioGrantList {
+	if in == nil {
+		return nil
+	}
+	out := new(SippScenarioGrantList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SippScenarioGrantList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SippScenarioGrantSpec) DeepCopyInto(out *SippScenarioGrantSpec) {
+	*out = *in
+	if in.From != nil {
+		in, out := &in.From, &out.From
+		*out = make([]ScenarioGrantFrom, len(*in))
+		copy(*out, *in)
+	}
+	if in.To != nil {
+		in, out := &in.To, &out.To
+		*out = make([]ScenarioGrantTo, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SippScenarioGrantSpec.
+func (in *SippScenarioGrantSpec) DeepCopy() *SippScenarioGrantSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SippScenarioGrantSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SippScenarioList) DeepCopyInto(out *SippScenarioList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SippScenario, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SippScenarioList.
+func (in *SippScenarioList) DeepCopy() *SippScenarioList {
+	if in == nil {
+		return nil
+	}
+	out := new(SippScenarioList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SippScenarioList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SippScenarioRun) DeepCopyInto(out *SippScenarioRun) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SippScenarioRun.
+func (in *SippScenarioRun) DeepCopy() *SippScenarioRun {
+	if in == nil {
+		return nil
+	}
+	out := new(SippScenarioRun)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SippScenarioRun) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SippScenarioRunList) DeepCopyInto(out *SippScenarioRunList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SippScenarioRun, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SippScenarioRunList.
+func (in *SippScenarioRunList) DeepCopy() *SippScenarioRunList {
+	if in == nil {
+		return nil
+	}
+	out := new(SippScenarioRunList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SippScenarioRunList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SippScenarioRunSpec) DeepCopyInto(out *SippScenarioRunSpec) {
+	*out = *in
+	if in.Parallelism != nil {
+		in, out := &in.Parallelism, &out.Parallelism
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ImagePullSecrets != nil {
+		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
+		*out = make([]corev1.LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.JobAnnotations != nil {
+		in, out := &in.JobAnnotations, &out.JobAnnotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.StopGracePeriodSeconds != nil {
+		in, out := &in.StopGracePeriodSeconds, &out.StopGracePeriodSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.RetryPolicy != nil {
+		in, out := &in.RetryPolicy, &out.RetryPolicy
+		*out = new(RetryPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TTLSecondsAfterFinished != nil {
+		in, out := &in.TTLSecondsAfterFinished, &out.TTLSecondsAfterFinished
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ScenarioRef != nil {
+		in, out := &in.ScenarioRef, &out.ScenarioRef
+		*out = new(ScenarioReference)
+		**out = **in
+	}
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExtraArgs != nil {
+		in, out := &in.ExtraArgs, &out.ExtraArgs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Transport != nil {
+		in, out := &in.Transport, &out.Transport
+		*out = new(Transport)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CallLength != nil {
+		in, out := &in.CallLength, &out.CallLength
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Rate != nil {
+		in, out := &in.Rate, &out.Rate
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ExitWhenCallsProcessed != nil {
+		in, out := &in.ExitWhenCallsProcessed, &out.ExitWhenCallsProcessed
+		*out = new(bool)
+		**out = **in
+	}
+	if in.LocalPort != nil {
+		in, out := &in.LocalPort, &out.LocalPort
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MediaPort != nil {
+		in, out := &in.MediaPort, &out.MediaPort
+		*out = new(int32)
+		**out = **in
+	}
+	if in.RTPPortRange != nil {
+		in, out := &in.RTPPortRange, &out.RTPPortRange
+		*out = new(PortRange)
+		**out = **in
+	}
+	if in.RTP != nil {
+		in, out := &in.RTP, &out.RTP
+		*out = new(RTPOptions)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Traces != nil {
+		in, out := &in.Traces, &out.Traces
+		*out = new(TraceOptions)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Upload != nil {
+		in, out := &in.Upload, &out.Upload
+		*out = new(ObjectStorage)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Baseline != nil {
+		in, out := &in.Baseline, &out.Baseline
+		*out = new(Baseline)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SippScenario.
-func (in *SippScenario) DeepCopy() *SippScenario {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SippScenarioRunSpec.
+func (in *SippScenarioRunSpec) DeepCopy() *SippScenarioRunSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(SippScenario)
+	out := new(SippScenarioRunSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *SippScenario) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SippScenarioRunStatus) DeepCopyInto(out *SippScenarioRunStatus) {
+	*out = *in
+	if in.InjectValuesDigests != nil {
+		in, out := &in.InjectValuesDigests, &out.InjectValuesDigests
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
-	return nil
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Artifacts != nil {
+		in, out := &in.Artifacts, &out.Artifacts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Uploads != nil {
+		in, out := &in.Uploads, &out.Uploads
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PausedPods != nil {
+		in, out := &in.PausedPods, &out.PausedPods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.StoppedPods != nil {
+		in, out := &in.StoppedPods, &out.StoppedPods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Attempts != nil {
+		in, out := &in.Attempts, &out.Attempts
+		*out = make([]RunAttempt, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Metrics != nil {
+		in, out := &in.Metrics, &out.Metrics
+		*out = new(RunMetrics)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BaselineDeltas != nil {
+		in, out := &in.BaselineDeltas, &out.BaselineDeltas
+		*out = make([]MetricDelta, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SippScenarioRunStatus.
+func (in *SippScenarioRunStatus) DeepCopy() *SippScenarioRunStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SippScenarioRunStatus)
+	in.DeepCopyInto(out)
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *SippScenarioList) DeepCopyInto(out *SippScenarioList) {
+func (in *SippScenarioSpec) DeepCopyInto(out *SippScenarioSpec) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ListMeta.DeepCopyInto(&out.ListMeta)
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]SippScenario, len(*in))
+	if in.ScenarioFrom != nil {
+		in, out := &in.ScenarioFrom, &out.ScenarioFrom
+		*out = new(ContentSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.InjectValues != nil {
+		in, out := &in.InjectValues, &out.InjectValues
+		*out = make([]InjectionFile, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make([]ScenarioParameter, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.MediaAssets != nil {
+		in, out := &in.MediaAssets, &out.MediaAssets
+		*out = make([]MediaAsset, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SippScenarioList.
-func (in *SippScenarioList) DeepCopy() *SippScenarioList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SippScenarioSpec.
+func (in *SippScenarioSpec) DeepCopy() *SippScenarioSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(SippScenarioList)
+	out := new(SippScenarioSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *SippScenarioList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SippScenarioStatus) DeepCopyInto(out *SippScenarioStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
-	return nil
+	if in.Summary != nil {
+		in, out := &in.Summary, &out.Summary
+		*out = new(ScenarioSummary)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SippScenarioStatus.
+func (in *SippScenarioStatus) DeepCopy() *SippScenarioStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SippScenarioStatus)
+	in.DeepCopyInto(out)
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *SippScenarioRun) DeepCopyInto(out *SippScenarioRun) {
+func (in *SippTestSuite) DeepCopyInto(out *SippTestSuite) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SippScenarioRun.
-func (in *SippScenarioRun) DeepCopy() *SippScenarioRun {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SippTestSuite.
+func (in *SippTestSuite) DeepCopy() *SippTestSuite {
 	if in == nil {
 		return nil
 	}
-	out := new(SippScenarioRun)
+	out := new(SippTestSuite)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *SippScenarioRun) DeepCopyObject() runtime.Object {
+func (in *SippTestSuite) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -111,31 +1341,31 @@ func (in *SippScenarioRun) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *SippScenarioRunList) DeepCopyInto(out *SippScenarioRunList) {
+func (in *SippTestSuiteList) DeepCopyInto(out *SippTestSuiteList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]SippScenarioRun, len(*in))
+		*out = make([]SippTestSuite, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SippScenarioRunList.
-func (in *SippScenarioRunList) DeepCopy() *SippScenarioRunList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SippTestSuiteList.
+func (in *SippTestSuiteList) DeepCopy() *SippTestSuiteList {
 	if in == nil {
 		return nil
 	}
-	out := new(SippScenarioRunList)
+	out := new(SippTestSuiteList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *SippScenarioRunList) DeepCopyObject() runtime.Object {
+func (in *SippTestSuiteList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -143,88 +1373,164 @@ func (in *SippScenarioRunList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *SippScenarioRunSpec) DeepCopyInto(out *SippScenarioRunSpec) {
+func (in *SippTestSuiteSpec) DeepCopyInto(out *SippTestSuiteSpec) {
 	*out = *in
-	if in.Parallelism != nil {
-		in, out := &in.Parallelism, &out.Parallelism
-		*out = new(int32)
-		**out = **in
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]TestStep, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
-	if in.ImagePullSecrets != nil {
-		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
-		*out = make([]v1.LocalObjectReference, len(*in))
-		copy(*out, *in)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SippTestSuiteSpec.
+func (in *SippTestSuiteSpec) DeepCopy() *SippTestSuiteSpec {
+	if in == nil {
+		return nil
 	}
-	if in.JobAnnotations != nil {
-		in, out := &in.JobAnnotations, &out.JobAnnotations
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
+	out := new(SippTestSuiteSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SippTestSuiteStatus) DeepCopyInto(out *SippTestSuiteStatus) {
+	*out = *in
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]TestStepStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-	if in.ScenarioRef != nil {
-		in, out := &in.ScenarioRef, &out.ScenarioRef
-		*out = new(v1.LocalObjectReference)
-		**out = **in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
-	if in.Transport != nil {
-		in, out := &in.Transport, &out.Transport
-		*out = new(Transport)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SippTestSuiteStatus.
+func (in *SippTestSuiteStatus) DeepCopy() *SippTestSuiteStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SippTestSuiteStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StepCondition) DeepCopyInto(out *StepCondition) {
+	*out = *in
+	out.ExpectedCondition = in.ExpectedCondition
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StepCondition.
+func (in *StepCondition) DeepCopy() *StepCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(StepCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TestStep) DeepCopyInto(out *TestStep) {
+	*out = *in
+	if in.DependsOn != nil {
+		in, out := &in.DependsOn, &out.DependsOn
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.UAS != nil {
+		in, out := &in.UAS, &out.UAS
+		*out = new(SippScenarioRunSpec)
 		(*in).DeepCopyInto(*out)
 	}
-	if in.CallLength != nil {
-		in, out := &in.CallLength, &out.CallLength
-		*out = new(int32)
-		**out = **in
+	if in.Run != nil {
+		in, out := &in.Run, &out.Run
+		*out = new(SippScenarioRunSpec)
+		(*in).DeepCopyInto(*out)
 	}
-	if in.ExitWhenCallsProcessed != nil {
-		in, out := &in.ExitWhenCallsProcessed, &out.ExitWhenCallsProcessed
-		*out = new(bool)
-		**out = **in
+	if in.Wait != nil {
+		in, out := &in.Wait, &out.Wait
+		*out = new(WaitStep)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Assert != nil {
+		in, out := &in.Assert, &out.Assert
+		*out = new(AssertStep)
+		(*in).DeepCopyInto(*out)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SippScenarioRunSpec.
-func (in *SippScenarioRunSpec) DeepCopy() *SippScenarioRunSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TestStep.
+func (in *TestStep) DeepCopy() *TestStep {
 	if in == nil {
 		return nil
 	}
-	out := new(SippScenarioRunSpec)
+	out := new(TestStep)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *SippScenarioRunStatus) DeepCopyInto(out *SippScenarioRunStatus) {
+func (in *TestStepStatus) DeepCopyInto(out *TestStepStatus) {
 	*out = *in
+	if in.Outputs != nil {
+		in, out := &in.Outputs, &out.Outputs
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SippScenarioRunStatus.
-func (in *SippScenarioRunStatus) DeepCopy() *SippScenarioRunStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TestStepStatus.
+func (in *TestStepStatus) DeepCopy() *TestStepStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(SippScenarioRunStatus)
+	out := new(TestStepStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *SippScenarioSpec) DeepCopyInto(out *SippScenarioSpec) {
+func (in *TraceOptions) DeepCopyInto(out *TraceOptions) {
 	*out = *in
-	if in.InjectValues != nil {
-		in, out := &in.InjectValues, &out.InjectValues
-		*out = make([]string, len(*in))
-		copy(*out, *in)
+	if in.MaxFileSize != nil {
+		in, out := &in.MaxFileSize, &out.MaxFileSize
+		x := (*in).DeepCopy()
+		*out = &x
 	}
+	if in.MaxFiles != nil {
+		in, out := &in.MaxFiles, &out.MaxFiles
+		*out = new(int32)
+		**out = **in
+	}
+	in.Storage.DeepCopyInto(&out.Storage)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SippScenarioSpec.
-func (in *SippScenarioSpec) DeepCopy() *SippScenarioSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TraceOptions.
+func (in *TraceOptions) DeepCopy() *TraceOptions {
 	if in == nil {
 		return nil
 	}
-	out := new(SippScenarioSpec)
+	out := new(TraceOptions)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -248,3 +1554,43 @@ func (in *Transport) DeepCopy() *Transport {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *URLSource) DeepCopyInto(out *URLSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new URLSource.
+func (in *URLSource) DeepCopy() *URLSource {
+	if in == nil {
+		return nil
+	}
+	out := new(URLSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WaitStep) DeepCopyInto(out *WaitStep) {
+	*out = *in
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Condition != nil {
+		in, out := &in.Condition, &out.Condition
+		*out = new(StepCondition)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WaitStep.
+func (in *WaitStep) DeepCopy() *WaitStep {
+	if in == nil {
+		return nil
+	}
+	out := new(WaitStep)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,60 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The collector reads the metrics of a finished SippScenarioRun
+// from the sipp statistics files kept in its artifacts claim.
+// It runs in a Job created by the operator, with the operator image.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/alexandrevilain/sipp-operator/internal/metrics"
+)
+
+func main() {
+	var dir, pods, terminationLog string
+	flag.StringVar(&dir, "dir", "/artifacts", "The directory of the run artifacts, holding a directory per pod.")
+	flag.StringVar(&pods, "pods", "", "The comma separated pods of the last attempt of the run.")
+	flag.StringVar(&terminationLog, "termination-log", "/dev/termination-log", "The file the metrics are written to.")
+	flag.Parse()
+
+	if err := run(dir, strings.Split(pods, ","), terminationLog); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		_ = ioutil.WriteFile(terminationLog, []byte(err.Error()), 0644)
+		os.Exit(1)
+	}
+}
+
+func run(dir string, pods []string, terminationLog string) error {
+	result, err := metrics.CollectDir(dir, pods)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+
+	return ioutil.WriteFile(terminationLog, data, 0644)
+}
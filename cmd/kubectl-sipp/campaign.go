@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+)
+
+// runCampaign prints the comparison table of a campaign
+func runCampaign(flags *flag.FlagSet, options *globalOptions, args []string) error {
+	args, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return flag.ErrHelp
+	}
+
+	s, err := options.connect()
+	if err != nil {
+		return err
+	}
+
+	campaign := &v1alpha1.SippCampaign{}
+	if err := s.Get(context.Background(), types.NamespacedName{Namespace: s.namespace, Name: args[0]}, campaign); err != nil {
+		return err
+	}
+	return printCampaign(os.Stdout, campaign)
+}
+
+// printCampaign writes a row per run of the campaign, with its key metrics
+func printCampaign(out io.Writer, campaign *v1alpha1.SippCampaign) error {
+	writer := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(writer, "RUN\tSCENARIO\tTRANSPORT\tRATE\tDESTINATION\tPHASE\tSUCCESS\tCALL RATE\tP50\tP90\tP99")
+	for _, result := range campaign.Status.Results {
+		rate := "-"
+		if result.Rate != nil {
+			rate = strconv.FormatInt(int64(*result.Rate), 10)
+		}
+		success, callRate, p50, p90, p99 := "-", "-", "-", "-", "-"
+		if metrics := result.Metrics; metrics != nil {
+			if ratio, ok := metrics.SuccessRatio(); ok {
+				success = fmt.Sprintf("%.2f%%", ratio*100)
+			}
+			if metrics.CallRate != "" {
+				callRate = metrics.CallRate + "/s"
+			}
+			p50, p90, p99 = formatResponseTime(metrics.ResponseTimeP50), formatResponseTime(metrics.ResponseTimeP90), formatResponseTime(metrics.ResponseTimeP99)
+		}
+
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", result.Run, orDash(result.Scenario),
+			orDash(result.Transport), rate, orDash(result.Destination), result.Phase, success, callRate, p50, p90, p99)
+	}
+	return writer.Flush()
+}
+
+func formatResponseTime(responseTime *metav1.Duration) string {
+	if responseTime == nil {
+		return "-"
+	}
+	return responseTime.Duration.String()
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
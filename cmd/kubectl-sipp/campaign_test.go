@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+
+	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+)
+
+func TestPrintCampaign(t *testing.T) {
+	campaign := &v1alpha1.SippCampaign{
+		Status: v1alpha1.SippCampaignStatus{
+			Results: []v1alpha1.CampaignResult{
+				{
+					Run:       "campaign-0",
+					Scenario:  "uac",
+					Transport: "u1",
+					Rate:      pointer.Int32Ptr(10),
+					Phase:     v1alpha1.CampaignPhaseSucceeded,
+					Metrics: &v1alpha1.RunMetrics{
+						SuccessfulCalls: 199,
+						FailedCalls:     1,
+						CallRate:        "9.8",
+						ResponseTimeP50: &metav1.Duration{Duration: 20 * time.Millisecond},
+						ResponseTimeP90: &metav1.Duration{Duration: 50 * time.Millisecond},
+						ResponseTimeP99: &metav1.Duration{Duration: 200 * time.Millisecond},
+					},
+				},
+				{Run: "campaign-1", Scenario: "uac", Destination: "sbc:5060", Phase: v1alpha1.CampaignPhasePending},
+			},
+		},
+	}
+
+	out := &bytes.Buffer{}
+	require.NoError(t, printCampaign(out, campaign))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, []string{"RUN", "SCENARIO", "TRANSPORT", "RATE", "DESTINATION", "PHASE", "SUCCESS", "CALL", "RATE", "P50", "P90", "P99"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"campaign-0", "uac", "u1", "10", "-", "Succeeded", "99.50%", "9.8/s", "20ms", "50ms", "200ms"}, strings.Fields(lines[1]))
+	assert.Equal(t, []string{"campaign-1", "uac", "-", "-", "sbc:5060", "Pending", "-", "-", "-", "-", "-"}, strings.Fields(lines[2]))
+}
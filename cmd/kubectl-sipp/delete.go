@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+)
+
+// runDelete deletes runs, and the scenarios created by the run command
+// once no run uses them anymore
+func runDelete(flags *flag.FlagSet, options *globalOptions, args []string) error {
+	var keepScenario bool
+	flags.BoolVar(&keepScenario, "keep-scenario", false, "Keep the scenarios created by kubectl sipp run.")
+	args, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return flag.ErrHelp
+	}
+
+	s, err := options.connect()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	scenarios := map[string]bool{}
+	for _, name := range args {
+		run := &v1alpha1.SippScenarioRun{}
+		if err := s.Get(ctx, types.NamespacedName{Namespace: s.namespace, Name: name}, run); err != nil {
+			return err
+		}
+		if err := s.Delete(ctx, run, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil {
+			return err
+		}
+		fmt.Printf("sippscenariorun/%s deleted\n", run.Name)
+
+		if isManaged(run.Labels) && run.Spec.ScenarioRef != nil && !run.Spec.ScenarioRef.IsClusterScoped() && !run.IsCrossNamespace() {
+			scenarios[run.Spec.ScenarioRef.Name] = true
+		}
+	}
+	if keepScenario || len(scenarios) == 0 {
+		return nil
+	}
+
+	// Scenarios still used by other runs are kept
+	runs := &v1alpha1.SippScenarioRunList{}
+	if err := s.List(ctx, runs, client.InNamespace(s.namespace)); err != nil {
+		return err
+	}
+	for _, run := range runs.Items {
+		if run.DeletionTimestamp == nil && run.Spec.ScenarioRef != nil && !run.Spec.ScenarioRef.IsClusterScoped() && !run.IsCrossNamespace() {
+			delete(scenarios, run.Spec.ScenarioRef.Name)
+		}
+	}
+
+	for name := range scenarios {
+		scenario := &v1alpha1.SippScenario{}
+		err := s.Get(ctx, types.NamespacedName{Namespace: s.namespace, Name: name}, scenario)
+		if client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		if err != nil || !isManaged(scenario.Labels) {
+			continue
+		}
+		if err := s.Delete(ctx, scenario); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		fmt.Printf("sippscenario/%s deleted\n", name)
+	}
+
+	return nil
+}
+
+// isManaged returns whether the resource was created by the plugin
+func isManaged(labels map[string]string) bool {
+	for key, value := range managedByLabels {
+		if labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+
+	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	"github.com/alexandrevilain/sipp-operator/internal/cmdline"
+)
+
+// importer converts sipp command lines to manifests
+type importer struct {
+	name      string
+	namespace string
+	// baseDir is the directory the relative paths of the command lines are relative to
+	baseDir   string
+	scenarios map[string]*v1alpha1.SippScenario
+	objects   []runtime.Object
+}
+
+func (i *importer) readFile(file string) ([]byte, error) {
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(i.baseDir, file)
+	}
+	return ioutil.ReadFile(file)
+}
+
+// scenario returns the SippScenario with the spec, adding it when no imported one has the same
+func (i *importer) scenario(name string, spec *v1alpha1.SippScenarioSpec) *v1alpha1.SippScenario {
+	candidate := name
+	for n := 2; ; n++ {
+		scenario, ok := i.scenarios[candidate]
+		if !ok {
+			break
+		}
+		if equality.Semantic.DeepEqual(&scenario.Spec, spec) {
+			return scenario
+		}
+		candidate = fmt.Sprintf("%s-%d", name, n)
+	}
+
+	scenario := &v1alpha1.SippScenario{
+		TypeMeta:   metav1.TypeMeta{APIVersion: v1alpha1.GroupVersion.String(), Kind: "SippScenario"},
+		ObjectMeta: metav1.ObjectMeta{Name: candidate, Namespace: i.namespace},
+		Spec:       *spec,
+	}
+	i.scenarios[candidate] = scenario
+	i.objects = append(i.objects, scenario)
+	return scenario
+}
+
+// add converts the arguments of a sipp command line to a SippScenario and a SippScenarioRun,
+// the run is named after the scenario with the suffix when it is set
+func (i *importer) add(args []string, suffix string) ([]string, error) {
+	result, err := cmdline.Import(args, i.readFile)
+	if err != nil {
+		return nil, err
+	}
+
+	name := i.name
+	if name == "" {
+		name = "sipp"
+		if result.ScenarioFile != "" {
+			name = nameFromFile(result.ScenarioFile)
+		}
+	}
+	scenario := i.scenario(name, result.Scenario)
+
+	run := &v1alpha1.SippScenarioRun{
+		TypeMeta:   metav1.TypeMeta{APIVersion: v1alpha1.GroupVersion.String(), Kind: "SippScenarioRun"},
+		ObjectMeta: metav1.ObjectMeta{Name: name + suffix, Namespace: i.namespace},
+		Spec:       *result.Run,
+	}
+	run.Spec.ScenarioRef = &v1alpha1.ScenarioReference{Name: scenario.Name}
+	i.objects = append(i.objects, run)
+
+	return result.Warnings, nil
+}
+
+// runImport prints the manifests equivalent to a sipp command line,
+// or to the sipp command lines of a shell script
+func runImport(flags *flag.FlagSet, options *globalOptions, args []string) error {
+	var script string
+	i := &importer{scenarios: map[string]*v1alpha1.SippScenario{}, baseDir: "."}
+	flags.StringVar(&i.name, "name", "", "The name of the resources, defaults to the name of the scenario file.")
+	flags.StringVar(&script, "script", "", "A shell script, the resources of all its sipp command lines are printed.")
+	args, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	if (script == "") == (len(args) == 0) {
+		return flag.ErrHelp
+	}
+	i.namespace = options.namespace
+
+	if script == "" {
+		if path.Base(args[0]) == "sipp" {
+			args = args[1:]
+		}
+		warnings, err := i.add(args, "")
+		if err != nil {
+			return err
+		}
+		for _, warning := range warnings {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+		}
+		return i.print()
+	}
+
+	data, err := ioutil.ReadFile(script)
+	if err != nil {
+		return err
+	}
+	invocations, err := cmdline.FindInvocations(string(data))
+	if err != nil {
+		return fmt.Errorf("%s: %v", script, err)
+	}
+	if len(invocations) == 0 {
+		return fmt.Errorf("%s: no sipp command line found", script)
+	}
+
+	i.baseDir = filepath.Dir(script)
+	for n, invocation := range invocations {
+		suffix := ""
+		if len(invocations) > 1 {
+			suffix = fmt.Sprintf("-%d", n+1)
+		}
+		warnings, err := i.add(invocation.Args, suffix)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %v", script, invocation.Line, err)
+		}
+		for _, warning := range warnings {
+			fmt.Fprintf(os.Stderr, "warning: %s:%d: %s\n", script, invocation.Line, warning)
+		}
+	}
+	return i.print()
+}
+
+// print writes the manifests as a multi-document YAML stream
+func (i *importer) print() error {
+	for n, object := range i.objects {
+		data, err := yaml.Marshal(object)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			fmt.Println("---")
+		}
+		fmt.Print(string(data))
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+)
+
+// logStreamer prints the logs of the sipp instances of a run,
+// each line prefixed with the name of its pod
+type logStreamer struct {
+	session  *session
+	follow   bool
+	streamed map[string]bool
+	output   sync.Mutex
+	group    sync.WaitGroup
+}
+
+func newLogStreamer(s *session, follow bool) *logStreamer {
+	return &logStreamer{session: s, follow: follow, streamed: map[string]bool{}}
+}
+
+// streamNew starts printing the logs of the pods of the run whose sipp container started,
+// and which aren't printed yet. The pods of all the attempts are printed.
+func (l *logStreamer) streamNew(ctx context.Context, run *v1alpha1.SippScenarioRun) error {
+	pods := &corev1.PodList{}
+	err := l.session.List(ctx, pods, client.InNamespace(run.Namespace), client.MatchingLabels{"job-name": run.ChildResourceName("job")})
+	if err != nil {
+		return err
+	}
+	sort.Slice(pods.Items, func(i, j int) bool {
+		return pods.Items[i].CreationTimestamp.Before(&pods.Items[j].CreationTimestamp)
+	})
+
+	for _, pod := range pods.Items {
+		if l.streamed[pod.Name] || !sippStarted(&pod) {
+			continue
+		}
+		l.streamed[pod.Name] = true
+
+		l.group.Add(1)
+		go func(name string) {
+			defer l.group.Done()
+			if err := l.stream(ctx, name); err != nil && ctx.Err() == nil {
+				l.print(name, fmt.Sprintf("unable to read logs: %v", err))
+			}
+		}(pod.Name)
+	}
+
+	return nil
+}
+
+// sippStarted returns whether the sipp container of the pod is running or terminated
+func sippStarted(pod *corev1.Pod) bool {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == "sipp" && (status.State.Running != nil || status.State.Terminated != nil) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *logStreamer) stream(ctx context.Context, pod string) error {
+	request := l.session.clientset.CoreV1().Pods(l.session.namespace).GetLogs(pod, &corev1.PodLogOptions{
+		Container: "sipp",
+		Follow:    l.follow,
+	})
+	stream, err := request.Stream(ctx)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	reader := bufio.NewReader(stream)
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			l.print(pod, line)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (l *logStreamer) print(pod, line string) {
+	l.output.Lock()
+	defer l.output.Unlock()
+	fmt.Fprintf(os.Stdout, "[%s] %s", pod, line)
+	if line[len(line)-1] != '\n' {
+		fmt.Fprintln(os.Stdout)
+	}
+}
+
+// wait returns once the logs of the streamed pods are printed
+func (l *logStreamer) wait() {
+	l.group.Wait()
+}
+
+// runLogs prints the logs of the sipp instances of a run
+func runLogs(flags *flag.FlagSet, options *globalOptions, args []string) error {
+	var follow bool
+	flags.BoolVar(&follow, "f", false, "Follow the logs of the running sipp instances.")
+	args, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return flag.ErrHelp
+	}
+
+	s, err := options.connect()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	run := &v1alpha1.SippScenarioRun{}
+	if err := s.Get(ctx, types.NamespacedName{Namespace: s.namespace, Name: args[0]}, run); err != nil {
+		return err
+	}
+
+	streamer := newLogStreamer(s, follow)
+	if err := streamer.streamNew(ctx, run); err != nil {
+		return err
+	}
+	streamer.wait()
+
+	if len(streamer.streamed) == 0 {
+		return fmt.Errorf("no sipp instance of run %s has started", run.Name)
+	}
+	return nil
+}
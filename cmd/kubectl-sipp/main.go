@@ -0,0 +1,231 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// kubectl-sipp is a kubectl plugin working with the resources of the operator.
+// Install it in the PATH, then run it as kubectl sipp <command>.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+)
+
+// command is a subcommand of the plugin
+type command struct {
+	name        string
+	usage       string
+	description string
+	run         func(flags *flag.FlagSet, options *globalOptions, args []string) error
+}
+
+var commands = []command{
+	{
+		name:        "run",
+		usage:       "run -f SCENARIO.xml [--inf FILE.csv]...",
+		description: "Create a scenario from local files and run it, following its progress",
+		run:         runRun,
+	},
+	{
+		name:        "status",
+		usage:       "status [RUN]",
+		description: "Describe a run, or list the runs of the namespace",
+		run:         runStatus,
+	},
+	{
+		name:        "campaign",
+		usage:       "campaign CAMPAIGN",
+		description: "Print the comparison table of the runs of a campaign",
+		run:         runCampaign,
+	},
+	{
+		name:        "logs",
+		usage:       "logs RUN",
+		description: "Print the logs of the sipp instances of a run",
+		run:         runLogs,
+	},
+	{
+		name:        "delete",
+		usage:       "delete RUN...",
+		description: "Delete runs, and the scenarios created for them by kubectl sipp run",
+		run:         runDelete,
+	},
+	{
+		name:        "import",
+		usage:       "import [--script FILE.sh] [-- sipp ARGS...]",
+		description: "Print the SippScenario and SippScenarioRun manifests equivalent to sipp command lines",
+		run:         runImport,
+	},
+	{
+		name:        "from-pcap",
+		usage:       "from-pcap CAPTURE [--call-id ID] [--side caller|callee|ADDRESS]",
+		description: "Print the SippScenario replaying a call of a pcap or pcapng capture",
+		run:         runFromPcap,
+	},
+	{
+		name:        "report",
+		usage:       "report RUN",
+		description: "Print the JUnit report of a finished run",
+		run:         runReport,
+	},
+}
+
+// managedByLabels are set on the resources created by the plugin
+var managedByLabels = map[string]string{"app.kubernetes.io/managed-by": "kubectl-sipp"}
+
+// exitError ends the plugin with an exit code
+type exitError struct {
+	code    int
+	message string
+}
+
+func (e *exitError) Error() string {
+	return e.message
+}
+
+// globalOptions are the flags of all the commands
+type globalOptions struct {
+	kubeconfig string
+	namespace  string
+}
+
+func (o *globalOptions) addFlags(flags *flag.FlagSet) {
+	flags.StringVar(&o.kubeconfig, "kubeconfig", "", "Path to the kubeconfig file, defaults to the kubectl one.")
+	flags.StringVar(&o.namespace, "namespace", "", "The namespace of the resources, defaults to the one of the current context.")
+	flags.StringVar(&o.namespace, "n", "", "Shorthand for --namespace.")
+}
+
+// session holds the clients of the cluster and the namespace to work in
+type session struct {
+	client.Client
+	clientset kubernetes.Interface
+	namespace string
+}
+
+// connect returns a session with the cluster of the kubeconfig
+func (o *globalOptions) connect() (*session, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = o.kubeconfig
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{})
+
+	namespace := o.namespace
+	if namespace == "" {
+		var err error
+		if namespace, _, err = clientConfig.Namespace(); err != nil {
+			return nil, err
+		}
+	}
+
+	config, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = v1alpha1.AddToScheme(scheme)
+
+	c, err := client.New(config, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, err
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &session{Client: c, clientset: clientset, namespace: namespace}, nil
+}
+
+// parseArgs parses the flags placed before or after the positional arguments,
+// as kubectl does, and returns the positional arguments.
+// The arguments following -- are all positional.
+func parseArgs(flags *flag.FlagSet, args []string) ([]string, error) {
+	var trailing []string
+	for i, arg := range args {
+		if arg == "--" {
+			args, trailing = args[:i], args[i+1:]
+			break
+		}
+	}
+
+	positional := []string{}
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		args = flags.Args()
+		if len(args) == 0 {
+			return append(positional, trailing...), nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: kubectl sipp <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, command := range commands {
+		fmt.Fprintf(os.Stderr, "  %-30s %s\n", command.usage, command.description)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Run kubectl sipp <command> -h for the flags of a command.")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	for _, command := range commands {
+		if command.name != os.Args[1] {
+			continue
+		}
+
+		flags := flag.NewFlagSet("kubectl sipp "+command.name, flag.ExitOnError)
+		options := &globalOptions{}
+		options.addFlags(flags)
+		err := command.run(flags, options, os.Args[2:])
+		if err == flag.ErrHelp {
+			fmt.Fprintf(os.Stderr, "Usage: kubectl sipp %s [flags]\n", command.usage)
+			flags.PrintDefaults()
+			os.Exit(2)
+		}
+		if exit, ok := err.(*exitError); ok {
+			fmt.Fprintln(os.Stderr, exit.message)
+			os.Exit(exit.code)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	usage()
+	os.Exit(2)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"text/tabwriter"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	"github.com/alexandrevilain/sipp-operator/internal/capture"
+	"github.com/alexandrevilain/sipp-operator/internal/scenario"
+)
+
+// runFromPcap prints the SippScenario replaying a call of a packet capture
+func runFromPcap(flags *flag.FlagSet, options *globalOptions, args []string) error {
+	var callID, side, name, xmlFile string
+	flags.StringVar(&callID, "call-id", "", "The Call-ID of the call, required when the capture holds several calls.")
+	flags.StringVar(&side, "side", scenario.SideCaller, "The side played by sipp: caller, callee, or its address as ip or ip:port.")
+	flags.StringVar(&name, "name", "", "The name of the SippScenario, defaults to the name of the capture file.")
+	flags.StringVar(&xmlFile, "xml", "", "Also write the scenario file to this path.")
+	args, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return flag.ErrHelp
+	}
+
+	file, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	packets, err := capture.ReadPackets(file)
+	if err != nil {
+		return fmt.Errorf("%s: %v", args[0], err)
+	}
+
+	dialogs := capture.Dialogs(capture.Messages(packets))
+	if len(dialogs) == 0 {
+		return fmt.Errorf("%s: no SIP message found", args[0])
+	}
+
+	var dialog *capture.Dialog
+	for _, candidate := range dialogs {
+		if candidate.CallID == callID || (callID == "" && len(dialogs) == 1) {
+			dialog = candidate
+		}
+	}
+	if dialog == nil {
+		printDialogs(dialogs)
+		if callID == "" {
+			return fmt.Errorf("%s holds %d calls, choose one with --call-id", args[0], len(dialogs))
+		}
+		return fmt.Errorf("%s has no call with the Call-ID %q", args[0], callID)
+	}
+
+	if name == "" {
+		name = nameFromFile(args[0])
+	}
+	content, err := scenario.Generate(dialog.Messages, scenario.GenerateOptions{Name: name, Side: side})
+	if err != nil {
+		return err
+	}
+	if xmlFile != "" {
+		if err := ioutil.WriteFile(xmlFile, []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+
+	data, err := yaml.Marshal(&v1alpha1.SippScenario{
+		TypeMeta:   metav1.TypeMeta{APIVersion: v1alpha1.GroupVersion.String(), Kind: "SippScenario"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: options.namespace},
+		Spec:       v1alpha1.SippScenarioSpec{ScenarioFileContent: content},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
+
+// printDialogs lists the calls of a capture
+func printDialogs(dialogs []*capture.Dialog) {
+	writer := tabwriter.NewWriter(os.Stderr, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(writer, "CALL-ID\tFIRST MESSAGE\tFROM\tTO\tMESSAGES")
+	for _, dialog := range dialogs {
+		first := dialog.Messages[0]
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%d\n", dialog.CallID, first.StartLine, first.Src, first.Dst, len(dialog.Messages))
+	}
+	writer.Flush()
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	"github.com/alexandrevilain/sipp-operator/internal/report"
+)
+
+// runReport prints the JUnit report of a finished run, or writes it to a file
+func runReport(flags *flag.FlagSet, options *globalOptions, args []string) error {
+	var output string
+	flags.StringVar(&output, "o", "", "The file the report is written to, instead of the standard output.")
+	args, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return flag.ErrHelp
+	}
+
+	s, err := options.connect()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	run := &v1alpha1.SippScenarioRun{}
+	if err := s.Get(ctx, types.NamespacedName{Namespace: s.namespace, Name: args[0]}, run); err != nil {
+		return err
+	}
+	if run.Status.Report == "" {
+		return fmt.Errorf("run %s has no report yet, it is generated once the run is finished", run.Name)
+	}
+
+	configMap := &corev1.ConfigMap{}
+	if err := s.Get(ctx, types.NamespacedName{Namespace: s.namespace, Name: run.Status.Report}, configMap); err != nil {
+		return err
+	}
+
+	junit := configMap.Data[report.JUnitKey]
+	if output == "" {
+		_, err = fmt.Fprint(os.Stdout, junit)
+		return err
+	}
+	return ioutil.WriteFile(output, []byte(junit), 0644)
+}
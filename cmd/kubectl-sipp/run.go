@@ -0,0 +1,350 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	"github.com/alexandrevilain/sipp-operator/internal/content"
+	"github.com/alexandrevilain/sipp-operator/internal/shellwords"
+)
+
+const (
+	// exitFailed is the exit code of a failed run
+	exitFailed = 1
+	// exitRejected is the exit code of a run the operator refuses to start
+	exitRejected = 3
+	// exitRegressed is the exit code of a run regressed compared to its baseline
+	exitRegressed = 4
+	// exitTimeout is the exit code of a run still in progress after the timeout
+	exitTimeout = 5
+
+	pollInterval = 2 * time.Second
+)
+
+var invalidNameRegexp = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// stringList is a repeatable flag
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// runOptions are the flags of the run command
+type runOptions struct {
+	scenarioFile string
+	injectFiles  stringList
+	name         string
+	parameters   stringList
+	destination  string
+	transport    string
+	rate         int
+	callLength   int
+	duration     time.Duration
+	parallelism  int
+	image        string
+	extraArgs    string
+	detach       bool
+	logs         bool
+	timeout      time.Duration
+}
+
+func (o *runOptions) addFlags(flags *flag.FlagSet) {
+	flags.StringVar(&o.scenarioFile, "f", "", "The sipp scenario file.")
+	flags.Var(&o.injectFiles, "inf", "A sipp CSV file injecting values into the scenario, may be repeated.")
+	flags.StringVar(&o.name, "name", "", "The name of the SippScenario, defaults to the name of the scenario file. Runs are named after it. An existing SippScenario is only updated if kubectl sipp run created it.")
+	flags.Var(&o.parameters, "set", "A scenario parameter as name=value, may be repeated.")
+	flags.StringVar(&o.destination, "destination", "", "The remote host and port sipp calls.")
+	flags.StringVar(&o.transport, "transport", "", "The transport protocol: udp, tcp or tls.")
+	flags.IntVar(&o.rate, "rate", 0, "The number of calls started per second.")
+	flags.IntVar(&o.callLength, "call-length", 0, "The length of the calls, in milliseconds.")
+	flags.DurationVar(&o.duration, "duration", 0, "Stop the run gracefully after this duration.")
+	flags.IntVar(&o.parallelism, "parallelism", 0, "The number of sipp instances.")
+	flags.StringVar(&o.image, "image", "", "The sipp image.")
+	flags.StringVar(&o.extraArgs, "extra-args", "", "Extra sipp arguments, appended to the generated ones.")
+	flags.BoolVar(&o.detach, "detach", false, "Exit once the run is created instead of following it.")
+	flags.BoolVar(&o.logs, "logs", true, "Stream the logs of the sipp instances while following the run.")
+	flags.DurationVar(&o.timeout, "timeout", 0, "Stop following the run after this duration, 0 follows it until it is finished.")
+}
+
+// nameFromFile returns a resource name made of the base name of the file
+func nameFromFile(path string) string {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return strings.Trim(invalidNameRegexp.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+// scenarioName returns the name of the SippScenario created for the run
+func (o *runOptions) scenarioName() string {
+	if o.name != "" {
+		return o.name
+	}
+	return nameFromFile(o.scenarioFile)
+}
+
+// scenarioSpec returns the spec of the SippScenario holding the local files
+func (o *runOptions) scenarioSpec() (*v1alpha1.SippScenarioSpec, error) {
+	scenarioFile, err := ioutil.ReadFile(o.scenarioFile)
+	if err != nil {
+		return nil, err
+	}
+	spec := &v1alpha1.SippScenarioSpec{ScenarioFileContent: string(scenarioFile)}
+
+	for _, path := range o.injectFiles {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		injectValues, err := content.ParseInjectionFile(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		spec.InjectValues = append(spec.InjectValues, *injectValues)
+	}
+
+	return spec, nil
+}
+
+// runSpec returns the spec of the SippScenarioRun of the flags
+func (o *runOptions) runSpec() (*v1alpha1.SippScenarioRunSpec, error) {
+	spec := &v1alpha1.SippScenarioRunSpec{
+		ScenarioRef: &v1alpha1.ScenarioReference{Name: o.scenarioName()},
+		Destination: o.destination,
+		Image:       o.image,
+	}
+
+	for _, parameter := range o.parameters {
+		parts := strings.SplitN(parameter, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid parameter %q, expected name=value", parameter)
+		}
+		if spec.Parameters == nil {
+			spec.Parameters = map[string]string{}
+		}
+		spec.Parameters[parts[0]] = parts[1]
+	}
+
+	switch strings.ToUpper(o.transport) {
+	case "":
+	case string(v1alpha1.ProtocolUDP), string(v1alpha1.ProtocolTCP), string(v1alpha1.ProtocolTLS):
+		spec.Transport = &v1alpha1.Transport{Protocol: v1alpha1.Protocol(strings.ToUpper(o.transport)), Socket: v1alpha1.SocketOne}
+	default:
+		return nil, fmt.Errorf("invalid transport %q, expected udp, tcp or tls", o.transport)
+	}
+
+	if o.rate > 0 {
+		rate := int32(o.rate)
+		spec.Rate = &rate
+	}
+	if o.callLength > 0 {
+		callLength := int32(o.callLength)
+		spec.CallLength = &callLength
+	}
+	if o.duration > 0 {
+		spec.Duration = &metav1.Duration{Duration: o.duration}
+	}
+	if o.parallelism > 0 {
+		parallelism := int32(o.parallelism)
+		spec.Parallelism = &parallelism
+	}
+	if o.extraArgs != "" {
+		extraArgs, err := shellwords.Split(o.extraArgs)
+		if err != nil {
+			return nil, fmt.Errorf("invalid extra args: %v", err)
+		}
+		spec.ExtraArgs = extraArgs
+	}
+
+	return spec, nil
+}
+
+// updateScenario sets the spec of the SippScenario created for the run.
+// A SippScenario which exists but wasn't created by the plugin is never overwritten.
+func updateScenario(scenario *v1alpha1.SippScenario, spec *v1alpha1.SippScenarioSpec) error {
+	if scenario.CreationTimestamp.IsZero() {
+		scenario.Labels = managedByLabels
+	}
+	for key, value := range managedByLabels {
+		if scenario.Labels[key] != value {
+			return fmt.Errorf("sippscenario/%s already exists and wasn't created by kubectl sipp run, choose another name with --name", scenario.Name)
+		}
+	}
+
+	scenario.Spec = *spec
+	return nil
+}
+
+// runRun creates the SippScenario of the local files and a SippScenarioRun using it,
+// then follows the run until it is finished
+func runRun(flags *flag.FlagSet, options *globalOptions, args []string) error {
+	opts := &runOptions{}
+	opts.addFlags(flags)
+	args, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 0 || opts.scenarioFile == "" {
+		return flag.ErrHelp
+	}
+
+	scenarioSpec, err := opts.scenarioSpec()
+	if err != nil {
+		return err
+	}
+	runSpec, err := opts.runSpec()
+	if err != nil {
+		return err
+	}
+
+	s, err := options.connect()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	scenario := &v1alpha1.SippScenario{
+		ObjectMeta: metav1.ObjectMeta{Name: opts.scenarioName(), Namespace: s.namespace},
+	}
+	operation, err := controllerutil.CreateOrUpdate(ctx, s, scenario, func() error {
+		return updateScenario(scenario, scenarioSpec)
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("sippscenario/%s %s\n", scenario.Name, operation)
+
+	run := &v1alpha1.SippScenarioRun{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: scenario.Name + "-",
+			Namespace:    s.namespace,
+			Labels:       managedByLabels,
+		},
+		Spec: *runSpec,
+	}
+	if err := s.Create(ctx, run); err != nil {
+		return err
+	}
+	fmt.Printf("sippscenariorun/%s created\n", run.Name)
+
+	if opts.detach {
+		return nil
+	}
+	return s.follow(ctx, run.Name, opts.logs, opts.timeout)
+}
+
+// follow prints the progress of the run until it is finished,
+// and returns an exitError reflecting its outcome
+func (s *session) follow(ctx context.Context, name string, logs bool, timeout time.Duration) error {
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		deadline = time.After(timeout)
+	}
+
+	logsCtx, stopLogs := context.WithCancel(ctx)
+	defer stopLogs()
+	streamer := newLogStreamer(s, true)
+
+	progress := ""
+	conditions := map[v1alpha1.ConditionType]string{}
+	for {
+		run := &v1alpha1.SippScenarioRun{}
+		if err := s.Get(ctx, types.NamespacedName{Namespace: s.namespace, Name: name}, run); err != nil {
+			return err
+		}
+
+		if current := fmt.Sprintf("active %d, succeeded %d, failed %d", run.Status.Active, run.Status.Succeeded, run.Status.Failed); current != progress {
+			progress = current
+			fmt.Printf("sippscenariorun/%s: %s\n", name, progress)
+		}
+		for _, condition := range run.Status.Conditions {
+			current := fmt.Sprintf("%s %s", condition.Status, condition.Reason)
+			if conditions[condition.Type] == current {
+				continue
+			}
+			conditions[condition.Type] = current
+			message := ""
+			if condition.Message != "" {
+				message = ": " + condition.Message
+			}
+			fmt.Printf("sippscenariorun/%s: %s=%s (%s)%s\n", name, condition.Type, condition.Status, condition.Reason, message)
+		}
+
+		if logs {
+			if err := streamer.streamNew(logsCtx, run); err != nil {
+				return err
+			}
+		}
+
+		if done, err := outcome(run); done {
+			// The logs end with the sipp instances, which are over
+			streamer.wait()
+			if err == nil {
+				fmt.Printf("sippscenariorun/%s succeeded\n", name)
+			}
+			if run.Status.Report != "" {
+				fmt.Printf("JUnit report: kubectl sipp report %s -n %s\n", name, s.namespace)
+			}
+			return err
+		}
+
+		select {
+		case <-deadline:
+			stopLogs()
+			streamer.wait()
+			return &exitError{code: exitTimeout, message: fmt.Sprintf("sippscenariorun/%s is still in progress after %s", name, timeout)}
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// outcome returns whether nothing is left to follow of the run,
+// and the exitError of the outcome when it didn't succeed
+func outcome(run *v1alpha1.SippScenarioRun) (bool, error) {
+	for _, conditionType := range []v1alpha1.ConditionType{v1alpha1.ConditionValid, v1alpha1.ConditionReferenceGranted} {
+		if condition := v1alpha1.FindCondition(run.Status.Conditions, conditionType); condition != nil && condition.Status == corev1.ConditionFalse {
+			return true, &exitError{code: exitRejected, message: fmt.Sprintf("sippscenariorun/%s rejected: %s", run.Name, condition.Message)}
+		}
+	}
+
+	finished := v1alpha1.FindCondition(run.Status.Conditions, v1alpha1.ConditionFinished)
+	if finished == nil || finished.Status != corev1.ConditionTrue {
+		return false, nil
+	}
+
+	// The metrics compared with the baseline and the report come after the run
+	if run.CollectsMetrics() {
+		collected := v1alpha1.FindCondition(run.Status.Conditions, v1alpha1.ConditionMetricsCollected)
+		if collected == nil || collected.Status == corev1.ConditionUnknown {
+			return false, nil
+		}
+	}
+	if run.Status.Report == "" {
+		return false, nil
+	}
+
+	if finished.Reason == "Failed" {
+		return true, &exitError{code: exitFailed, message: fmt.Sprintf("sippscenariorun/%s failed: %s", run.Name, finished.Message)}
+	}
+	if v1alpha1.IsConditionTrue(run.Status.Conditions, v1alpha1.ConditionRegressed) {
+		regressed := v1alpha1.FindCondition(run.Status.Conditions, v1alpha1.ConditionRegressed)
+		return true, &exitError{code: exitRegressed, message: fmt.Sprintf("sippscenariorun/%s regressed: %s", run.Name, regressed.Message)}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+)
+
+func TestNameFromFile(t *testing.T) {
+	tests := map[string]string{
+		"uac.xml":                  "uac",
+		"scenarios/UAC Basic.xml":  "uac-basic",
+		"/tmp/uas_3pcc.v2.xml":     "uas-3pcc-v2",
+		"--register--.xml":         "register",
+		"scenarios/options":        "options",
+		"scénario d'appel (1).xml": "sc-nario-d-appel-1",
+	}
+
+	for path, expected := range tests {
+		assert.Equal(t, expected, nameFromFile(path), path)
+	}
+}
+
+func TestScenarioSpec(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubectl-sipp")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	scenarioFile := filepath.Join(dir, "uac.xml")
+	require.NoError(t, ioutil.WriteFile(scenarioFile, []byte(`<scenario name="uac"></scenario>`), 0644))
+	usersFile := filepath.Join(dir, "users.csv")
+	require.NoError(t, ioutil.WriteFile(usersFile, []byte("SEQUENTIAL\nalice;secret\nbob;password\n"), 0644))
+	invalidFile := filepath.Join(dir, "invalid.csv")
+	require.NoError(t, ioutil.WriteFile(invalidFile, []byte("alice;secret\n"), 0644))
+
+	opts := &runOptions{scenarioFile: scenarioFile, injectFiles: stringList{usersFile}}
+	spec, err := opts.scenarioSpec()
+	require.NoError(t, err)
+	assert.Equal(t, `<scenario name="uac"></scenario>`, spec.ScenarioFileContent)
+	require.Len(t, spec.InjectValues, 1)
+	assert.Equal(t, [][]string{{"alice", "secret"}, {"bob", "password"}}, spec.InjectValues[0].Rows)
+	assert.Equal(t, "uac", opts.scenarioName())
+
+	opts.injectFiles = stringList{invalidFile}
+	_, err = opts.scenarioSpec()
+	assert.Error(t, err)
+
+	opts = &runOptions{scenarioFile: filepath.Join(dir, "missing.xml")}
+	_, err = opts.scenarioSpec()
+	assert.Error(t, err)
+}
+
+func TestUpdateScenario(t *testing.T) {
+	spec := &v1alpha1.SippScenarioSpec{ScenarioFileContent: "<scenario/>"}
+
+	// A new SippScenario is labelled as created by the plugin
+	created := &v1alpha1.SippScenario{ObjectMeta: metav1.ObjectMeta{Name: "uac"}}
+	require.NoError(t, updateScenario(created, spec))
+	assert.Equal(t, managedByLabels, created.Labels)
+	assert.Equal(t, *spec, created.Spec)
+
+	// The SippScenarios the plugin created are updated
+	created.CreationTimestamp = metav1.Now()
+	updated := &v1alpha1.SippScenarioSpec{ScenarioFileContent: "<scenario name=\"v2\"/>"}
+	require.NoError(t, updateScenario(created, updated))
+	assert.Equal(t, *updated, created.Spec)
+
+	// The other ones are left as is
+	existing := &v1alpha1.SippScenario{
+		ObjectMeta: metav1.ObjectMeta{Name: "uac", CreationTimestamp: metav1.Now(), Labels: map[string]string{"team": "sbc"}},
+		Spec:       v1alpha1.SippScenarioSpec{ScenarioFileContent: "<scenario name=\"production\"/>"},
+	}
+	assert.EqualError(t, updateScenario(existing, spec), "sippscenario/uac already exists and wasn't created by kubectl sipp run, choose another name with --name")
+	assert.Equal(t, "<scenario name=\"production\"/>", existing.Spec.ScenarioFileContent)
+}
+
+func TestOutcome(t *testing.T) {
+	condition := func(conditionType v1alpha1.ConditionType, status corev1.ConditionStatus, reason string) v1alpha1.Condition {
+		return v1alpha1.Condition{Type: conditionType, Status: status, Reason: reason}
+	}
+	finished := condition(v1alpha1.ConditionFinished, corev1.ConditionTrue, "Succeeded")
+	failed := condition(v1alpha1.ConditionFinished, corev1.ConditionTrue, "Failed")
+
+	tests := []struct {
+		Name       string
+		Conditions []v1alpha1.Condition
+		Report     string
+		Done       bool
+		ExitCode   int
+		Phase      string
+	}{
+		{Name: "pending", Phase: "Pending"},
+		{Name: "invalid", Conditions: []v1alpha1.Condition{condition(v1alpha1.ConditionValid, corev1.ConditionFalse, "ValidationFailed")}, Done: true, ExitCode: exitRejected, Phase: "Rejected"},
+		{Name: "not granted", Conditions: []v1alpha1.Condition{condition(v1alpha1.ConditionReferenceGranted, corev1.ConditionFalse, "NotGranted")}, Done: true, ExitCode: exitRejected, Phase: "Rejected"},
+		{Name: "not reported", Conditions: []v1alpha1.Condition{finished}, Phase: "Finishing"},
+		{Name: "succeeded", Conditions: []v1alpha1.Condition{finished}, Report: "run-report", Done: true, Phase: "Succeeded"},
+		{Name: "failed", Conditions: []v1alpha1.Condition{failed}, Report: "run-report", Done: true, ExitCode: exitFailed, Phase: "Failed"},
+		{
+			Name:       "regressed",
+			Conditions: []v1alpha1.Condition{finished, condition(v1alpha1.ConditionRegressed, corev1.ConditionTrue, "Regressed")},
+			Report:     "run-report",
+			Done:       true,
+			ExitCode:   exitRegressed,
+			Phase:      "Regressed",
+		},
+	}
+
+	for _, test := range tests {
+		run := &v1alpha1.SippScenarioRun{ObjectMeta: metav1.ObjectMeta{Name: "run"}}
+		run.Status.Conditions = test.Conditions
+		run.Status.Report = test.Report
+
+		done, err := outcome(run)
+		assert.Equal(t, test.Done, done, test.Name)
+		assert.Equal(t, test.Phase, runPhase(run), test.Name)
+		if test.ExitCode == 0 {
+			assert.NoError(t, err, test.Name)
+			continue
+		}
+		exit, ok := err.(*exitError)
+		require.True(t, ok, test.Name)
+		assert.Equal(t, test.ExitCode, exit.code, test.Name)
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+)
+
+// runStatus describes a run, or lists the runs of the namespace
+func runStatus(flags *flag.FlagSet, options *globalOptions, args []string) error {
+	args, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(args) > 1 {
+		return flag.ErrHelp
+	}
+
+	s, err := options.connect()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	if len(args) == 0 {
+		return s.listRuns(ctx)
+	}
+
+	run := &v1alpha1.SippScenarioRun{}
+	if err := s.Get(ctx, types.NamespacedName{Namespace: s.namespace, Name: args[0]}, run); err != nil {
+		return err
+	}
+	describeRun(run)
+	return nil
+}
+
+// runPhase returns a one-word state of the run
+func runPhase(run *v1alpha1.SippScenarioRun) string {
+	if done, err := outcome(run); done {
+		if exit, ok := err.(*exitError); ok {
+			switch exit.code {
+			case exitRejected:
+				return "Rejected"
+			case exitRegressed:
+				return "Regressed"
+			}
+			return "Failed"
+		}
+		return "Succeeded"
+	}
+	if run.Status.Active > 0 {
+		return "Running"
+	}
+	if v1alpha1.IsConditionTrue(run.Status.Conditions, v1alpha1.ConditionFinished) {
+		return "Finishing"
+	}
+	return "Pending"
+}
+
+func (s *session) listRuns(ctx context.Context) error {
+	runs := &v1alpha1.SippScenarioRunList{}
+	if err := s.List(ctx, runs, client.InNamespace(s.namespace)); err != nil {
+		return err
+	}
+	if len(runs.Items) == 0 {
+		fmt.Fprintf(os.Stderr, "No runs found in namespace %s.\n", s.namespace)
+		return nil
+	}
+	sort.Slice(runs.Items, func(i, j int) bool {
+		return runs.Items[j].CreationTimestamp.Before(&runs.Items[i].CreationTimestamp)
+	})
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(writer, "NAME\tSCENARIO\tPHASE\tACTIVE\tSUCCEEDED\tFAILED\tAGE")
+	for i := range runs.Items {
+		run := &runs.Items[i]
+		scenario := ""
+		if run.Spec.ScenarioRef != nil {
+			scenario = run.Spec.ScenarioRef.Name
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%d\t%d\t%d\t%s\n", run.Name, scenario, runPhase(run),
+			run.Status.Active, run.Status.Succeeded, run.Status.Failed,
+			duration.HumanDuration(time.Since(run.CreationTimestamp.Time)))
+	}
+	return writer.Flush()
+}
+
+func describeRun(run *v1alpha1.SippScenarioRun) {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	defer writer.Flush()
+
+	fmt.Fprintf(writer, "Name:\t%s\n", run.Name)
+	fmt.Fprintf(writer, "Namespace:\t%s\n", run.Namespace)
+	if ref := run.Spec.ScenarioRef; ref != nil {
+		kind := ref.Kind
+		if kind == "" {
+			kind = v1alpha1.ScenarioKindSippScenario
+		}
+		fmt.Fprintf(writer, "Scenario:\t%s/%s\n", kind, ref.Name)
+	}
+	if run.Spec.Destination != "" {
+		fmt.Fprintf(writer, "Destination:\t%s\n", run.Spec.Destination)
+	}
+	if run.Spec.Transport != nil {
+		fmt.Fprintf(writer, "Transport:\t%s\n", run.Spec.Transport)
+	}
+	fmt.Fprintf(writer, "Phase:\t%s\n", runPhase(run))
+	fmt.Fprintf(writer, "Instances:\t%d active, %d succeeded, %d failed\n", run.Status.Active, run.Status.Succeeded, run.Status.Failed)
+
+	for _, attempt := range run.Status.Attempts {
+		result := "succeeded"
+		if !attempt.Succeeded {
+			result = "failed"
+			if attempt.ExitCode != nil {
+				result += fmt.Sprintf(" with exit code %d", *attempt.ExitCode)
+			}
+		}
+		fmt.Fprintf(writer, "Attempt %d:\t%s\n", attempt.Attempt, result)
+	}
+
+	if metrics := run.Status.Metrics; metrics != nil {
+		fmt.Fprintf(writer, "Calls:\t%d successful, %d failed\n", metrics.SuccessfulCalls, metrics.FailedCalls)
+		if metrics.CallRate != "" {
+			fmt.Fprintf(writer, "Call rate:\t%s/s\n", metrics.CallRate)
+		}
+		if metrics.ResponseTimeP50 != nil && metrics.ResponseTimeP90 != nil && metrics.ResponseTimeP99 != nil {
+			fmt.Fprintf(writer, "Response times:\tp50 %s, p90 %s, p99 %s\n",
+				metrics.ResponseTimeP50.Duration, metrics.ResponseTimeP90.Duration, metrics.ResponseTimeP99.Duration)
+		}
+	}
+	for _, delta := range run.Status.BaselineDeltas {
+		regressed := ""
+		if delta.Regressed {
+			regressed = " REGRESSED"
+		}
+		fmt.Fprintf(writer, "Baseline %s:\t%s (baseline %s, %s)%s\n", delta.Metric, delta.Value, delta.Baseline, delta.Delta, regressed)
+	}
+	if run.Status.Report != "" {
+		fmt.Fprintf(writer, "Report:\tkubectl sipp report %s -n %s\n", run.Name, run.Namespace)
+	}
+	writer.Flush()
+
+	if len(run.Status.Conditions) == 0 {
+		return
+	}
+	fmt.Fprintln(writer, "Conditions:")
+	fmt.Fprintln(writer, "  TYPE\tSTATUS\tREASON\tMESSAGE")
+	for _, condition := range run.Status.Conditions {
+		fmt.Fprintf(writer, "  %s\t%s\t%s\t%s\n", condition.Type, condition.Status, condition.Reason, condition.Message)
+	}
+}
@@ -0,0 +1,97 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The uploader uploads the artifacts of a finished SippScenarioRun
+// and its results summary to an S3 compatible object storage.
+// It runs in a Job created by the operator, with the operator image.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/alexandrevilain/sipp-operator/internal/upload"
+)
+
+// maxTerminationMessageSize is the size limit of the termination message
+// the operator reads the object URLs from
+const maxTerminationMessageSize = 4096
+
+func main() {
+	var endpoint, region, bucket, prefix, dir, terminationLog string
+	var pathStyle bool
+	flag.StringVar(&endpoint, "endpoint", "", "The endpoint of the object storage.")
+	flag.StringVar(&region, "region", upload.DefaultRegion, "The region of the bucket.")
+	flag.StringVar(&bucket, "bucket", "", "The bucket the artifacts are uploaded to.")
+	flag.StringVar(&prefix, "prefix", "", "The prefix of the object keys.")
+	flag.BoolVar(&pathStyle, "path-style", false, "Put the bucket in the URL path instead of the host name.")
+	flag.StringVar(&dir, "dir", "/artifacts", "The directory of the artifacts, it may not exist.")
+	flag.StringVar(&terminationLog, "termination-log", "/dev/termination-log", "The file the object URLs are written to.")
+	flag.Parse()
+
+	if err := run(endpoint, region, bucket, prefix, pathStyle, dir, terminationLog); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		_ = ioutil.WriteFile(terminationLog, []byte(err.Error()), 0644)
+		os.Exit(1)
+	}
+}
+
+func run(endpoint, region, bucket, prefix string, pathStyle bool, dir, terminationLog string) error {
+	client, err := upload.NewClient(endpoint, region, bucket, pathStyle, upload.Credentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	summaryURL, err := client.PutObject(ctx, path.Join(prefix, upload.SummaryKey), strings.NewReader(os.Getenv("SIPP_RUN_SUMMARY")))
+	if err != nil {
+		return err
+	}
+	urls := []string{summaryURL}
+
+	if _, err := os.Stat(dir); err == nil {
+		artifactURLs, err := upload.UploadDir(ctx, client, dir, prefix)
+		if err != nil {
+			return err
+		}
+		urls = append(urls, artifactURLs...)
+	}
+
+	for _, url := range urls {
+		fmt.Println(url)
+	}
+
+	// The URLs which don't fit in the termination message are only logged
+	message := ""
+	for _, url := range urls {
+		if len(message)+len(url)+1 > maxTerminationMessageSize {
+			break
+		}
+		message += url + "\n"
+	}
+
+	return ioutil.WriteFile(terminationLog, []byte(message), 0644)
+}
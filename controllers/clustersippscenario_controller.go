@@ -0,0 +1,78 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	"github.com/alexandrevilain/sipp-operator/internal/content"
+)
+
+// ClusterSippScenarioReconciler reconciles a ClusterSippScenario object
+type ClusterSippScenarioReconciler struct {
+	client.Client
+	Log             logr.Logger
+	Scheme          *runtime.Scheme
+	ContentResolver *content.Resolver
+}
+
+// +kubebuilder:rbac:groups=sipp.alexandrevilain.dev,resources=clustersippscenarios,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=sipp.alexandrevilain.dev,resources=clustersippscenarios/status,verbs=get;update;patch
+
+func (r *ClusterSippScenarioReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("clustersippscenario", req.Name)
+
+	clusterScenario := &v1alpha1.ClusterSippScenario{}
+	err := r.Get(ctx, req.NamespacedName, clusterScenario)
+	if err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	// The files of a cluster scenario are inline or fetched from URLs,
+	// which need no namespace to be resolved
+	condition, summary, resolveErr := checkScenario(ctx, r.ContentResolver, clusterScenario.ToSippScenario(""), clusterScenario.Validate())
+
+	log.Info("validated ClusterSippScenario", "status", condition.Status, "reason", condition.Reason)
+
+	v1alpha1.SetCondition(&clusterScenario.Status.Conditions, condition)
+	clusterScenario.Status.ObservedGeneration = clusterScenario.Generation
+	if resolveErr == nil {
+		clusterScenario.Status.Summary = summary
+	}
+
+	err = r.Status().Update(ctx, clusterScenario)
+	if err != nil {
+		log.Error(err, "unable to update ClusterSippScenario status")
+		return ctrl.Result{}, err
+	}
+
+	// Content served by URLs may become available later
+	return ctrl.Result{}, resolveErr
+}
+
+func (r *ClusterSippScenarioReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.ClusterSippScenario{}).
+		Complete(r)
+}
@@ -0,0 +1,210 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	"github.com/alexandrevilain/sipp-operator/internal/control"
+)
+
+// reconcileControl pauses, resumes or stops the sipp instances of the run
+// through their remote control port. The pause command toggles the traffic,
+// so the pods are annotated before any command is sent to them, and a command
+// is never sent twice for the same transition.
+// A stopped instance stops placing new calls, and is asked to quit immediately
+// once the grace period of the calls in progress is over.
+// It returns when the run must be reconciled again to control its instances.
+func (r *SippScenarioRunReconciler) reconcileControl(ctx context.Context, log logr.Logger, scenarioRun *v1alpha1.SippScenarioRun, childJob *batchv1.Job) (time.Duration, error) {
+	spec := scenarioRun.Spec
+	now := time.Now()
+	requeueAfter := time.Duration(0)
+	requeue := func(after time.Duration) {
+		if requeueAfter == 0 || after < requeueAfter {
+			requeueAfter = after
+		}
+	}
+
+	stop := spec.Stop
+	stopReason := "StopRequested"
+	if childJob.Status.StartTime != nil {
+		if deadline, ok := scenarioRun.Deadline(childJob.Status.StartTime.Time); ok {
+			if now.Before(deadline) {
+				requeue(deadline.Sub(now))
+			} else if !stop {
+				stop = true
+				stopReason = "DurationElapsed"
+			}
+		}
+	}
+
+	// The grace period starts when the run is first stopped
+	graceOver := false
+	if stop {
+		v1alpha1.SetCondition(&scenarioRun.Status.Conditions, v1alpha1.Condition{
+			Type:   v1alpha1.ConditionStopped,
+			Status: corev1.ConditionTrue,
+			Reason: stopReason,
+		})
+		stoppedAt := v1alpha1.FindCondition(scenarioRun.Status.Conditions, v1alpha1.ConditionStopped).LastTransitionTime.Time
+		graceDeadline := stoppedAt.Add(scenarioRun.StopGracePeriod())
+		if now.Before(graceDeadline) {
+			requeue(graceDeadline.Sub(now))
+		} else {
+			graceOver = true
+		}
+	}
+
+	// The pods are listed even when nothing is requested,
+	// as their annotations tell which ones must be resumed
+	pods := &corev1.PodList{}
+	err := r.List(ctx, pods, client.InNamespace(scenarioRun.Namespace), client.MatchingLabels{"job-name": scenarioRun.ChildResourceName("job")})
+	if err != nil {
+		return 0, err
+	}
+
+	pausedPods := []string{}
+	stoppedPods := []string{}
+
+	send := func(pod *corev1.Pod, command control.Command) bool {
+		if err := r.RemoteControl.Send(ctx, controlAddress(pod), command); err != nil {
+			log.Error(err, "unable to control sipp instance", "pod", pod.Name)
+			requeue(controlRequeueDelay)
+			return false
+		}
+		return true
+	}
+
+	// transition records the new state of the pod before sending the command,
+	// and restores it when the command can't be sent
+	transition := func(pod *corev1.Pod, annotation string, value bool, command control.Command) error {
+		if err := r.annotatePod(ctx, pod, annotation, value); err != nil {
+			return err
+		}
+		if !send(pod, command) {
+			return r.annotatePod(ctx, pod, annotation, !value)
+		}
+		return nil
+	}
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.Phase == corev1.PodPending || (pod.Status.Phase == corev1.PodRunning && pod.Status.PodIP == "") {
+			if spec.Suspend || stop {
+				requeue(controlRequeueDelay)
+			}
+			continue
+		}
+		// Finished pods are forgotten
+		if pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+
+		// A stopping instance is left as is, it quits once its calls are over
+		if !podAnnotated(pod, v1alpha1.StoppedAnnotation) {
+			isPaused := podAnnotated(pod, v1alpha1.PausedAnnotation)
+			switch {
+			case spec.Suspend && !stop && !isPaused:
+				err = transition(pod, v1alpha1.PausedAnnotation, true, control.Pause)
+			case !spec.Suspend && isPaused:
+				err = transition(pod, v1alpha1.PausedAnnotation, false, control.Pause)
+			}
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		if stop && !podAnnotated(pod, v1alpha1.StoppedAnnotation) {
+			if err := transition(pod, v1alpha1.StoppedAnnotation, true, control.SoftQuit); err != nil {
+				return 0, err
+			}
+		}
+
+		// Quitting immediately is idempotent, it is sent until the pod is over
+		if graceOver && podAnnotated(pod, v1alpha1.StoppedAnnotation) {
+			send(pod, control.Quit)
+			requeue(controlRequeueDelay)
+		}
+
+		if podAnnotated(pod, v1alpha1.PausedAnnotation) {
+			pausedPods = append(pausedPods, pod.Name)
+		}
+		if podAnnotated(pod, v1alpha1.StoppedAnnotation) {
+			stoppedPods = append(stoppedPods, pod.Name)
+		}
+	}
+
+	sort.Strings(pausedPods)
+	sort.Strings(stoppedPods)
+	scenarioRun.Status.PausedPods = pausedPods
+	scenarioRun.Status.StoppedPods = stoppedPods
+
+	if spec.Suspend || v1alpha1.FindCondition(scenarioRun.Status.Conditions, v1alpha1.ConditionSuspended) != nil {
+		condition := v1alpha1.Condition{
+			Type:   v1alpha1.ConditionSuspended,
+			Status: corev1.ConditionFalse,
+			Reason: "Resumed",
+		}
+		if len(pausedPods) > 0 {
+			condition.Status = corev1.ConditionTrue
+			condition.Reason = "Paused"
+		}
+		v1alpha1.SetCondition(&scenarioRun.Status.Conditions, condition)
+	}
+
+	return requeueAfter, nil
+}
+
+// podAnnotated returns whether the control annotation is set on the pod
+func podAnnotated(pod *corev1.Pod, annotation string) bool {
+	return pod.Annotations[annotation] == "true"
+}
+
+// annotatePod sets or removes the control annotation of the pod
+func (r *SippScenarioRunReconciler) annotatePod(ctx context.Context, pod *corev1.Pod, annotation string, value bool) error {
+	if value {
+		if pod.Annotations == nil {
+			pod.Annotations = map[string]string{}
+		}
+		pod.Annotations[annotation] = "true"
+	} else {
+		delete(pod.Annotations, annotation)
+	}
+	return r.Update(ctx, pod)
+}
+
+// controlAddress returns the address of the remote control port of the sipp instance.
+// On the host network, the pod IP is the node one and the port is reserved on the node.
+func controlAddress(pod *corev1.Pod) string {
+	port := int32(control.DefaultPort)
+	for _, container := range pod.Spec.Containers {
+		for _, containerPort := range container.Ports {
+			if containerPort.Name == control.PortName {
+				port = containerPort.ContainerPort
+			}
+		}
+	}
+	return control.Address(pod.Status.PodIP, port)
+}
@@ -0,0 +1,237 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	v1alpha1 "github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	"github.com/alexandrevilain/sipp-operator/internal/control"
+)
+
+// fakeSender records the commands sent to each address,
+// and fails sending them while failing is set
+type fakeSender struct {
+	sent    map[string][]control.Command
+	failing bool
+}
+
+func (s *fakeSender) Send(ctx context.Context, address string, command control.Command) error {
+	if s.failing {
+		return errors.New("network is unreachable")
+	}
+	if s.sent == nil {
+		s.sent = map[string][]control.Command{}
+	}
+	s.sent[address] = append(s.sent[address], command)
+	return nil
+}
+
+// newRunPod returns a running pod of the run job
+func newRunPod(name, ip string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"job-name": "run-job"}},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning, PodIP: ip},
+	}
+}
+
+// newControlTestReconciler returns a run reconciler sending the commands to the fake sender
+func newControlTestReconciler(t *testing.T, sender *fakeSender, objects ...*corev1.Pod) *SippScenarioRunReconciler {
+	pods := make([]runtime.Object, 0, len(objects))
+	for _, pod := range objects {
+		pods = append(pods, pod)
+	}
+	r := newTestRunReconciler(t, pods...)
+	r.RemoteControl = sender
+	return r
+}
+
+func TestReconcileControlPause(t *testing.T) {
+	sender := &fakeSender{}
+	r := newControlTestReconciler(t, sender, newRunPod("run-job-a", "10.0.0.1"), newRunPod("run-job-b", "10.0.0.2"))
+	run := newTestRun("run")
+	run.Spec.Suspend = true
+
+	_, err := r.reconcileControl(context.Background(), log.NullLogger{}, run.DeepCopy(), &batchv1.Job{})
+	require.NoError(t, err)
+
+	// The status isn't saved, as if its update failed: the pause isn't toggled back
+	suspended := run.DeepCopy()
+	_, err = r.reconcileControl(context.Background(), log.NullLogger{}, suspended, &batchv1.Job{})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string][]control.Command{
+		"10.0.0.1:8888": {control.Pause},
+		"10.0.0.2:8888": {control.Pause},
+	}, sender.sent)
+	assert.Equal(t, []string{"run-job-a", "run-job-b"}, suspended.Status.PausedPods)
+	assert.True(t, v1alpha1.IsConditionTrue(suspended.Status.Conditions, v1alpha1.ConditionSuspended))
+
+	pod := &corev1.Pod{}
+	require.True(t, exists(t, r, "default", "run-job-a", pod))
+	assert.Equal(t, "true", pod.Annotations[v1alpha1.PausedAnnotation])
+
+	// Resuming toggles the traffic once again
+	resumed := suspended.DeepCopy()
+	resumed.Spec.Suspend = false
+	for i := 0; i < 2; i++ {
+		_, err = r.reconcileControl(context.Background(), log.NullLogger{}, resumed, &batchv1.Job{})
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, []control.Command{control.Pause, control.Pause}, sender.sent["10.0.0.1:8888"])
+	assert.Empty(t, resumed.Status.PausedPods)
+	condition := v1alpha1.FindCondition(resumed.Status.Conditions, v1alpha1.ConditionSuspended)
+	require.NotNil(t, condition)
+	assert.Equal(t, "Resumed", condition.Reason)
+
+	pod = &corev1.Pod{}
+	require.True(t, exists(t, r, "default", "run-job-a", pod))
+	assert.NotContains(t, pod.Annotations, v1alpha1.PausedAnnotation)
+}
+
+func TestReconcileControlSendFailure(t *testing.T) {
+	sender := &fakeSender{failing: true}
+	r := newControlTestReconciler(t, sender, newRunPod("run-job-a", "10.0.0.1"))
+	run := newTestRun("run")
+	run.Spec.Suspend = true
+
+	// The pod isn't recorded as paused when the command can't be sent
+	requeueAfter, err := r.reconcileControl(context.Background(), log.NullLogger{}, run, &batchv1.Job{})
+	require.NoError(t, err)
+	assert.Equal(t, controlRequeueDelay, requeueAfter)
+	assert.Empty(t, run.Status.PausedPods)
+
+	pod := &corev1.Pod{}
+	require.True(t, exists(t, r, "default", "run-job-a", pod))
+	assert.NotContains(t, pod.Annotations, v1alpha1.PausedAnnotation)
+
+	sender.failing = false
+	_, err = r.reconcileControl(context.Background(), log.NullLogger{}, run, &batchv1.Job{})
+	require.NoError(t, err)
+	assert.Equal(t, []control.Command{control.Pause}, sender.sent["10.0.0.1:8888"])
+	assert.Equal(t, []string{"run-job-a"}, run.Status.PausedPods)
+}
+
+func TestReconcileControlStop(t *testing.T) {
+	sender := &fakeSender{}
+	paused := newRunPod("run-job-a", "10.0.0.1")
+	paused.Annotations = map[string]string{v1alpha1.PausedAnnotation: "true"}
+	pending := newRunPod("run-job-b", "")
+	pending.Status.Phase = corev1.PodPending
+	r := newControlTestReconciler(t, sender, paused, pending)
+
+	run := newTestRun("run")
+	run.Spec.Suspend = true
+	run.Spec.Stop = true
+	for i := 0; i < 2; i++ {
+		requeueAfter, err := r.reconcileControl(context.Background(), log.NullLogger{}, run, &batchv1.Job{})
+		require.NoError(t, err)
+		assert.Equal(t, controlRequeueDelay, requeueAfter)
+	}
+
+	// A paused instance is stopped as is, and only once
+	assert.Equal(t, map[string][]control.Command{"10.0.0.1:8888": {control.SoftQuit}}, sender.sent)
+	assert.Equal(t, []string{"run-job-a"}, run.Status.StoppedPods)
+	assert.Equal(t, []string{"run-job-a"}, run.Status.PausedPods)
+	condition := v1alpha1.FindCondition(run.Status.Conditions, v1alpha1.ConditionStopped)
+	require.NotNil(t, condition)
+	assert.Equal(t, "StopRequested", condition.Reason)
+
+	// A stopping instance isn't resumed
+	run.Spec.Suspend = false
+	_, err := r.reconcileControl(context.Background(), log.NullLogger{}, run, &batchv1.Job{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]control.Command{"10.0.0.1:8888": {control.SoftQuit}}, sender.sent)
+}
+
+func TestControlAddress(t *testing.T) {
+	pod := newRunPod("run-job-a", "192.168.1.10")
+	assert.Equal(t, "192.168.1.10:8888", controlAddress(pod))
+
+	pod.Spec.Containers = []corev1.Container{{
+		Name:  "sipp",
+		Ports: []corev1.ContainerPort{{Name: control.PortName, ContainerPort: 9888, HostPort: 9888, Protocol: corev1.ProtocolUDP}},
+	}}
+	assert.Equal(t, "192.168.1.10:9888", controlAddress(pod))
+}
+
+// startedJob returns a run job started the given time ago
+func startedJob(ago time.Duration) *batchv1.Job {
+	startTime := metav1.NewTime(time.Now().Add(-ago))
+	return &batchv1.Job{Status: batchv1.JobStatus{StartTime: &startTime}}
+}
+
+func TestReconcileControlDuration(t *testing.T) {
+	sender := &fakeSender{}
+	r := newControlTestReconciler(t, sender, newRunPod("run-job-a", "10.0.0.1"))
+	run := newTestRun("run")
+	run.Spec.Duration = &metav1.Duration{Duration: 5 * time.Minute}
+
+	// The run is requeued for when its duration elapses, from the start of its job
+	requeueAfter, err := r.reconcileControl(context.Background(), log.NullLogger{}, run, startedJob(time.Minute))
+	require.NoError(t, err)
+	assert.InDelta(t, float64(4*time.Minute), float64(requeueAfter), float64(time.Second))
+	assert.Nil(t, v1alpha1.FindCondition(run.Status.Conditions, v1alpha1.ConditionStopped))
+	assert.Empty(t, sender.sent)
+
+	// Once elapsed, the run is stopped and requeued for the end of the grace period
+	requeueAfter, err = r.reconcileControl(context.Background(), log.NullLogger{}, run, startedJob(10*time.Minute))
+	require.NoError(t, err)
+	assert.InDelta(t, float64(v1alpha1.DefaultStopGracePeriod), float64(requeueAfter), float64(time.Second))
+	condition := v1alpha1.FindCondition(run.Status.Conditions, v1alpha1.ConditionStopped)
+	require.NotNil(t, condition)
+	assert.Equal(t, "DurationElapsed", condition.Reason)
+	assert.Equal(t, map[string][]control.Command{"10.0.0.1:8888": {control.SoftQuit}}, sender.sent)
+}
+
+func TestReconcileControlGracePeriod(t *testing.T) {
+	sender := &fakeSender{}
+	stopping := newRunPod("run-job-a", "10.0.0.1")
+	stopping.Annotations = map[string]string{v1alpha1.StoppedAnnotation: "true"}
+	r := newControlTestReconciler(t, sender, stopping)
+
+	run := newTestRun("run")
+	run.Spec.Stop = true
+	run.Spec.StopGracePeriodSeconds = pointer.Int32Ptr(60)
+	run.Status.Conditions = []v1alpha1.Condition{{
+		Type:               v1alpha1.ConditionStopped,
+		Status:             corev1.ConditionTrue,
+		Reason:             "StopRequested",
+		LastTransitionTime: metav1.NewTime(time.Now().Add(-10 * time.Second)),
+	}}
+
+	// The grace period runs from the time the run was first stopped
+	requeueAfter, err := r.reconcileControl(context.Background(), log.NullLogger{}, run, startedJob(time.Hour))
+	require.NoError(t, err)
+	assert.InDelta(t, float64(50*time.Second), float64(requeueAfter), float64(time.Second))
+	assert.Empty(t, sender.sent)
+
+	// Once over, the instance is asked to quit immediately until its pod is over
+	run.Status.Conditions[0].LastTransitionTime = metav1.NewTime(time.Now().Add(-2 * time.Minute))
+	for i := 0; i < 2; i++ {
+		requeueAfter, err = r.reconcileControl(context.Background(), log.NullLogger{}, run, startedJob(time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, controlRequeueDelay, requeueAfter)
+	}
+	assert.Equal(t, map[string][]control.Command{"10.0.0.1:8888": {control.Quit, control.Quit}}, sender.sent)
+	assert.Equal(t, []string{"run-job-a"}, run.Status.StoppedPods)
+
+	stopping.Status.Phase = corev1.PodSucceeded
+	require.NoError(t, r.Status().Update(context.Background(), stopping))
+	requeueAfter, err = r.reconcileControl(context.Background(), log.NullLogger{}, run, startedJob(time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, requeueAfter)
+	assert.Len(t, sender.sent["10.0.0.1:8888"], 2)
+	assert.Empty(t, run.Status.StoppedPods)
+}
@@ -0,0 +1,85 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1alpha1 "github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+)
+
+// runScenarioNamespaceField indexes the runs by the namespace of the scenario
+// they use, when it isn't their own
+const runScenarioNamespaceField = "spec.scenarioRef.crossNamespace"
+
+// runIndexes are the fields the runs are indexed by
+var runIndexes = map[string]client.IndexerFunc{
+	runScenarioNamespaceField: func(object runtime.Object) []string {
+		run := object.(*v1alpha1.SippScenarioRun)
+		if !run.IsCrossNamespace() {
+			return nil
+		}
+		return []string{run.ScenarioNamespace()}
+	},
+}
+
+// isReferenceGranted returns whether a SippScenarioGrant of the scenario namespace
+// allows the run to use the scenario, runs can use the scenarios of their own namespace
+func (r *SippScenarioRunReconciler) isReferenceGranted(ctx context.Context, scenarioRun *v1alpha1.SippScenarioRun) (bool, error) {
+	if !scenarioRun.IsCrossNamespace() {
+		return true, nil
+	}
+
+	grants := &v1alpha1.SippScenarioGrantList{}
+	if err := r.List(ctx, grants, client.InNamespace(scenarioRun.ScenarioNamespace())); err != nil {
+		return false, err
+	}
+
+	for i := range grants.Items {
+		if grants.Items[i].Allows(scenarioRun.Namespace, scenarioRun.Spec.ScenarioRef.Name) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// grantRequests returns the runs using the scenarios of the grant namespace,
+// which are reconciled again when the grant changes
+func (r *SippScenarioRunReconciler) grantRequests(object handler.MapObject) []reconcile.Request {
+	runs := &v1alpha1.SippScenarioRunList{}
+	err := r.List(context.Background(), runs, client.MatchingFields{runScenarioNamespaceField: object.Meta.GetNamespace()})
+	if err != nil {
+		r.Log.Error(err, "unable to list SippScenarioRuns for SippScenarioGrant", "grant", object.Meta.GetName())
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for i := range runs.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: runs.Items[i].Namespace, Name: runs.Items[i].Name},
+		})
+	}
+
+	return requests
+}
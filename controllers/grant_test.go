@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1alpha1 "github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	"github.com/alexandrevilain/sipp-operator/internal/content"
+)
+
+// newGrantTestReconciler returns a run reconciler whose lists use the run indexes
+func newGrantTestReconciler(t *testing.T, objects ...runtime.Object) *SippScenarioRunReconciler {
+	r := newTestRunReconciler(t, objects...)
+	r.Client = indexedClient{Client: r.Client, indexes: runIndexes}
+	r.ContentResolver = content.NewResolver(r.Client)
+	return r
+}
+
+// newSharedRun returns a run of the default namespace using the scenario of the shared namespace
+func newSharedRun(name, scenarioName string) *v1alpha1.SippScenarioRun {
+	run := newTestRun(name)
+	run.Spec.ScenarioRef = &v1alpha1.ScenarioReference{Name: scenarioName, Namespace: "shared"}
+	return run
+}
+
+// newGrant returns a grant of the shared namespace allowing the namespace to use the scenarios
+func newGrant(namespace string, scenarioNames ...string) *v1alpha1.SippScenarioGrant {
+	grant := &v1alpha1.SippScenarioGrant{
+		ObjectMeta: metav1.ObjectMeta{Name: "grant", Namespace: "shared"},
+		Spec:       v1alpha1.SippScenarioGrantSpec{From: []v1alpha1.ScenarioGrantFrom{{Namespace: namespace}}},
+	}
+	for _, name := range scenarioNames {
+		grant.Spec.To = append(grant.Spec.To, v1alpha1.ScenarioGrantTo{Name: name})
+	}
+	return grant
+}
+
+func TestIsReferenceGranted(t *testing.T) {
+	local := newTestRun("run")
+	local.Spec.ScenarioRef = &v1alpha1.ScenarioReference{Name: "uac"}
+
+	tests := []struct {
+		Name    string
+		Run     *v1alpha1.SippScenarioRun
+		Grant   *v1alpha1.SippScenarioGrant
+		Granted bool
+	}{
+		{Name: "same namespace", Run: local, Granted: true},
+		{Name: "no grant", Run: newSharedRun("run", "uac")},
+		{Name: "namespace granted", Run: newSharedRun("run", "uac"), Grant: newGrant("default"), Granted: true},
+		{Name: "scenario granted", Run: newSharedRun("run", "uac"), Grant: newGrant("default", "uas", "uac"), Granted: true},
+		{Name: "other namespace granted", Run: newSharedRun("run", "uac"), Grant: newGrant("team-a")},
+		{Name: "other scenario granted", Run: newSharedRun("run", "uac"), Grant: newGrant("default", "uas")},
+	}
+
+	for _, test := range tests {
+		objects := []runtime.Object{}
+		if test.Grant != nil {
+			objects = append(objects, test.Grant)
+		}
+		r := newGrantTestReconciler(t, objects...)
+
+		granted, err := r.isReferenceGranted(context.Background(), test.Run)
+		require.NoError(t, err, test.Name)
+		assert.Equal(t, test.Granted, granted, test.Name)
+	}
+}
+
+func TestGrantRequests(t *testing.T) {
+	local := newTestRun("local")
+	local.Spec.ScenarioRef = &v1alpha1.ScenarioReference{Name: "uac"}
+	elsewhere := newTestRun("elsewhere")
+	elsewhere.Spec.ScenarioRef = &v1alpha1.ScenarioReference{Name: "uac", Namespace: "other"}
+	r := newGrantTestReconciler(t, newSharedRun("shared", "uac"), local, elsewhere)
+
+	// Only the runs using the scenarios of the grant namespace are reconciled again
+	grant := newGrant("default")
+	requests := r.grantRequests(handler.MapObject{Meta: grant, Object: grant})
+	assert.Equal(t, []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: "default", Name: "shared"}}}, requests)
+}
+
+func TestReconcileGrant(t *testing.T) {
+	scenario := &v1alpha1.SippScenario{
+		ObjectMeta: metav1.ObjectMeta{Name: "uac", Namespace: "shared"},
+		Spec:       v1alpha1.SippScenarioSpec{ScenarioFileContent: `<scenario name="uac"></scenario>`},
+	}
+	r := newGrantTestReconciler(t, newSharedRun("run", "uac"), scenario)
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "run"}}
+	referenceGranted := func() *v1alpha1.Condition {
+		_, err := r.Reconcile(request)
+		require.NoError(t, err)
+		run := &v1alpha1.SippScenarioRun{}
+		require.True(t, exists(t, r, "default", "run", run))
+		condition := v1alpha1.FindCondition(run.Status.Conditions, v1alpha1.ConditionReferenceGranted)
+		require.NotNil(t, condition)
+		return condition
+	}
+
+	// Denied without a grant
+	condition := referenceGranted()
+	assert.Equal(t, corev1.ConditionFalse, condition.Status)
+	assert.Equal(t, "no SippScenarioGrant in namespace shared allows namespace default to use SippScenario uac", condition.Message)
+	assert.False(t, exists(t, r, "default", "run-job", &batchv1.Job{}))
+
+	// Granted once the grant is created, which reconciles the run again
+	grant := newGrant("default", "uac")
+	require.NoError(t, r.Create(context.Background(), grant))
+	assert.Len(t, r.grantRequests(handler.MapObject{Meta: grant, Object: grant}), 1)
+	condition = referenceGranted()
+	assert.Equal(t, corev1.ConditionTrue, condition.Status)
+	assert.True(t, exists(t, r, "default", "run-job", &batchv1.Job{}))
+
+	// Denied again once the grant is revoked
+	require.NoError(t, r.Delete(context.Background(), grant))
+	condition = referenceGranted()
+	assert.Equal(t, corev1.ConditionFalse, condition.Status)
+	assert.Equal(t, "NotGranted", condition.Reason)
+}
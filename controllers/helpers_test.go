@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	v1alpha1 "github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+)
+
+// newTestScheme returns the scheme of the objects handled by the controllers
+func newTestScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+	return scheme
+}
+
+// newTestRunReconciler returns a run reconciler backed by a fake client holding the objects
+func newTestRunReconciler(t *testing.T, objects ...runtime.Object) *SippScenarioRunReconciler {
+	scheme := newTestScheme(t)
+	return &SippScenarioRunReconciler{
+		Client: fake.NewFakeClientWithScheme(scheme, objects...),
+		Log:    log.NullLogger{},
+		Scheme: scheme,
+	}
+}
+
+// newTestRun returns a run of the default namespace
+func newTestRun(name string) *v1alpha1.SippScenarioRun {
+	return &v1alpha1.SippScenarioRun{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: types.UID(name + "-uid")},
+	}
+}
+
+// ownedBy returns the object meta of an object controlled by the run
+func ownedBy(run *v1alpha1.SippScenarioRun, name string, labels map[string]string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      name,
+		Namespace: run.Namespace,
+		Labels:    labels,
+		OwnerReferences: []metav1.OwnerReference{{
+			APIVersion: v1alpha1.GroupVersion.String(),
+			Kind:       "SippScenarioRun",
+			Name:       run.Name,
+			UID:        run.UID,
+			Controller: pointer.BoolPtr(true),
+		}},
+	}
+}
+
+// rejectingClient is a client whose creations and updates are rejected by the API
+type rejectingClient struct {
+	client.Client
+}
+
+func (c rejectingClient) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	return apierrors.NewForbidden(schema.GroupResource{}, "", errors.New("exceeded quota"))
+}
+
+func (c rejectingClient) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	return apierrors.NewForbidden(schema.GroupResource{}, "", errors.New("exceeded quota"))
+}
+
+// exists returns whether the object is found by the client
+func exists(t *testing.T, c client.Client, namespace, name string, object runtime.Object) bool {
+	err := c.Get(context.Background(), types.NamespacedName{Namespace: namespace, Name: name}, object)
+	if err != nil {
+		require.True(t, client.IgnoreNotFound(err) == nil, err)
+		return false
+	}
+	return true
+}
+
+// indexedClient is a client filtering the lists by the fields of its indexes,
+// as the cache of the manager does while the fake client ignores them
+type indexedClient struct {
+	client.Client
+	indexes map[string]client.IndexerFunc
+}
+
+func (c indexedClient) List(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	if err := c.Client.List(ctx, list, opts...); err != nil {
+		return err
+	}
+
+	listOpts := &client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	if listOpts.FieldSelector == nil || listOpts.FieldSelector.Empty() {
+		return nil
+	}
+
+	objects, err := apimeta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+	kept := []runtime.Object{}
+	for _, object := range objects {
+		matches := true
+		for _, requirement := range listOpts.FieldSelector.Requirements() {
+			extractValue, ok := c.indexes[requirement.Field]
+			if !ok {
+				return fmt.Errorf("no index for field %s", requirement.Field)
+			}
+			matches = matches && containsString(extractValue(object), requirement.Value)
+		}
+		if matches {
+			kept = append(kept, object)
+		}
+	}
+	return apimeta.SetList(list, kept)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,276 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	"github.com/alexandrevilain/sipp-operator/internal/report"
+	"github.com/alexandrevilain/sipp-operator/internal/resource"
+)
+
+// finishedCondition returns the Finished condition of a run once its job is over,
+// which fails when any of its sipp instances failed
+func finishedCondition(childJob *batchv1.Job, instances []report.Instance) v1alpha1.Condition {
+	condition := v1alpha1.Condition{
+		Type:   v1alpha1.ConditionFinished,
+		Status: corev1.ConditionTrue,
+		Reason: "Succeeded",
+	}
+
+	for _, jobCondition := range childJob.Status.Conditions {
+		if jobCondition.Type == batchv1.JobFailed && jobCondition.Status == corev1.ConditionTrue {
+			condition.Reason = "Failed"
+			condition.Message = jobCondition.Message
+		}
+	}
+
+	failed := 0
+	for _, instance := range instances {
+		if instance.Failed() {
+			failed++
+		}
+	}
+	if failed > 0 && condition.Reason != "Failed" {
+		condition.Reason = "Failed"
+		condition.Message = fmt.Sprintf("%d of %d sipp instances failed", failed, len(instances))
+	}
+
+	return condition
+}
+
+// isSettled returns whether nothing is left to do for the run:
+// it is finished and reported, and its metrics are collected and its artifacts uploaded when needed
+func (r *SippScenarioRunReconciler) isSettled(scenarioRun *v1alpha1.SippScenarioRun) bool {
+	if !v1alpha1.IsConditionTrue(scenarioRun.Status.Conditions, v1alpha1.ConditionFinished) || scenarioRun.Status.Report == "" {
+		return false
+	}
+
+	if scenarioRun.CollectsMetrics() {
+		collected := v1alpha1.FindCondition(scenarioRun.Status.Conditions, v1alpha1.ConditionMetricsCollected)
+		if collected == nil || collected.Status == corev1.ConditionUnknown {
+			return false
+		}
+	}
+
+	if r.UploadSettings.ForRun(scenarioRun).Enabled() {
+		uploaded := v1alpha1.FindCondition(scenarioRun.Status.Conditions, v1alpha1.ConditionUploaded)
+		return uploaded != nil && uploaded.Status != corev1.ConditionUnknown
+	}
+
+	return true
+}
+
+// finishedAt returns the time the run finished at
+func finishedAt(scenarioRun *v1alpha1.SippScenarioRun) time.Time {
+	condition := v1alpha1.FindCondition(scenarioRun.Status.Conditions, v1alpha1.ConditionFinished)
+	if condition == nil {
+		return time.Time{}
+	}
+	return condition.LastTransitionTime.Time
+}
+
+// reconcileTTL deletes the settled run once its TTL expired,
+// or requeues it for when it expires
+func (r *SippScenarioRunReconciler) reconcileTTL(ctx context.Context, log logr.Logger, scenarioRun *v1alpha1.SippScenarioRun) (ctrl.Result, error) {
+	if scenarioRun.Spec.TTLSecondsAfterFinished == nil {
+		return ctrl.Result{}, nil
+	}
+
+	ttl := time.Duration(*scenarioRun.Spec.TTLSecondsAfterFinished) * time.Second
+	remaining := time.Until(finishedAt(scenarioRun).Add(ttl))
+	if remaining > 0 {
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+
+	log.Info("deleting SippScenarioRun after its TTL expired")
+	err := r.Delete(ctx, scenarioRun, client.PropagationPolicy(metav1.DeletePropagationBackground))
+	return ctrl.Result{}, client.IgnoreNotFound(err)
+}
+
+// historyLimit returns the history limit set by the annotation of the namespace,
+// or -1 when there is none
+func historyLimit(log logr.Logger, namespace *corev1.Namespace, annotation string) int {
+	value, ok := namespace.Annotations[annotation]
+	if !ok {
+		return -1
+	}
+
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 0 {
+		log.Info("ignoring invalid history limit", "annotation", annotation, "value", value)
+		return -1
+	}
+	return limit
+}
+
+// pruneHistory garbage-collects the oldest settled runs of the namespace
+// beyond its history limits
+func (r *SippScenarioRunReconciler) pruneHistory(ctx context.Context, log logr.Logger, namespaceName string) error {
+	namespace := &corev1.Namespace{}
+	if err := r.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	succeededLimit := historyLimit(log, namespace, v1alpha1.SucceededRunsHistoryLimitAnnotation)
+	failedLimit := historyLimit(log, namespace, v1alpha1.FailedRunsHistoryLimitAnnotation)
+	if succeededLimit < 0 && failedLimit < 0 {
+		return nil
+	}
+
+	keep := v1alpha1.HistoryKeep(namespace.Annotations[v1alpha1.RunsHistoryKeepAnnotation])
+
+	runs := &v1alpha1.SippScenarioRunList{}
+	if err := r.List(ctx, runs, client.InNamespace(namespaceName)); err != nil {
+		return err
+	}
+
+	succeeded := []*v1alpha1.SippScenarioRun{}
+	failed := []*v1alpha1.SippScenarioRun{}
+	for i := range runs.Items {
+		run := &runs.Items[i]
+		if !r.isSettled(run) || v1alpha1.IsConditionTrue(run.Status.Conditions, v1alpha1.ConditionPruned) {
+			continue
+		}
+
+		if v1alpha1.FindCondition(run.Status.Conditions, v1alpha1.ConditionFinished).Reason == "Failed" {
+			failed = append(failed, run)
+		} else {
+			succeeded = append(succeeded, run)
+		}
+	}
+
+	for _, history := range []struct {
+		runs  []*v1alpha1.SippScenarioRun
+		limit int
+	}{{succeeded, succeededLimit}, {failed, failedLimit}} {
+		if history.limit < 0 || len(history.runs) <= history.limit {
+			continue
+		}
+
+		// The most recent runs are kept
+		sort.Slice(history.runs, func(i, j int) bool {
+			return finishedAt(history.runs[i]).After(finishedAt(history.runs[j]))
+		})
+
+		for _, run := range history.runs[history.limit:] {
+			log.Info("pruning SippScenarioRun beyond the history limit", "run", run.Name, "keep", keep)
+			if err := r.pruneRun(ctx, run, keep); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// pruneRun deletes the run, or only its resources according to what is kept.
+// The run is recorded as pruned before its resources are deleted, so a stale
+// run is never left without its job and started again.
+func (r *SippScenarioRunReconciler) pruneRun(ctx context.Context, scenarioRun *v1alpha1.SippScenarioRun, keep v1alpha1.HistoryKeep) error {
+	if keep != v1alpha1.HistoryKeepResults && keep != v1alpha1.HistoryKeepArtifacts {
+		err := r.Delete(ctx, scenarioRun, client.PropagationPolicy(metav1.DeletePropagationBackground))
+		return client.IgnoreNotFound(err)
+	}
+
+	v1alpha1.SetCondition(&scenarioRun.Status.Conditions, v1alpha1.Condition{
+		Type:   v1alpha1.ConditionPruned,
+		Status: corev1.ConditionTrue,
+		Reason: "HistoryLimit",
+	})
+	if err := r.Status().Update(ctx, scenarioRun); err != nil {
+		return err
+	}
+
+	return r.deletePruned(ctx, scenarioRun, keep)
+}
+
+// deletePruned deletes the resources of a pruned run which aren't kept
+func (r *SippScenarioRunReconciler) deletePruned(ctx context.Context, scenarioRun *v1alpha1.SippScenarioRun, keep v1alpha1.HistoryKeep) error {
+	lists := []runtime.Object{&batchv1.JobList{}, &corev1.ConfigMapList{}, &corev1.SecretList{}}
+	if keep != v1alpha1.HistoryKeepArtifacts {
+		lists = append(lists, &corev1.PersistentVolumeClaimList{})
+	}
+
+	for _, list := range lists {
+		if err := r.deleteOwned(ctx, scenarioRun, list); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// finishPruning deletes the resources a pruned run may still have,
+// when its pruning was interrupted after it was recorded
+func (r *SippScenarioRunReconciler) finishPruning(ctx context.Context, scenarioRun *v1alpha1.SippScenarioRun) error {
+	namespace := &corev1.Namespace{}
+	if err := r.Get(ctx, types.NamespacedName{Name: scenarioRun.Namespace}, namespace); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	keep := v1alpha1.HistoryKeep(namespace.Annotations[v1alpha1.RunsHistoryKeepAnnotation])
+	return r.deletePruned(ctx, scenarioRun, keep)
+}
+
+// deleteOwned deletes the objects of the list kind controlled by the run
+func (r *SippScenarioRunReconciler) deleteOwned(ctx context.Context, scenarioRun *v1alpha1.SippScenarioRun, list runtime.Object) error {
+	err := r.List(ctx, list, client.InNamespace(scenarioRun.Namespace), client.MatchingLabels{"app.kubernetes.io/part-of": "sipp-run"})
+	if err != nil {
+		return err
+	}
+
+	objects, err := apimeta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+
+	for _, object := range objects {
+		accessor, err := apimeta.Accessor(object)
+		if err != nil {
+			return err
+		}
+		if !metav1.IsControlledBy(accessor, scenarioRun) {
+			continue
+		}
+		// The report is part of the results
+		if accessor.GetLabels()["app.kubernetes.io/component"] == resource.ReportComponent {
+			continue
+		}
+
+		err = r.Delete(ctx, object, client.PropagationPolicy(metav1.DeletePropagationBackground))
+		if client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	return nil
+}
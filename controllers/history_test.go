@@ -0,0 +1,215 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	v1alpha1 "github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+)
+
+// newSettledRun returns a reported run which finished with the reason the given time ago
+func newSettledRun(name, reason string, ago time.Duration) *v1alpha1.SippScenarioRun {
+	run := newTestRun(name)
+	run.Status.Report = name + "-report"
+	run.Status.Conditions = []v1alpha1.Condition{{
+		Type:               v1alpha1.ConditionFinished,
+		Status:             corev1.ConditionTrue,
+		Reason:             reason,
+		LastTransitionTime: metav1.NewTime(time.Now().Add(-ago)),
+	}}
+	return run
+}
+
+// newHistoryNamespace returns the default namespace with the annotations
+func newHistoryNamespace(annotations map[string]string) *corev1.Namespace {
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default", Annotations: annotations}}
+}
+
+func TestHistoryLimit(t *testing.T) {
+	namespace := newHistoryNamespace(map[string]string{
+		v1alpha1.SucceededRunsHistoryLimitAnnotation: "3",
+		v1alpha1.FailedRunsHistoryLimitAnnotation:    "-1",
+		"invalid": "three",
+	})
+
+	assert.Equal(t, 3, historyLimit(log.NullLogger{}, namespace, v1alpha1.SucceededRunsHistoryLimitAnnotation))
+	assert.Equal(t, -1, historyLimit(log.NullLogger{}, namespace, v1alpha1.FailedRunsHistoryLimitAnnotation))
+	assert.Equal(t, -1, historyLimit(log.NullLogger{}, namespace, "invalid"))
+	assert.Equal(t, -1, historyLimit(log.NullLogger{}, namespace, "missing"))
+	assert.Equal(t, 0, historyLimit(log.NullLogger{}, newHistoryNamespace(map[string]string{"zero": "0"}), "zero"))
+}
+
+func TestReconcileTTL(t *testing.T) {
+	expired := newSettledRun("expired", "Succeeded", time.Hour)
+	expired.Spec.TTLSecondsAfterFinished = pointer.Int32Ptr(60)
+	pending := newSettledRun("pending", "Succeeded", time.Minute)
+	pending.Spec.TTLSecondsAfterFinished = pointer.Int32Ptr(3600)
+	forever := newSettledRun("forever", "Succeeded", 24*time.Hour)
+	r := newTestRunReconciler(t, expired, pending, forever)
+
+	result, err := r.reconcileTTL(context.Background(), log.NullLogger{}, expired)
+	require.NoError(t, err)
+	assert.Zero(t, result.RequeueAfter)
+	assert.False(t, exists(t, r, "default", "expired", &v1alpha1.SippScenarioRun{}))
+
+	// The run is requeued for when its TTL expires
+	result, err = r.reconcileTTL(context.Background(), log.NullLogger{}, pending)
+	require.NoError(t, err)
+	assert.InDelta(t, float64(59*time.Minute), float64(result.RequeueAfter), float64(time.Second))
+	assert.True(t, exists(t, r, "default", "pending", &v1alpha1.SippScenarioRun{}))
+
+	result, err = r.reconcileTTL(context.Background(), log.NullLogger{}, forever)
+	require.NoError(t, err)
+	assert.Zero(t, result.RequeueAfter)
+	assert.True(t, exists(t, r, "default", "forever", &v1alpha1.SippScenarioRun{}))
+}
+
+func TestPruneHistory(t *testing.T) {
+	running := newTestRun("running")
+	unreported := newSettledRun("unreported", "Succeeded", 10*time.Hour)
+	unreported.Status.Report = ""
+
+	r := newTestRunReconciler(t,
+		newHistoryNamespace(map[string]string{
+			v1alpha1.SucceededRunsHistoryLimitAnnotation: "2",
+			v1alpha1.FailedRunsHistoryLimitAnnotation:    "1",
+		}),
+		newSettledRun("succeeded-1", "Succeeded", 4*time.Hour),
+		newSettledRun("succeeded-2", "Succeeded", 3*time.Hour),
+		newSettledRun("succeeded-3", "Succeeded", 2*time.Hour),
+		newSettledRun("succeeded-4", "Succeeded", time.Hour),
+		newSettledRun("failed-1", "Failed", 3*time.Hour),
+		newSettledRun("failed-2", "Failed", 30*time.Minute),
+		running, unreported,
+	)
+
+	require.NoError(t, r.pruneHistory(context.Background(), log.NullLogger{}, "default"))
+
+	// The most recent runs of each outcome are kept, apart from each other
+	for name, kept := range map[string]bool{
+		"succeeded-1": false,
+		"succeeded-2": false,
+		"succeeded-3": true,
+		"succeeded-4": true,
+		"failed-1":    false,
+		"failed-2":    true,
+		"running":     true,
+		"unreported":  true,
+	} {
+		assert.Equal(t, kept, exists(t, r, "default", name, &v1alpha1.SippScenarioRun{}), name)
+	}
+}
+
+func TestPruneHistoryWithoutLimits(t *testing.T) {
+	r := newTestRunReconciler(t,
+		newHistoryNamespace(map[string]string{v1alpha1.SucceededRunsHistoryLimitAnnotation: "none"}),
+		newSettledRun("succeeded-1", "Succeeded", 2*time.Hour),
+		newSettledRun("succeeded-2", "Succeeded", time.Hour),
+	)
+
+	require.NoError(t, r.pruneHistory(context.Background(), log.NullLogger{}, "default"))
+	assert.True(t, exists(t, r, "default", "succeeded-1", &v1alpha1.SippScenarioRun{}))
+	assert.True(t, exists(t, r, "default", "succeeded-2", &v1alpha1.SippScenarioRun{}))
+
+	// A missing namespace has no limits
+	require.NoError(t, r.pruneHistory(context.Background(), log.NullLogger{}, "missing"))
+}
+
+func TestPruneRun(t *testing.T) {
+	component := func(name string) map[string]string {
+		return map[string]string{"app.kubernetes.io/component": name, "app.kubernetes.io/part-of": "sipp-run"}
+	}
+
+	tests := []struct {
+		Keep      v1alpha1.HistoryKeep
+		RunKept   bool
+		ClaimKept bool
+	}{
+		{Keep: v1alpha1.HistoryKeepNothing},
+		{Keep: ""},
+		{Keep: v1alpha1.HistoryKeepResults, RunKept: true},
+		{Keep: v1alpha1.HistoryKeepArtifacts, RunKept: true, ClaimKept: true},
+	}
+
+	for _, test := range tests {
+		run := newSettledRun("run", "Succeeded", time.Hour)
+		other := newTestRun("other")
+		r := newTestRunReconciler(t, run,
+			&batchv1.Job{ObjectMeta: ownedBy(run, "run-job", component("job"))},
+			&corev1.ConfigMap{ObjectMeta: ownedBy(run, "run-configmap", component("configmap"))},
+			&corev1.ConfigMap{ObjectMeta: ownedBy(run, "run-report", component("report"))},
+			&corev1.Secret{ObjectMeta: ownedBy(run, "run-secret", component("secret"))},
+			&corev1.PersistentVolumeClaim{ObjectMeta: ownedBy(run, "run-artifacts", component("artifacts"))},
+			&corev1.ConfigMap{ObjectMeta: ownedBy(other, "other-configmap", component("configmap"))},
+		)
+
+		stored := &v1alpha1.SippScenarioRun{}
+		require.True(t, exists(t, r, "default", "run", stored))
+		require.NoError(t, r.pruneRun(context.Background(), stored, test.Keep))
+
+		name := string(test.Keep)
+		assert.Equal(t, test.RunKept, exists(t, r, "default", "run", stored), name)
+		if !test.RunKept {
+			// The owned resources are garbage-collected with the run
+			continue
+		}
+
+		assert.True(t, v1alpha1.IsConditionTrue(stored.Status.Conditions, v1alpha1.ConditionPruned), name)
+		for _, object := range []struct {
+			Name   string
+			Object runtime.Object
+			Kept   bool
+		}{
+			{"run-job", &batchv1.Job{}, false},
+			{"run-configmap", &corev1.ConfigMap{}, false},
+			{"run-secret", &corev1.Secret{}, false},
+			{"run-report", &corev1.ConfigMap{}, true},
+			{"other-configmap", &corev1.ConfigMap{}, true},
+		} {
+			assert.Equal(t, object.Kept, exists(t, r, "default", object.Name, object.Object), name+" "+object.Name)
+		}
+		assert.Equal(t, test.ClaimKept, exists(t, r, "default", "run-artifacts", &corev1.PersistentVolumeClaim{}), name)
+	}
+}
+
+func TestPruneRunConflict(t *testing.T) {
+	run := newSettledRun("run", "Succeeded", time.Hour)
+	job := &batchv1.Job{ObjectMeta: ownedBy(run, "run-job", map[string]string{"app.kubernetes.io/part-of": "sipp-run"})}
+	r := newTestRunReconciler(t, run, job, newHistoryNamespace(map[string]string{
+		v1alpha1.RunsHistoryKeepAnnotation: string(v1alpha1.HistoryKeepResults),
+	}))
+
+	// A stale run isn't pruned, and keeps its job
+	stale := &v1alpha1.SippScenarioRun{}
+	require.True(t, exists(t, r, "default", "run", stale))
+	current := stale.DeepCopy()
+	current.Labels = map[string]string{"team": "sbc"}
+	require.NoError(t, r.Update(context.Background(), current))
+	assert.Error(t, r.pruneRun(context.Background(), stale, v1alpha1.HistoryKeepResults))
+	assert.True(t, exists(t, r, "default", "run-job", &batchv1.Job{}))
+
+	// A pruned run whose job is left deletes it instead of starting again
+	stored := &v1alpha1.SippScenarioRun{}
+	require.True(t, exists(t, r, "default", "run", stored))
+	v1alpha1.SetCondition(&stored.Status.Conditions, v1alpha1.Condition{
+		Type:   v1alpha1.ConditionPruned,
+		Status: corev1.ConditionTrue,
+		Reason: "HistoryLimit",
+	})
+	require.NoError(t, r.Status().Update(context.Background(), stored))
+	_, err := r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "run"}})
+	require.NoError(t, err)
+	assert.False(t, exists(t, r, "default", "run-job", &batchv1.Job{}))
+}
@@ -0,0 +1,172 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	"github.com/alexandrevilain/sipp-operator/internal/resource"
+)
+
+// reconcileMetrics runs the job reading the metrics of the finished run from
+// the statistics files of its last attempt, and reports its outcome in the
+// MetricsCollected condition and the status metrics
+func (r *SippScenarioRunReconciler) reconcileMetrics(ctx context.Context, log logr.Logger, scenarioRun *v1alpha1.SippScenarioRun, childJob *batchv1.Job) error {
+	// The metrics are collected once, whatever the outcome
+	if condition := v1alpha1.FindCondition(scenarioRun.Status.Conditions, v1alpha1.ConditionMetricsCollected); condition != nil && condition.Status != corev1.ConditionUnknown {
+		return nil
+	}
+
+	condition := v1alpha1.Condition{
+		Type:   v1alpha1.ConditionMetricsCollected,
+		Status: corev1.ConditionUnknown,
+		Reason: "Collecting",
+	}
+	defer func() {
+		v1alpha1.SetCondition(&scenarioRun.Status.Conditions, condition)
+	}()
+
+	// Each attempt writes its statistics in the directories of its own pods
+	pods := &corev1.PodList{}
+	err := r.List(ctx, pods, client.InNamespace(childJob.Namespace), client.MatchingLabels{"controller-uid": string(childJob.UID)})
+	if err != nil {
+		return err
+	}
+	podNames := make([]string, 0, len(pods.Items))
+	for _, pod := range pods.Items {
+		podNames = append(podNames, pod.Name)
+	}
+	sort.Strings(podNames)
+
+	err = r.apply(ctx, log, &resource.MetricsJobBuilder{
+		Instance: scenarioRun,
+		Scheme:   r.Scheme,
+		Image:    r.UploaderImage,
+		Pods:     podNames,
+	})
+	if err != nil {
+		return err
+	}
+
+	metricsJob := &batchv1.Job{}
+	err = r.Get(ctx, types.NamespacedName{Namespace: scenarioRun.Namespace, Name: scenarioRun.ChildResourceName("metrics")}, metricsJob)
+	if err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if !isJobFinished(metricsJob) {
+		return nil
+	}
+
+	message, err := r.terminationMessage(ctx, metricsJob)
+	if err != nil {
+		return err
+	}
+
+	if metricsJob.Status.Succeeded == 0 {
+		condition.Status = corev1.ConditionFalse
+		condition.Reason = "CollectionFailed"
+		condition.Message = message
+		return nil
+	}
+
+	metrics := &v1alpha1.RunMetrics{}
+	if err := json.Unmarshal([]byte(message), metrics); err != nil {
+		condition.Status = corev1.ConditionFalse
+		condition.Reason = "CollectionFailed"
+		condition.Message = fmt.Sprintf("invalid metrics: %v", err)
+		return nil
+	}
+
+	condition.Status = corev1.ConditionTrue
+	condition.Reason = "Collected"
+	scenarioRun.Status.Metrics = metrics
+	return nil
+}
+
+// reconcileBaseline compares the metrics of the run with its baseline,
+// and reports the regressions in the Regressed condition
+func (r *SippScenarioRunReconciler) reconcileBaseline(ctx context.Context, scenarioRun *v1alpha1.SippScenarioRun) error {
+	baseline := scenarioRun.Spec.Baseline
+	collected := v1alpha1.FindCondition(scenarioRun.Status.Conditions, v1alpha1.ConditionMetricsCollected)
+	if collected == nil || collected.Status == corev1.ConditionUnknown {
+		return nil
+	}
+
+	condition := v1alpha1.Condition{
+		Type:   v1alpha1.ConditionRegressed,
+		Status: corev1.ConditionUnknown,
+	}
+	defer func() {
+		v1alpha1.SetCondition(&scenarioRun.Status.Conditions, condition)
+	}()
+
+	if scenarioRun.Status.Metrics == nil {
+		condition.Reason = "MetricsUnavailable"
+		condition.Message = collected.Message
+		return nil
+	}
+
+	baselineMetrics := baseline.Metrics
+	if baseline.RunName != "" {
+		baselineRun := &v1alpha1.SippScenarioRun{}
+		err := r.Get(ctx, types.NamespacedName{Namespace: scenarioRun.Namespace, Name: baseline.RunName}, baselineRun)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		baselineMetrics = baselineRun.Status.Metrics
+	}
+	if baselineMetrics == nil {
+		condition.Reason = "BaselineUnavailable"
+		condition.Message = fmt.Sprintf("run %s doesn't exist or has no metrics", baseline.RunName)
+		return nil
+	}
+
+	deltas := v1alpha1.CompareMetrics(baselineMetrics, scenarioRun.Status.Metrics, baseline.Tolerances)
+	scenarioRun.Status.BaselineDeltas = deltas
+
+	regressed := []string{}
+	compared := []string{}
+	for _, delta := range deltas {
+		compared = append(compared, delta.String())
+		if delta.Regressed {
+			regressed = append(regressed, delta.String())
+		}
+	}
+
+	if len(regressed) > 0 {
+		condition.Status = corev1.ConditionTrue
+		condition.Reason = "Regressed"
+		condition.Message = strings.Join(regressed, "; ")
+	} else {
+		condition.Status = corev1.ConditionFalse
+		condition.Reason = "WithinTolerances"
+		condition.Message = strings.Join(compared, "; ")
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"sort"
+
+	"github.com/go-logr/logr"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	"github.com/alexandrevilain/sipp-operator/internal/report"
+	"github.com/alexandrevilain/sipp-operator/internal/resource"
+)
+
+// reconcileReport stores the JUnit report of the finished run in a ConfigMap,
+// once its metrics are compared with the baseline when needed
+func (r *SippScenarioRunReconciler) reconcileReport(ctx context.Context, log logr.Logger, scenarioRun *v1alpha1.SippScenarioRun, childJob *batchv1.Job) error {
+	// The report is generated once, the pods it describes may be gone afterwards
+	if scenarioRun.Status.Report != "" {
+		return nil
+	}
+	if scenarioRun.CollectsMetrics() {
+		collected := v1alpha1.FindCondition(scenarioRun.Status.Conditions, v1alpha1.ConditionMetricsCollected)
+		if collected == nil || collected.Status == corev1.ConditionUnknown {
+			return nil
+		}
+	}
+
+	// The instances of the last attempt are reported
+	instances, err := r.listInstances(ctx, childJob)
+	if err != nil {
+		return err
+	}
+
+	junit, err := report.JUnit(scenarioRun, instances)
+	if err != nil {
+		return err
+	}
+
+	err = r.apply(ctx, log, &resource.ReportBuilder{
+		Instance: scenarioRun,
+		Scheme:   r.Scheme,
+		JUnit:    junit,
+	})
+	if err != nil {
+		return err
+	}
+
+	scenarioRun.Status.Report = resource.ReportName(scenarioRun)
+	return nil
+}
+
+// listInstances returns the sipp instances of the job sorted by pod name.
+// The pods of previous attempts are labelled with the same job name,
+// but not with the same job uid.
+func (r *SippScenarioRunReconciler) listInstances(ctx context.Context, childJob *batchv1.Job) ([]report.Instance, error) {
+	pods := &corev1.PodList{}
+	err := r.List(ctx, pods, client.InNamespace(childJob.Namespace), client.MatchingLabels{"controller-uid": string(childJob.UID)})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(pods.Items, func(i, j int) bool {
+		return pods.Items[i].Name < pods.Items[j].Name
+	})
+
+	instances := make([]report.Instance, 0, len(pods.Items))
+	for i := range pods.Items {
+		instances = append(instances, report.NewInstance(&pods.Items[i]))
+	}
+	return instances, nil
+}
@@ -0,0 +1,32 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestReconcileReport(t *testing.T) {
+	r := newTestRunReconciler(t)
+	run := newTestRun("run")
+
+	require.NoError(t, r.reconcileReport(context.Background(), log.NullLogger{}, run, &batchv1.Job{}))
+	assert.Equal(t, "run-report", run.Status.Report)
+	assert.True(t, exists(t, r, "default", "run-report", &corev1.ConfigMap{}))
+}
+
+func TestReconcileReportRejected(t *testing.T) {
+	r := newTestRunReconciler(t)
+	r.Client = rejectingClient{r.Client}
+	run := newTestRun("run")
+
+	// The report isn't recorded until its ConfigMap is stored
+	assert.Error(t, r.reconcileReport(context.Background(), log.NullLogger{}, run, &batchv1.Job{}))
+	assert.Empty(t, run.Status.Report)
+	assert.False(t, exists(t, r, "default", "run-report", &corev1.ConfigMap{}))
+}
@@ -0,0 +1,123 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	"github.com/alexandrevilain/sipp-operator/internal/report"
+	"github.com/alexandrevilain/sipp-operator/internal/resource"
+)
+
+// reconcileAttempt records the attempt executed by the finished job, and starts
+// the run again by deleting the job when the retry policy allows it.
+// The job is only deleted once the attempt is recorded in the run status,
+// so an attempt is never started twice.
+// It returns whether the run is retried, and when to reconcile it again.
+func (r *SippScenarioRunReconciler) reconcileAttempt(ctx context.Context, log logr.Logger, scenarioRun *v1alpha1.SippScenarioRun, childJob *batchv1.Job) (bool, time.Duration, error) {
+	// The job of the next attempt is created once this one is gone
+	if childJob.DeletionTimestamp != nil {
+		return true, 0, nil
+	}
+
+	number := resource.JobAttempt(childJob)
+	var attempt *v1alpha1.RunAttempt
+	for i := range scenarioRun.Status.Attempts {
+		if scenarioRun.Status.Attempts[i].Attempt == number {
+			attempt = &scenarioRun.Status.Attempts[i]
+		}
+	}
+
+	recorded := attempt != nil
+	if !recorded {
+		instances, err := r.listInstances(ctx, childJob)
+		if err != nil {
+			return false, 0, err
+		}
+		newAttempt := newAttempt(childJob, number, instances)
+		scenarioRun.Status.Attempts = append(scenarioRun.Status.Attempts, newAttempt)
+		attempt = &newAttempt
+	}
+
+	// A stopped run is never started again
+	if attempt.Succeeded || v1alpha1.IsConditionTrue(scenarioRun.Status.Conditions, v1alpha1.ConditionStopped) {
+		return false, 0, nil
+	}
+	if !scenarioRun.CanRetry(number, attempt.ExitCode) {
+		return false, 0, nil
+	}
+
+	retryAt := time.Now()
+	if attempt.CompletionTime != nil {
+		retryAt = attempt.CompletionTime.Add(scenarioRun.RetryBackoff(number))
+	}
+	if wait := time.Until(retryAt); wait > 0 || !recorded {
+		if wait < controlRequeueDelay {
+			wait = controlRequeueDelay
+		}
+		return true, wait, nil
+	}
+
+	log.Info("retrying failed SippScenarioRun", "attempt", number+1)
+	err := r.Delete(ctx, childJob, client.PropagationPolicy(metav1.DeletePropagationForeground))
+	return true, 0, client.IgnoreNotFound(err)
+}
+
+// newAttempt returns the attempt executed by the finished job and its sipp instances.
+// The exit code is the one of the first failed instance.
+func newAttempt(childJob *batchv1.Job, number int32, instances []report.Instance) v1alpha1.RunAttempt {
+	attempt := v1alpha1.RunAttempt{
+		Attempt:        number,
+		StartTime:      childJob.Status.StartTime,
+		CompletionTime: childJob.Status.CompletionTime,
+		Succeeded:      true,
+	}
+
+	for _, condition := range childJob.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
+			attempt.Succeeded = false
+			attempt.Reason = condition.Reason
+			// Failed jobs have no completion time
+			completionTime := condition.LastTransitionTime
+			attempt.CompletionTime = &completionTime
+		}
+	}
+
+	for _, instance := range instances {
+		if !instance.Failed() {
+			continue
+		}
+		if attempt.Succeeded {
+			attempt.Succeeded = false
+			attempt.Reason = "InstanceFailed"
+		}
+		if attempt.ExitCode == nil && instance.ExitCode != nil {
+			exitCode := *instance.ExitCode
+			attempt.ExitCode = &exitCode
+		}
+	}
+
+	return attempt
+}
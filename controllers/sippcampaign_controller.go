@@ -0,0 +1,253 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	"github.com/alexandrevilain/sipp-operator/internal/resource"
+)
+
+// SippCampaignReconciler reconciles a SippCampaign object
+type SippCampaignReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=sipp.alexandrevilain.dev,resources=sippcampaigns,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=sipp.alexandrevilain.dev,resources=sippcampaigns/status,verbs=get;update;patch
+
+func (r *SippCampaignReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("sippcampaign", req.NamespacedName)
+
+	campaign := &v1alpha1.SippCampaign{}
+	err := r.Get(ctx, req.NamespacedName, campaign)
+	if err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if isCampaignOver(campaign.Status.Phase) {
+		return ctrl.Result{}, nil
+	}
+
+	// Invalid campaigns are never started
+	if err := campaign.Validate(); err != nil {
+		log.Info("invalid SippCampaign", "reason", err.Error())
+		v1alpha1.SetCondition(&campaign.Status.Conditions, v1alpha1.Condition{
+			Type:    v1alpha1.ConditionValid,
+			Status:  corev1.ConditionFalse,
+			Reason:  "ValidationFailed",
+			Message: err.Error(),
+		})
+		campaign.Status.Phase = v1alpha1.CampaignPhaseFailed
+		return ctrl.Result{}, r.Status().Update(ctx, campaign)
+	}
+
+	v1alpha1.SetCondition(&campaign.Status.Conditions, v1alpha1.Condition{
+		Type:   v1alpha1.ConditionValid,
+		Status: corev1.ConditionTrue,
+		Reason: "Validated",
+	})
+
+	entries := campaign.Entries()
+	initCampaignResults(campaign, entries)
+
+	// The runs in progress are checked first, to free their slots once finished
+	running := int32(0)
+	requeueAfter := time.Duration(0)
+	for i := range campaign.Status.Results {
+		result := &campaign.Status.Results[i]
+		if result.Phase != v1alpha1.CampaignPhaseRunning {
+			continue
+		}
+
+		// The runs are created once, a run missing afterwards fails its row
+		run, err := r.getRun(ctx, campaign, result.Run)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		switch {
+		case run != nil:
+			observeCampaignRun(result, run)
+		case !cacheSynced(result.StartTime):
+			requeueAfter = controlRequeueDelay
+		default:
+			now := metav1.Now()
+			result.Phase = v1alpha1.CampaignPhaseFailed
+			result.Message = fmt.Sprintf("the run %s was deleted", result.Run)
+			result.CompletionTime = &now
+		}
+		if result.Phase == v1alpha1.CampaignPhaseRunning {
+			running++
+		}
+	}
+
+	for i := range campaign.Status.Results {
+		result := &campaign.Status.Results[i]
+		if result.Phase != v1alpha1.CampaignPhasePending || running >= campaign.GetConcurrency() {
+			continue
+		}
+
+		log.Info("starting SippCampaign run", "run", result.Run)
+		if err := r.startRun(ctx, campaign, i, entries[i]); err != nil {
+			return ctrl.Result{}, err
+		}
+		now := metav1.Now()
+		result.Phase = v1alpha1.CampaignPhaseRunning
+		result.StartTime = &now
+		running++
+	}
+
+	updateCampaignCounts(campaign)
+	if isCampaignOver(campaign.Status.Phase) {
+		log.Info("SippCampaign is over", "phase", campaign.Status.Phase)
+	}
+
+	err = r.Status().Update(ctx, campaign)
+	if err != nil {
+		log.Error(err, "unable to update SippCampaign status")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+func isCampaignOver(phase v1alpha1.CampaignPhase) bool {
+	return phase == v1alpha1.CampaignPhaseSucceeded || phase == v1alpha1.CampaignPhaseFailed
+}
+
+// initCampaignResults adds the rows of the runs not started yet
+func initCampaignResults(campaign *v1alpha1.SippCampaign, entries []v1alpha1.CampaignEntry) {
+	existing := map[string]v1alpha1.CampaignResult{}
+	for _, result := range campaign.Status.Results {
+		existing[result.Run] = result
+	}
+
+	results := make([]v1alpha1.CampaignResult, 0, len(entries))
+	for i, entry := range entries {
+		result, ok := existing[campaign.RunName(i)]
+		if !ok {
+			result = campaign.Result(i, entry)
+		}
+		results = append(results, result)
+	}
+	campaign.Status.Results = results
+}
+
+// updateCampaignCounts sets the totals and the phase of the campaign from its results
+func updateCampaignCounts(campaign *v1alpha1.SippCampaign) {
+	status := &campaign.Status
+	status.Total = int32(len(status.Results))
+	status.Running = 0
+	status.Succeeded = 0
+	status.Failed = 0
+
+	pending := 0
+	for _, result := range status.Results {
+		switch result.Phase {
+		case v1alpha1.CampaignPhasePending:
+			pending++
+		case v1alpha1.CampaignPhaseRunning:
+			status.Running++
+		case v1alpha1.CampaignPhaseSucceeded:
+			status.Succeeded++
+		case v1alpha1.CampaignPhaseFailed:
+			status.Failed++
+		}
+	}
+
+	switch {
+	case pending > 0 || status.Running > 0:
+		status.Phase = v1alpha1.CampaignPhaseRunning
+	case status.Failed > 0:
+		status.Phase = v1alpha1.CampaignPhaseFailed
+	default:
+		status.Phase = v1alpha1.CampaignPhaseSucceeded
+	}
+}
+
+// observeCampaignRun copies the results of the run to its row
+func observeCampaignRun(result *v1alpha1.CampaignResult, run *v1alpha1.SippScenarioRun) {
+	result.Succeeded = run.Status.Succeeded
+	result.Failed = run.Status.Failed
+	result.Attempts = int32(len(run.Status.Attempts))
+	result.Metrics = run.Status.Metrics.DeepCopy()
+	if attempts := run.Status.Attempts; len(attempts) > 0 {
+		result.ExitCode = attempts[len(attempts)-1].ExitCode
+	}
+
+	if valid := v1alpha1.FindCondition(run.Status.Conditions, v1alpha1.ConditionValid); valid != nil && valid.Status == corev1.ConditionFalse {
+		now := metav1.Now()
+		result.Phase = v1alpha1.CampaignPhaseFailed
+		result.Message = fmt.Sprintf("invalid run: %s", valid.Message)
+		result.CompletionTime = &now
+		return
+	}
+
+	finished := v1alpha1.FindCondition(run.Status.Conditions, v1alpha1.ConditionFinished)
+	if finished == nil || finished.Status != corev1.ConditionTrue {
+		return
+	}
+
+	completionTime := finished.LastTransitionTime
+	result.CompletionTime = &completionTime
+	result.Phase = v1alpha1.CampaignPhaseSucceeded
+	if finished.Reason == "Failed" {
+		result.Phase = v1alpha1.CampaignPhaseFailed
+		result.Message = finished.Message
+	}
+}
+
+// startRun creates the run of the entry
+func (r *SippCampaignReconciler) startRun(ctx context.Context, campaign *v1alpha1.SippCampaign, index int, entry v1alpha1.CampaignEntry) error {
+	builder := &resource.CampaignRunBuilder{Campaign: campaign, Scheme: r.Scheme, Index: index, Entry: entry}
+	return createResource(ctx, r, builder)
+}
+
+// getRun returns the named run, or nil when it isn't created yet
+func (r *SippCampaignReconciler) getRun(ctx context.Context, campaign *v1alpha1.SippCampaign, name string) (*v1alpha1.SippScenarioRun, error) {
+	run := &v1alpha1.SippScenarioRun{}
+	err := r.Get(ctx, types.NamespacedName{Namespace: campaign.Namespace, Name: name}, run)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+func (r *SippCampaignReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.SippCampaign{}).
+		Owns(&v1alpha1.SippScenarioRun{}).
+		Complete(r)
+}
@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	v1alpha1 "github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+)
+
+// reconcileCampaign reconciles the campaign and returns it as stored afterwards
+func reconcileCampaign(t *testing.T, r *SippCampaignReconciler) (ctrl.Result, *v1alpha1.SippCampaign) {
+	result, err := r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "campaign"}})
+	require.NoError(t, err)
+
+	campaign := &v1alpha1.SippCampaign{}
+	require.True(t, exists(t, r, "default", "campaign", campaign))
+	return result, campaign
+}
+
+func TestReconcileCampaign(t *testing.T) {
+	campaign := &v1alpha1.SippCampaign{
+		ObjectMeta: metav1.ObjectMeta{Name: "campaign", Namespace: "default", UID: "campaign-uid"},
+		Spec: v1alpha1.SippCampaignSpec{
+			Template: v1alpha1.SippScenarioRunSpec{ScenarioRef: &v1alpha1.ScenarioReference{Name: "uac"}},
+			Matrix:   v1alpha1.CampaignMatrix{Destinations: []string{"sbc-a:5060", "sbc-b:5060"}},
+		},
+	}
+	scheme := newTestScheme(t)
+	r := &SippCampaignReconciler{
+		Client: fake.NewFakeClientWithScheme(scheme, campaign),
+		Log:    log.NullLogger{},
+		Scheme: scheme,
+	}
+
+	_, stored := reconcileCampaign(t, r)
+	require.Len(t, stored.Status.Results, 2)
+	assert.Equal(t, v1alpha1.CampaignPhaseRunning, stored.Status.Results[0].Phase)
+	assert.Equal(t, v1alpha1.CampaignPhasePending, stored.Status.Results[1].Phase)
+
+	// The run isn't applied again once created, and its results are copied to its row
+	run := &v1alpha1.SippScenarioRun{}
+	require.True(t, exists(t, r, "default", campaign.RunName(0), run))
+	run.Spec.Stop = true
+	require.NoError(t, r.Update(context.Background(), run))
+	run.Status.Metrics = &v1alpha1.RunMetrics{SuccessfulCalls: 99, FailedCalls: 1, CallRate: "10.5"}
+	run.Status.Conditions = []v1alpha1.Condition{{Type: v1alpha1.ConditionFinished, Status: corev1.ConditionTrue, Reason: "Succeeded"}}
+	require.NoError(t, r.Status().Update(context.Background(), run))
+
+	_, stored = reconcileCampaign(t, r)
+	assert.Equal(t, v1alpha1.CampaignPhaseSucceeded, stored.Status.Results[0].Phase)
+	assert.Equal(t, run.Status.Metrics, stored.Status.Results[0].Metrics)
+	assert.Equal(t, v1alpha1.CampaignPhaseRunning, stored.Status.Results[1].Phase)
+	run = &v1alpha1.SippScenarioRun{}
+	require.True(t, exists(t, r, "default", campaign.RunName(0), run))
+	assert.True(t, run.Spec.Stop)
+
+	// A run deleted afterwards fails its row instead of being created again,
+	// once it can no longer be missing from the cache only
+	other := &v1alpha1.SippScenarioRun{}
+	require.True(t, exists(t, r, "default", campaign.RunName(1), other))
+	require.NoError(t, r.Delete(context.Background(), other))
+	result, stored := reconcileCampaign(t, r)
+	assert.Equal(t, controlRequeueDelay, result.RequeueAfter)
+	assert.Equal(t, v1alpha1.CampaignPhaseRunning, stored.Status.Results[1].Phase)
+
+	startTime := metav1.NewTime(time.Now().Add(-time.Minute))
+	stored.Status.Results[1].StartTime = &startTime
+	require.NoError(t, r.Status().Update(context.Background(), stored))
+	_, stored = reconcileCampaign(t, r)
+	assert.Equal(t, v1alpha1.CampaignPhaseFailed, stored.Status.Results[1].Phase)
+	assert.Equal(t, v1alpha1.CampaignPhaseFailed, stored.Status.Phase)
+	assert.False(t, exists(t, r, "default", campaign.RunName(1), &v1alpha1.SippScenarioRun{}))
+}
@@ -0,0 +1,242 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	v1alpha1 "github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	"github.com/alexandrevilain/sipp-operator/internal/content"
+	"github.com/alexandrevilain/sipp-operator/internal/scenario"
+)
+
+const (
+	// maxReportedErrors is the maximum number of scenario errors reported in the Valid condition
+	maxReportedErrors = 10
+
+	// scenarioConfigMapsField indexes the scenarios by the ConfigMaps their content is loaded from
+	scenarioConfigMapsField = "spec.contentConfigMaps"
+	// scenarioSecretsField indexes the scenarios by the Secrets their content is loaded from
+	scenarioSecretsField = "spec.contentSecrets"
+)
+
+// scenarioIndexes are the fields the scenarios are indexed by,
+// so that they are checked again when the content they load changes
+var scenarioIndexes = map[string]client.IndexerFunc{
+	scenarioConfigMapsField: func(object runtime.Object) []string {
+		names := []string{}
+		for _, source := range contentSources(object.(*v1alpha1.SippScenario)) {
+			if source.ConfigMapKeyRef != nil {
+				names = append(names, source.ConfigMapKeyRef.Name)
+			}
+		}
+		return names
+	},
+	scenarioSecretsField: func(object runtime.Object) []string {
+		names := []string{}
+		for _, source := range contentSources(object.(*v1alpha1.SippScenario)) {
+			if source.SecretKeyRef != nil {
+				names = append(names, source.SecretKeyRef.Name)
+			}
+		}
+		return names
+	},
+}
+
+// SippScenarioReconciler reconciles a SippScenario object
+type SippScenarioReconciler struct {
+	client.Client
+	Log             logr.Logger
+	Scheme          *runtime.Scheme
+	ContentResolver *content.Resolver
+}
+
+// +kubebuilder:rbac:groups=sipp.alexandrevilain.dev,resources=sippscenarios,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=sipp.alexandrevilain.dev,resources=sippscenarios/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+
+func (r *SippScenarioReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("sippscenario", req.NamespacedName)
+
+	sippScenario := &v1alpha1.SippScenario{}
+	err := r.Get(ctx, req.NamespacedName, sippScenario)
+	if err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	condition, summary, resolveErr := checkScenario(ctx, r.ContentResolver, sippScenario, sippScenario.Validate())
+
+	log.Info("validated SippScenario", "status", condition.Status, "reason", condition.Reason)
+
+	v1alpha1.SetCondition(&sippScenario.Status.Conditions, condition)
+	sippScenario.Status.ObservedGeneration = sippScenario.Generation
+	if resolveErr == nil {
+		sippScenario.Status.Summary = summary
+	}
+
+	err = r.Status().Update(ctx, sippScenario)
+	if err != nil {
+		log.Error(err, "unable to update SippScenario status")
+		return ctrl.Result{}, err
+	}
+
+	// Content served by other resources or URLs may become available later
+	return ctrl.Result{}, resolveErr
+}
+
+// checkScenario validates the scenario given the error of its spec validation,
+// and summarises its resolved content. The returned error is only set when
+// its content can't be loaded, as it may become available later.
+func checkScenario(ctx context.Context, resolver *content.Resolver, sippScenario *v1alpha1.SippScenario, validationErr error) (v1alpha1.Condition, *v1alpha1.ScenarioSummary, error) {
+	condition := v1alpha1.Condition{
+		Type:   v1alpha1.ConditionValid,
+		Status: corev1.ConditionTrue,
+		Reason: "Validated",
+	}
+
+	var resolveErr error
+	var summary *v1alpha1.ScenarioSummary
+	if validationErr != nil {
+		condition.Status = corev1.ConditionFalse
+		condition.Reason = "InvalidSpec"
+		condition.Message = validationErr.Error()
+	} else if resolved, err := resolver.Resolve(ctx, sippScenario); err != nil {
+		resolveErr = err
+		condition.Status = corev1.ConditionUnknown
+		condition.Reason = "ContentUnavailable"
+		condition.Message = err.Error()
+	} else if err := resolved.Validate(); err != nil {
+		// The loaded content, such as the injected rows, is checked like inline content
+		condition.Status = corev1.ConditionFalse
+		condition.Reason = "InvalidContent"
+		condition.Message = err.Error()
+	} else if resolved.Spec.ScenarioFileContent != "" {
+		// Parameterised scenarios are checked as rendered with the default values
+		var renderErr error
+		if len(resolved.Spec.Parameters) > 0 {
+			resolved.Spec.ScenarioFileContent, renderErr = scenario.Render(resolved.Spec.ScenarioFileContent, resolved.DefaultParameterValues(), resolved.RawParameters())
+		}
+
+		if renderErr != nil {
+			condition.Status = corev1.ConditionFalse
+			condition.Reason = "InvalidTemplate"
+			condition.Message = renderErr.Error()
+		} else {
+			if err := scenario.Validate(resolved.Spec.ScenarioFileContent); err != nil {
+				condition.Status = corev1.ConditionFalse
+				condition.Reason = "InvalidScenario"
+				condition.Message = errorsMessage(err)
+			}
+
+			summary = summarize(resolved)
+		}
+	}
+
+	return condition, summary, resolveErr
+}
+
+// summarize returns the summary of a resolved scenario,
+// or nil if its scenario file can't be parsed
+func summarize(resolved *v1alpha1.SippScenario) *v1alpha1.ScenarioSummary {
+	root, err := scenario.Parse(resolved.Spec.ScenarioFileContent)
+	if err != nil {
+		return nil
+	}
+
+	summary := scenario.Summarize(root)
+	for i := range resolved.Spec.InjectValues {
+		summary.InjectValuesColumns = append(summary.InjectValuesColumns, int32(resolved.Spec.InjectValues[i].ColumnCount()))
+	}
+
+	return summary
+}
+
+// errorsMessage returns the message of the scenario errors,
+// truncated to the first maxReportedErrors ones
+func errorsMessage(err error) string {
+	errs, ok := err.(scenario.ErrorList)
+	if !ok || len(errs) <= maxReportedErrors {
+		return err.Error()
+	}
+
+	return errs[:maxReportedErrors].Error() + "; and more errors"
+}
+
+// contentSources returns the sources the scenario file and injection files are loaded from
+func contentSources(sippScenario *v1alpha1.SippScenario) []*v1alpha1.ContentSource {
+	sources := []*v1alpha1.ContentSource{}
+	if sippScenario.Spec.ScenarioFrom != nil {
+		sources = append(sources, sippScenario.Spec.ScenarioFrom)
+	}
+	for i := range sippScenario.Spec.InjectValues {
+		if sippScenario.Spec.InjectValues[i].From != nil {
+			sources = append(sources, sippScenario.Spec.InjectValues[i].From)
+		}
+	}
+	return sources
+}
+
+// contentRequests returns the scenarios of the object namespace loading content from it,
+// which are reconciled again when it changes
+func (r *SippScenarioReconciler) contentRequests(field string) handler.ToRequestsFunc {
+	return func(object handler.MapObject) []reconcile.Request {
+		scenarios := &v1alpha1.SippScenarioList{}
+		err := r.List(context.Background(), scenarios, client.InNamespace(object.Meta.GetNamespace()), client.MatchingFields{field: object.Meta.GetName()})
+		if err != nil {
+			r.Log.Error(err, "unable to list SippScenarios loading content", "namespace", object.Meta.GetNamespace(), "name", object.Meta.GetName())
+			return nil
+		}
+
+		requests := []reconcile.Request{}
+		for i := range scenarios.Items {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: scenarios.Items[i].Namespace, Name: scenarios.Items[i].Name},
+			})
+		}
+		return requests
+	}
+}
+
+func (r *SippScenarioReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	for field, extractValue := range scenarioIndexes {
+		if err := mgr.GetFieldIndexer().IndexField(context.Background(), &v1alpha1.SippScenario{}, field, extractValue); err != nil {
+			return err
+		}
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.SippScenario{}).
+		Watches(&source.Kind{Type: &corev1.ConfigMap{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: r.contentRequests(scenarioConfigMapsField),
+		}).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: r.contentRequests(scenarioSecretsField),
+		}).
+		Complete(r)
+}
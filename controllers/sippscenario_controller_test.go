@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1alpha1 "github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	"github.com/alexandrevilain/sipp-operator/internal/content"
+)
+
+// newTestScenarioReconciler returns a scenario reconciler backed by a fake client
+// holding the objects, whose lists use the scenario indexes
+func newTestScenarioReconciler(t *testing.T, objects ...runtime.Object) *SippScenarioReconciler {
+	scheme := newTestScheme(t)
+	c := indexedClient{Client: fake.NewFakeClientWithScheme(scheme, objects...), indexes: scenarioIndexes}
+	return &SippScenarioReconciler{
+		Client:          c,
+		Log:             log.NullLogger{},
+		Scheme:          scheme,
+		ContentResolver: content.NewResolver(c),
+	}
+}
+
+// configMapSource returns a source loading the key of the ConfigMap
+func configMapSource(name, key string) *v1alpha1.ContentSource {
+	return &v1alpha1.ContentSource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: name},
+		Key:                  key,
+	}}
+}
+
+func TestScenarioContentRequests(t *testing.T) {
+	uac := &v1alpha1.SippScenario{
+		ObjectMeta: metav1.ObjectMeta{Name: "uac", Namespace: "default"},
+		Spec:       v1alpha1.SippScenarioSpec{ScenarioFrom: configMapSource("scenarios", "uac.xml")},
+	}
+	uas := &v1alpha1.SippScenario{
+		ObjectMeta: metav1.ObjectMeta{Name: "uas", Namespace: "default"},
+		Spec: v1alpha1.SippScenarioSpec{
+			ScenarioFileContent: "<scenario/>",
+			InjectValues: []v1alpha1.InjectionFile{
+				{Rows: [][]string{{"alice"}}},
+				{From: &v1alpha1.ContentSource{SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "users"},
+					Key:                  "users.csv",
+				}}},
+			},
+		},
+	}
+	other := &v1alpha1.SippScenario{
+		ObjectMeta: metav1.ObjectMeta{Name: "uac", Namespace: "other"},
+		Spec:       v1alpha1.SippScenarioSpec{ScenarioFrom: configMapSource("scenarios", "uac.xml")},
+	}
+	r := newTestScenarioReconciler(t, uac, uas, other)
+
+	requests := func(field, name string) []reconcile.Request {
+		object := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}}
+		return r.contentRequests(field)(handler.MapObject{Meta: object, Object: object})
+	}
+	request := func(name string) reconcile.Request {
+		return reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: name}}
+	}
+
+	assert.Equal(t, []reconcile.Request{request("uac")}, requests(scenarioConfigMapsField, "scenarios"))
+	assert.Equal(t, []reconcile.Request{request("uas")}, requests(scenarioSecretsField, "users"))
+	assert.Empty(t, requests(scenarioConfigMapsField, "users"))
+	assert.Empty(t, requests(scenarioSecretsField, "scenarios"))
+}
+
+func TestReconcileScenarioContentUpdate(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "scenarios", Namespace: "default"},
+		Data:       map[string]string{"uac.xml": `<scenario name="options"><send><![CDATA[OPTIONS sip:[remote_ip] SIP/2.0]]></send></scenario>`},
+	}
+	uac := &v1alpha1.SippScenario{
+		ObjectMeta: metav1.ObjectMeta{Name: "uac", Namespace: "default"},
+		Spec:       v1alpha1.SippScenarioSpec{ScenarioFrom: configMapSource("scenarios", "uac.xml")},
+	}
+	r := newTestScenarioReconciler(t, configMap, uac)
+
+	_, err := r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "uac"}})
+	require.NoError(t, err)
+	stored := &v1alpha1.SippScenario{}
+	require.True(t, exists(t, r, "default", "uac", stored))
+	require.NotNil(t, stored.Status.Summary)
+	assert.Equal(t, "options", stored.Status.Summary.Name)
+	assert.Equal(t, int32(1), stored.Status.Summary.MessageCount)
+
+	// The scenario is reconciled again with the updated content of the ConfigMap
+	configMap.Data["uac.xml"] = `<scenario name="register">
+  <send><![CDATA[REGISTER sip:[remote_ip] SIP/2.0]]></send>
+  <recv response="200"/>
+</scenario>`
+	require.NoError(t, r.Update(context.Background(), configMap))
+	requests := r.contentRequests(scenarioConfigMapsField)(handler.MapObject{Meta: configMap, Object: configMap})
+	require.Len(t, requests, 1)
+
+	_, err = r.Reconcile(requests[0])
+	require.NoError(t, err)
+	stored = &v1alpha1.SippScenario{}
+	require.True(t, exists(t, r, "default", "uac", stored))
+	assert.Equal(t, "register", stored.Status.Summary.Name)
+	assert.Equal(t, int32(2), stored.Status.Summary.MessageCount)
+}
@@ -18,36 +18,67 @@ package controllers
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	clientretry "k8s.io/client-go/util/retry"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	v1alpha1 "github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	"github.com/alexandrevilain/sipp-operator/internal/content"
+	"github.com/alexandrevilain/sipp-operator/internal/control"
+	"github.com/alexandrevilain/sipp-operator/internal/report"
 	"github.com/alexandrevilain/sipp-operator/internal/resource"
+	"github.com/alexandrevilain/sipp-operator/internal/scenario"
+	"github.com/alexandrevilain/sipp-operator/internal/upload"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
 // SippScenarioRunReconciler reconciles a SippScenarioRun object
 type SippScenarioRunReconciler struct {
 	client.Client
-	Log    logr.Logger
-	Scheme *runtime.Scheme
+	Log             logr.Logger
+	Scheme          *runtime.Scheme
+	ContentResolver *content.Resolver
+	// UploadSettings are the operator level settings of the object storage
+	// the run artifacts are uploaded to, each run can override them
+	UploadSettings upload.Settings
+	// UploaderImage is the operator image, which provides the uploader and the collector
+	UploaderImage string
+	// RemoteControl sends commands to the running sipp instances
+	RemoteControl control.Sender
 }
 
+// controlRequeueDelay is the delay before controlling the sipp instances not running yet
+const controlRequeueDelay = 5 * time.Second
+
 // +kubebuilder:rbac:groups=sipp.alexandrevilain.dev,resources=sippscenarioruns,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=sipp.alexandrevilain.dev,resources=sippscenarioruns/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=sipp.alexandrevilain.dev,resources=sippscenario,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=sipp.alexandrevilain.dev,resources=sippscenario/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=sipp.alexandrevilain.dev,resources=clustersippscenarios,verbs=get;list;watch
+// +kubebuilder:rbac:groups=sipp.alexandrevilain.dev,resources=sippscenariogrants,verbs=get;list;watch
 // +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=batch,resources=jobs/status,verbs=get
 // +kubebuilder:rbac:groups=core,resources=jobs,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=jobs/status,verbs=get
+// +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
 
 func (r *SippScenarioRunReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	ctx := context.Background()
@@ -58,23 +89,118 @@ func (r *SippScenarioRunReconciler) Reconcile(req ctrl.Request) (ctrl.Result, er
 	scenarioRun := &v1alpha1.SippScenarioRun{}
 	err := r.Get(ctx, req.NamespacedName, scenarioRun)
 	if err != nil {
+		if apierrors.IsNotFound(err) {
+			// Deleted after its TTL or by the history limit
+			return ctrl.Result{}, nil
+		}
 		log.Error(err, "unable to fetch SippScenarioRun")
 		return ctrl.Result{}, err
 	}
 
+	// Only the results of a pruned run are kept, it is never started again
+	if v1alpha1.IsConditionTrue(scenarioRun.Status.Conditions, v1alpha1.ConditionPruned) {
+		return ctrl.Result{}, r.finishPruning(ctx, scenarioRun)
+	}
+
+	// Scenarios of other namespaces are denied unless granted
+	granted, err := r.isReferenceGranted(ctx, scenarioRun)
+	if err != nil {
+		log.Error(err, "unable to list SippScenarioGrants")
+		return ctrl.Result{}, err
+	}
+	if scenarioRun.IsCrossNamespace() {
+		condition := v1alpha1.Condition{
+			Type:   v1alpha1.ConditionReferenceGranted,
+			Status: corev1.ConditionTrue,
+			Reason: "Granted",
+		}
+		if !granted {
+			condition.Status = corev1.ConditionFalse
+			condition.Reason = "NotGranted"
+			condition.Message = fmt.Sprintf("no SippScenarioGrant in namespace %s allows namespace %s to use SippScenario %s",
+				scenarioRun.ScenarioNamespace(), scenarioRun.Namespace, scenarioRun.Spec.ScenarioRef.Name)
+		}
+		v1alpha1.SetCondition(&scenarioRun.Status.Conditions, condition)
+	}
+	if !granted {
+		log.Info("SippScenario reference not granted", "namespace", scenarioRun.ScenarioNamespace())
+		return ctrl.Result{}, r.Status().Update(ctx, scenarioRun)
+	}
+
 	// Get the linked scenario
-	scenario := &v1alpha1.SippScenario{}
-	err = r.Get(ctx, types.NamespacedName{Namespace: req.Namespace, Name: scenarioRun.Spec.ScenarioRef.Name}, scenario)
+	scenario, err := r.getScenario(ctx, scenarioRun)
 	if err != nil {
-		log.Error(err, "unable to fetch SippScenario")
+		log.Error(err, "unable to fetch scenario", "kind", scenarioRun.Spec.ScenarioRef.Kind)
 		return ctrl.Result{}, err
 	}
 
+	// Reject specs sipp can't run, there is no point in retrying until the spec changes
+	if err := validate(scenarioRun, scenario); err != nil {
+		log.Info("invalid SippScenarioRun", "reason", err.Error())
+		v1alpha1.SetCondition(&scenarioRun.Status.Conditions, v1alpha1.Condition{
+			Type:    v1alpha1.ConditionValid,
+			Status:  corev1.ConditionFalse,
+			Reason:  "ValidationFailed",
+			Message: err.Error(),
+		})
+		return ctrl.Result{}, r.Status().Update(ctx, scenarioRun)
+	}
+
+	v1alpha1.SetCondition(&scenarioRun.Status.Conditions, v1alpha1.Condition{
+		Type:   v1alpha1.ConditionValid,
+		Status: corev1.ConditionTrue,
+		Reason: "Validated",
+	})
+
+	// Extra args repeating generated flags are still passed to sipp, as an escape hatch
+	conflicts := scenarioRun.ConflictingExtraArgs(scenario)
+	extraArgsCondition := v1alpha1.Condition{
+		Type:   v1alpha1.ConditionExtraArgsConflict,
+		Status: corev1.ConditionFalse,
+		Reason: "NoConflict",
+	}
+	if len(conflicts) > 0 {
+		extraArgsCondition.Status = corev1.ConditionTrue
+		extraArgsCondition.Reason = "FlagsAlreadyGenerated"
+		extraArgsCondition.Message = fmt.Sprintf("extraArgs repeat the generated flags %s, sipp uses the last values", strings.Join(conflicts, ", "))
+	}
+	v1alpha1.SetCondition(&scenarioRun.Status.Conditions, extraArgsCondition)
+
+	// Inline the files loaded from ConfigMaps, Secrets or URLs
+	resolvedScenario, err := r.ContentResolver.Resolve(ctx, scenario)
+	if err != nil {
+		log.Error(err, "unable to resolve SippScenario content")
+		return ctrl.Result{}, err
+	}
+
+	// Check the loaded content like inline content,
+	// then render the scenario file with the run parameters before mounting it
+	err = resolvedScenario.Validate()
+	if err == nil {
+		err = renderParameters(resolvedScenario, scenarioRun.Spec.Parameters)
+	}
+	if err != nil {
+		log.Info("invalid SippScenario content", "reason", err.Error())
+		v1alpha1.SetCondition(&scenarioRun.Status.Conditions, v1alpha1.Condition{
+			Type:    v1alpha1.ConditionValid,
+			Status:  corev1.ConditionFalse,
+			Reason:  "InvalidContent",
+			Message: err.Error(),
+		})
+		return ctrl.Result{}, r.Status().Update(ctx, scenarioRun)
+	}
+
+	scenarioRun.Status.ScenarioDigest = content.Digest([]byte(resolvedScenario.Spec.ScenarioFileContent))
+	scenarioRun.Status.InjectValuesDigests = make([]string, 0, len(resolvedScenario.Spec.InjectValues))
+	for i := range resolvedScenario.Spec.InjectValues {
+		scenarioRun.Status.InjectValuesDigests = append(scenarioRun.Status.InjectValuesDigests, content.Digest([]byte(resolvedScenario.Spec.InjectValues[i].Render())))
+	}
 
 	resourceBuilder := resource.SippResourceBuilder{
-		Instance: scenarioRun,
-		Scenario: scenario,
-		Scheme:   r.Scheme,
+		Instance:    scenarioRun,
+		Scenario:    resolvedScenario,
+		Scheme:      r.Scheme,
+		SecretFiles: content.SecretFiles(scenario),
 	}
 
 	builders, err := resourceBuilder.ResourceBuilders()
@@ -82,25 +208,22 @@ func (r *SippScenarioRunReconciler) Reconcile(req ctrl.Request) (ctrl.Result, er
 		return ctrl.Result{}, err
 	}
 
+	finished := v1alpha1.IsConditionTrue(scenarioRun.Status.Conditions, v1alpha1.ConditionFinished)
 	for _, builder := range builders {
-		resource, err := builder.Build()
-		if err != nil {
-			return ctrl.Result{}, err
+		// The job is created once per attempt, and never again once the run is finished
+		if _, ok := builder.(*resource.JobBuilder); ok {
+			if finished {
+				continue
+			}
+			if err := createResource(ctx, r, builder); err != nil {
+				log.Error(err, "unable to create child job")
+				return ctrl.Result{}, err
+			}
+			continue
 		}
-
-		var operationResult controllerutil.OperationResult
-		err = clientretry.RetryOnConflict(clientretry.DefaultRetry, func() error {
-			var apiError error
-			operationResult, apiError = controllerutil.CreateOrUpdate(ctx, r, resource, func() error {
-				return builder.Update(resource)
-			})
-			return apiError
-		})
-		if err != nil {
-			log.Error(err, "unable to create or update resource")
+		if err := r.apply(ctx, log, builder); err != nil {
+			return ctrl.Result{}, err
 		}
-
-		log.Info("builder finished", "operationResult", operationResult)
 	}
 
 	// Update status
@@ -111,9 +234,95 @@ func (r *SippScenarioRunReconciler) Reconcile(req ctrl.Request) (ctrl.Result, er
 		return ctrl.Result{}, err
 	}
 
+	// The files of a shrinking layout are no longer mounted by the next jobs
+	if err := r.pruneConfig(ctx, log, scenarioRun, resourceBuilder.Layout, childJob); err != nil {
+		log.Error(err, "unable to delete stale run files")
+		return ctrl.Result{}, err
+	}
+
+	// The pods of the sipp wrapper succeed whatever the outcome of sipp
+	instances := []report.Instance{}
+	if childJob.Name != "" {
+		instances, err = r.listInstances(ctx, childJob)
+		if err != nil {
+			log.Error(err, "unable to list sipp instances")
+			return ctrl.Result{}, err
+		}
+	}
 	scenarioRun.Status.Active = childJob.Status.Active
-	scenarioRun.Status.Failed = childJob.Status.Failed
-	scenarioRun.Status.Succeeded = childJob.Status.Succeeded
+	scenarioRun.Status.Failed = 0
+	scenarioRun.Status.Succeeded = 0
+	unschedulable := 0
+	for _, instance := range instances {
+		switch {
+		case instance.Failed():
+			scenarioRun.Status.Failed++
+		case instance.Finished:
+			scenarioRun.Status.Succeeded++
+		case instance.Reason == corev1.PodReasonUnschedulable:
+			unschedulable++
+		}
+	}
+	if childJob.Name != "" {
+		v1alpha1.SetCondition(&scenarioRun.Status.Conditions, unschedulableCondition(scenarioRun, unschedulable, len(instances)))
+	}
+
+	if scenarioRun.Spec.Traces != nil {
+		artifacts, err := r.listArtifacts(ctx, scenarioRun)
+		if err != nil {
+			log.Error(err, "unable to list run artifacts")
+			return ctrl.Result{}, err
+		}
+		scenarioRun.Status.Artifacts = artifacts
+	}
+
+	result := ctrl.Result{}
+	if isJobFinished(childJob) {
+		retrying, requeueAfter, err := r.reconcileAttempt(ctx, log, scenarioRun, childJob)
+		if err != nil {
+			log.Error(err, "unable to record SippScenarioRun attempt")
+			return ctrl.Result{}, err
+		}
+		result.RequeueAfter = requeueAfter
+		if !retrying {
+			v1alpha1.SetCondition(&scenarioRun.Status.Conditions, finishedCondition(childJob, instances))
+		}
+	} else if childJob.Name != "" {
+		requeueAfter, err := r.reconcileControl(ctx, log, scenarioRun, childJob)
+		if err != nil {
+			log.Error(err, "unable to control sipp instances")
+			return ctrl.Result{}, err
+		}
+		result.RequeueAfter = requeueAfter
+	}
+
+	if scenarioRun.CollectsMetrics() && v1alpha1.IsConditionTrue(scenarioRun.Status.Conditions, v1alpha1.ConditionFinished) {
+		if err := r.reconcileMetrics(ctx, log, scenarioRun, childJob); err != nil {
+			log.Error(err, "unable to collect SippScenarioRun metrics")
+			return ctrl.Result{}, err
+		}
+		if scenarioRun.Spec.Baseline != nil {
+			if err := r.reconcileBaseline(ctx, scenarioRun); err != nil {
+				log.Error(err, "unable to compare SippScenarioRun metrics with the baseline")
+				return ctrl.Result{}, err
+			}
+		}
+	}
+
+	if v1alpha1.IsConditionTrue(scenarioRun.Status.Conditions, v1alpha1.ConditionFinished) {
+		if err := r.reconcileReport(ctx, log, scenarioRun, childJob); err != nil {
+			log.Error(err, "unable to store SippScenarioRun report")
+			return ctrl.Result{}, err
+		}
+	}
+
+	uploadSettings := r.UploadSettings.ForRun(scenarioRun)
+	if uploadSettings.Enabled() && v1alpha1.IsConditionTrue(scenarioRun.Status.Conditions, v1alpha1.ConditionFinished) {
+		if err := r.reconcileUpload(ctx, log, scenarioRun, childJob, uploadSettings); err != nil {
+			log.Error(err, "unable to upload SippScenarioRun artifacts")
+			return ctrl.Result{}, err
+		}
+	}
 
 	err = r.Status().Update(ctx, scenarioRun)
 	if err != nil {
@@ -121,12 +330,270 @@ func (r *SippScenarioRunReconciler) Reconcile(req ctrl.Request) (ctrl.Result, er
 		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{}, nil
+	if !r.isSettled(scenarioRun) {
+		return result, nil
+	}
+
+	if err := r.pruneHistory(ctx, log, req.Namespace); err != nil {
+		log.Error(err, "unable to prune SippScenarioRun history")
+		return ctrl.Result{}, err
+	}
+
+	return r.reconcileTTL(ctx, log, scenarioRun)
+}
+
+// apply creates or updates the resource of the builder
+func (r *SippScenarioRunReconciler) apply(ctx context.Context, log logr.Logger, builder resource.ResourceBuilder) error {
+	return applyResource(ctx, r, log, builder)
+}
+
+// applyResource creates or updates the resource of the builder with the client,
+// and returns the API error so the reconciliation is retried
+func applyResource(ctx context.Context, c client.Client, log logr.Logger, builder resource.ResourceBuilder) error {
+	object, err := builder.Build()
+	if err != nil {
+		return err
+	}
+
+	var operationResult controllerutil.OperationResult
+	err = clientretry.RetryOnConflict(clientretry.DefaultRetry, func() error {
+		var apiError error
+		operationResult, apiError = controllerutil.CreateOrUpdate(ctx, c, object, func() error {
+			return builder.Update(object)
+		})
+		return apiError
+	})
+	if err != nil {
+		log.Error(err, "unable to create or update resource")
+		return err
+	}
+
+	log.Info("builder finished", "operationResult", operationResult)
+	return nil
+}
+
+// cacheSyncDelay is how long a resource created by a reconciliation
+// may be missing from the cache read by the following ones
+const cacheSyncDelay = 10 * time.Second
+
+// cacheSynced returns whether the resources created at the time are in the cache by now
+func cacheSynced(created *metav1.Time) bool {
+	return created != nil && time.Since(created.Time) > cacheSyncDelay
+}
+
+// isJobFinished returns whether the job completed or failed
+func isJobFinished(job *batchv1.Job) bool {
+	for _, condition := range job.Status.Conditions {
+		if (condition.Type == batchv1.JobComplete || condition.Type == batchv1.JobFailed) && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// unschedulableCondition reports the sipp instances of the job which can't be scheduled
+func unschedulableCondition(scenarioRun *v1alpha1.SippScenarioRun, unschedulable, instances int) v1alpha1.Condition {
+	if unschedulable == 0 {
+		return v1alpha1.Condition{
+			Type:   v1alpha1.ConditionUnschedulable,
+			Status: corev1.ConditionFalse,
+			Reason: "Scheduled",
+		}
+	}
+
+	message := fmt.Sprintf("%d of %d sipp instances can't be scheduled", unschedulable, instances)
+	if scenarioRun.Spec.HostNetwork {
+		message += ", each instance on the host network needs a node of its own"
+	}
+	return v1alpha1.Condition{
+		Type:    v1alpha1.ConditionUnschedulable,
+		Status:  corev1.ConditionTrue,
+		Reason:  corev1.PodReasonUnschedulable,
+		Message: message,
+	}
+}
+
+// createResource creates the resource of the builder with the client,
+// and leaves it as is when it already exists
+func createResource(ctx context.Context, c client.Client, builder resource.ResourceBuilder) error {
+	object, err := builder.Build()
+	if err != nil {
+		return err
+	}
+	if err := builder.Update(object); err != nil {
+		return err
+	}
+
+	err = c.Create(ctx, object)
+	if apierrors.IsAlreadyExists(err) {
+		return nil
+	}
+	return err
+}
+
+// pruneConfig deletes the ConfigMaps and Secret owned by the run which are neither
+// part of its layout, nor mounted by its current job
+func (r *SippScenarioRunReconciler) pruneConfig(ctx context.Context, log logr.Logger, scenarioRun *v1alpha1.SippScenarioRun, layout *resource.ConfigLayout, childJob *batchv1.Job) error {
+	keep := layout.ObjectNames(scenarioRun)
+	for _, volume := range childJob.Spec.Template.Spec.Volumes {
+		if volume.Projected == nil {
+			continue
+		}
+		for _, source := range volume.Projected.Sources {
+			if source.ConfigMap != nil {
+				keep[source.ConfigMap.Name] = true
+			}
+			if source.Secret != nil {
+				keep[source.Secret.Name] = true
+			}
+		}
+	}
+
+	configMaps := &corev1.ConfigMapList{}
+	err := r.List(ctx, configMaps, client.InNamespace(scenarioRun.Namespace), client.MatchingLabels{
+		"app.kubernetes.io/component": "configmap",
+		"app.kubernetes.io/part-of":   "sipp-run",
+	})
+	if err != nil {
+		return err
+	}
+	secrets := &corev1.SecretList{}
+	err = r.List(ctx, secrets, client.InNamespace(scenarioRun.Namespace), client.MatchingLabels{
+		"app.kubernetes.io/component": "secret",
+		"app.kubernetes.io/part-of":   "sipp-run",
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := range configMaps.Items {
+		configMap := &configMaps.Items[i]
+		if !metav1.IsControlledBy(configMap, scenarioRun) || keep[configMap.Name] {
+			continue
+		}
+		log.Info("deleting stale ConfigMap", "configmap", configMap.Name)
+		if err := r.Delete(ctx, configMap); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	for i := range secrets.Items {
+		secret := &secrets.Items[i]
+		if !metav1.IsControlledBy(secret, scenarioRun) || keep[secret.Name] {
+			continue
+		}
+		log.Info("deleting stale Secret", "secret", secret.Name)
+		if err := r.Delete(ctx, secret); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// listArtifacts returns the directories of the artifacts claim
+// where the pods of the run write their trace files
+func (r *SippScenarioRunReconciler) listArtifacts(ctx context.Context, scenarioRun *v1alpha1.SippScenarioRun) ([]string, error) {
+	pods := &corev1.PodList{}
+	err := r.List(ctx, pods, client.InNamespace(scenarioRun.Namespace), client.MatchingLabels{"job-name": scenarioRun.ChildResourceName("job")})
+	if err != nil {
+		return nil, err
+	}
+
+	// The artifacts of the pods already gone stay on the claim, so they are kept
+	artifacts := append([]string{}, scenarioRun.Status.Artifacts...)
+	known := map[string]bool{}
+	for _, artifact := range artifacts {
+		known[artifact] = true
+	}
+	for _, pod := range pods.Items {
+		artifact := fmt.Sprintf("%s:%s/%s", scenarioRun.ArtifactsClaimName(), scenarioRun.Name, pod.Name)
+		if !known[artifact] {
+			artifacts = append(artifacts, artifact)
+		}
+	}
+	sort.Strings(artifacts)
+
+	return artifacts, nil
+}
+
+// getScenario returns the scenario referenced by the run, a ClusterSippScenario
+// is returned as a SippScenario of the run namespace so it is used the same way
+func (r *SippScenarioRunReconciler) getScenario(ctx context.Context, scenarioRun *v1alpha1.SippScenarioRun) (*v1alpha1.SippScenario, error) {
+	ref := scenarioRun.Spec.ScenarioRef
+
+	if ref.IsClusterScoped() {
+		clusterScenario := &v1alpha1.ClusterSippScenario{}
+		if err := r.Get(ctx, types.NamespacedName{Name: ref.Name}, clusterScenario); err != nil {
+			return nil, err
+		}
+		return clusterScenario.ToSippScenario(scenarioRun.Namespace), nil
+	}
+
+	scenario := &v1alpha1.SippScenario{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: scenarioRun.ScenarioNamespace(), Name: ref.Name}, scenario); err != nil {
+		return nil, err
+	}
+	return scenario, nil
+}
+
+// validate checks the run and its scenario before building any resource
+func validate(scenarioRun *v1alpha1.SippScenarioRun, scenario *v1alpha1.SippScenario) error {
+	if err := scenarioRun.Validate(); err != nil {
+		return err
+	}
+	if _, err := resource.SippArgs(scenarioRun); err != nil {
+		return err
+	}
+
+	if scenarioRun.Spec.ScenarioRef.IsClusterScoped() {
+		clusterScenario := &v1alpha1.ClusterSippScenario{Spec: scenario.Spec}
+		if err := clusterScenario.Validate(); err != nil {
+			return fmt.Errorf("ClusterSippScenario %s: %v", scenario.Name, err)
+		}
+	} else if err := scenario.Validate(); err != nil {
+		return fmt.Errorf("SippScenario %s: %v", scenario.Name, err)
+	}
+
+	if _, err := scenario.ParameterValues(scenarioRun.Spec.Parameters); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// renderParameters renders the scenario file of a resolved scenario in place,
+// scenarios which don't declare parameters are left as is
+func renderParameters(resolved *v1alpha1.SippScenario, values map[string]string) error {
+	if len(resolved.Spec.Parameters) == 0 {
+		return nil
+	}
+
+	typedValues, err := resolved.ParameterValues(values)
+	if err != nil {
+		return err
+	}
+
+	rendered, err := scenario.Render(resolved.Spec.ScenarioFileContent, typedValues, resolved.RawParameters())
+	if err != nil {
+		return err
+	}
+	resolved.Spec.ScenarioFileContent = rendered
+
+	return nil
 }
 
 func (r *SippScenarioRunReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	for field, extractValue := range runIndexes {
+		if err := mgr.GetFieldIndexer().IndexField(context.Background(), &v1alpha1.SippScenarioRun{}, field, extractValue); err != nil {
+			return err
+		}
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&v1alpha1.SippScenarioRun{}).
 		Owns(&batchv1.Job{}).
+		Watches(&source.Kind{Type: &v1alpha1.SippScenarioGrant{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(r.grantRequests),
+		}).
 		Complete(r)
 }
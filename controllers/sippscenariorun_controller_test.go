@@ -0,0 +1,213 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	v1alpha1 "github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	"github.com/alexandrevilain/sipp-operator/internal/content"
+	"github.com/alexandrevilain/sipp-operator/internal/resource"
+)
+
+func TestPruneConfig(t *testing.T) {
+	run := newTestRun("run")
+	configMapLabels := map[string]string{"app.kubernetes.io/component": "configmap", "app.kubernetes.io/part-of": "sipp-run"}
+	secretLabels := map[string]string{"app.kubernetes.io/component": "secret", "app.kubernetes.io/part-of": "sipp-run"}
+
+	r := newTestRunReconciler(t,
+		&corev1.ConfigMap{ObjectMeta: ownedBy(run, "run-configmap", configMapLabels)},
+		&corev1.ConfigMap{ObjectMeta: ownedBy(run, "run-configmap-1", configMapLabels)},
+		&corev1.ConfigMap{ObjectMeta: ownedBy(run, "run-configmap-2", configMapLabels)},
+		&corev1.ConfigMap{ObjectMeta: ownedBy(newTestRun("other"), "other-configmap-1", configMapLabels)},
+		&corev1.Secret{ObjectMeta: ownedBy(run, "run-secret", secretLabels)},
+	)
+
+	// The job still running mounts the third ConfigMap
+	childJob := &batchv1.Job{}
+	childJob.Spec.Template.Spec.Volumes = []corev1.Volume{{
+		Name: "sipp-config",
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{
+				Sources: []corev1.VolumeProjection{
+					{ConfigMap: &corev1.ConfigMapProjection{LocalObjectReference: corev1.LocalObjectReference{Name: "run-configmap"}}},
+					{ConfigMap: &corev1.ConfigMapProjection{LocalObjectReference: corev1.LocalObjectReference{Name: "run-configmap-2"}}},
+				},
+			},
+		},
+	}}
+
+	layout := &resource.ConfigLayout{ConfigMaps: []resource.ConfigMapContent{{}}}
+	require.NoError(t, r.pruneConfig(context.Background(), log.NullLogger{}, run, layout, childJob))
+
+	assert.True(t, exists(t, r, "default", "run-configmap", &corev1.ConfigMap{}))
+	assert.False(t, exists(t, r, "default", "run-configmap-1", &corev1.ConfigMap{}))
+	assert.True(t, exists(t, r, "default", "run-configmap-2", &corev1.ConfigMap{}))
+	assert.True(t, exists(t, r, "default", "other-configmap-1", &corev1.ConfigMap{}))
+	assert.False(t, exists(t, r, "default", "run-secret", &corev1.Secret{}))
+
+	// Once the next job is created from the layout, the ConfigMap is deleted as well
+	require.NoError(t, r.pruneConfig(context.Background(), log.NullLogger{}, run, layout, &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "run-job"}}))
+	assert.False(t, exists(t, r, "default", "run-configmap-2", &corev1.ConfigMap{}))
+	assert.True(t, exists(t, r, "default", "run-configmap", &corev1.ConfigMap{}))
+}
+
+// newInstancePod returns a pod of the job whose sipp wrapper terminated
+// with the message, or which is still running when the message is empty
+func newInstancePod(job *batchv1.Job, name, message string) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: job.Namespace,
+			Labels:    map[string]string{"job-name": job.Name, "controller-uid": string(job.UID)},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	state := corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}
+	if message != "" {
+		pod.Status.Phase = corev1.PodSucceeded
+		state = corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "Completed", Message: message}}
+	}
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: "sipp", State: state}}
+	return pod
+}
+
+func TestFailingInstance(t *testing.T) {
+	run := newTestRun("run")
+	run.Spec.RetryPolicy = &v1alpha1.RetryPolicy{Retries: 1}
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "run-job", Namespace: "default", UID: "job-uid"}}
+	job.Status.Active = 1
+	failing := newInstancePod(job, "run-job-a", "sipp exit code: 1\nAborting call on unexpected message")
+	healthy := newInstancePod(job, "run-job-b", "")
+	r := newTestRunReconciler(t, run, job, failing, healthy)
+
+	// The job keeps running the healthy instance once the other one failed
+	instances, err := r.listInstances(context.Background(), job)
+	require.NoError(t, err)
+	require.Len(t, instances, 2)
+	assert.True(t, instances[0].Failed())
+	assert.Equal(t, "Aborting call on unexpected message", instances[0].Output)
+	assert.False(t, instances[1].Failed())
+	assert.False(t, instances[1].Finished)
+
+	// Once the healthy instance is over, the run fails with the exit code of the other one
+	healthy.Status.Phase = corev1.PodSucceeded
+	healthy.Status.ContainerStatuses[0].State = corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Message: "sipp exit code: 0\n"}}
+	require.NoError(t, r.Status().Update(context.Background(), healthy))
+	completionTime := metav1.Now()
+	job.Status = batchv1.JobStatus{
+		Succeeded:      2,
+		CompletionTime: &completionTime,
+		Conditions:     []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}},
+	}
+
+	retrying, _, err := r.reconcileAttempt(context.Background(), log.NullLogger{}, run, job)
+	require.NoError(t, err)
+	assert.True(t, retrying)
+	require.Len(t, run.Status.Attempts, 1)
+	attempt := run.Status.Attempts[0]
+	assert.False(t, attempt.Succeeded)
+	assert.Equal(t, "InstanceFailed", attempt.Reason)
+	assert.Equal(t, int32(1), *attempt.ExitCode)
+
+	instances, err = r.listInstances(context.Background(), job)
+	require.NoError(t, err)
+	condition := finishedCondition(job, instances)
+	assert.Equal(t, "Failed", condition.Reason)
+	assert.Equal(t, "1 of 2 sipp instances failed", condition.Message)
+}
+
+func TestReconcileCreatesJobOnce(t *testing.T) {
+	scenario := &v1alpha1.SippScenario{
+		ObjectMeta: metav1.ObjectMeta{Name: "uac", Namespace: "default"},
+		Spec:       v1alpha1.SippScenarioSpec{ScenarioFileContent: `<scenario name="uac"></scenario>`},
+	}
+	run := newTestRun("run")
+	run.Spec.ScenarioRef = &v1alpha1.ScenarioReference{Name: "uac"}
+	r := newTestRunReconciler(t, run, scenario)
+	r.ContentResolver = content.NewResolver(r.Client)
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "run"}}
+
+	_, err := r.Reconcile(request)
+	require.NoError(t, err)
+	childJob := &batchv1.Job{}
+	require.True(t, exists(t, r, "default", "run-job", childJob))
+
+	// The job of a finished run isn't created again once deleted
+	stored := newSettledRun("run", "Succeeded", time.Minute)
+	require.True(t, exists(t, r, "default", "run", run))
+	run.Status = stored.Status
+	require.NoError(t, r.Status().Update(context.Background(), run))
+	require.NoError(t, r.Delete(context.Background(), childJob))
+
+	_, err = r.Reconcile(request)
+	require.NoError(t, err)
+	assert.False(t, exists(t, r, "default", "run-job", &batchv1.Job{}))
+}
+
+func TestReconcileUnschedulable(t *testing.T) {
+	scenario := &v1alpha1.SippScenario{
+		ObjectMeta: metav1.ObjectMeta{Name: "uac", Namespace: "default"},
+		Spec:       v1alpha1.SippScenarioSpec{ScenarioFileContent: `<scenario name="uac"></scenario>`},
+	}
+	run := newTestRun("run")
+	run.Spec.ScenarioRef = &v1alpha1.ScenarioReference{Name: "uac"}
+	run.Spec.HostNetwork = true
+	childJob := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "run-job", Namespace: "default", UID: "job-uid"}}
+	scheduled := newInstancePod(childJob, "run-job-a", "")
+	pending := newInstancePod(childJob, "run-job-b", "")
+	pending.Status = corev1.PodStatus{
+		Phase:      corev1.PodPending,
+		Conditions: []corev1.PodCondition{{Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Reason: corev1.PodReasonUnschedulable}},
+	}
+	r := newTestRunReconciler(t, run, scenario, childJob, scheduled, pending)
+	r.ContentResolver = content.NewResolver(r.Client)
+
+	// The instance beyond the available nodes stays pending
+	_, err := r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "run"}})
+	require.NoError(t, err)
+	require.True(t, exists(t, r, "default", "run", run))
+	condition := v1alpha1.FindCondition(run.Status.Conditions, v1alpha1.ConditionUnschedulable)
+	require.NotNil(t, condition)
+	assert.Equal(t, corev1.ConditionTrue, condition.Status)
+	assert.Equal(t, "1 of 2 sipp instances can't be scheduled, each instance on the host network needs a node of its own", condition.Message)
+
+	// Once scheduled, the condition is cleared
+	pending.Status = corev1.PodStatus{Phase: corev1.PodRunning}
+	require.NoError(t, r.Status().Update(context.Background(), pending))
+	_, err = r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "run"}})
+	require.NoError(t, err)
+	require.True(t, exists(t, r, "default", "run", run))
+	assert.False(t, v1alpha1.IsConditionTrue(run.Status.Conditions, v1alpha1.ConditionUnschedulable))
+}
+
+func TestReconcileInvalidCommandOverride(t *testing.T) {
+	scenario := &v1alpha1.SippScenario{
+		ObjectMeta: metav1.ObjectMeta{Name: "uac", Namespace: "default"},
+		Spec:       v1alpha1.SippScenarioSpec{ScenarioFileContent: `<scenario name="uac"></scenario>`},
+	}
+	run := newTestRun("run")
+	run.Spec.ScenarioRef = &v1alpha1.ScenarioReference{Name: "uac"}
+	run.Spec.CommandOverride = `-key name "a b`
+	r := newTestRunReconciler(t, run, scenario)
+	r.ContentResolver = content.NewResolver(r.Client)
+
+	// The command override sipp can't be given is rejected before building the job
+	_, err := r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "run"}})
+	require.NoError(t, err)
+	require.True(t, exists(t, r, "default", "run", run))
+	condition := v1alpha1.FindCondition(run.Status.Conditions, v1alpha1.ConditionValid)
+	require.NotNil(t, condition)
+	assert.Equal(t, corev1.ConditionFalse, condition.Status)
+	assert.Equal(t, "invalid commandOverride: unterminated double quote at offset 10", condition.Message)
+	assert.False(t, exists(t, r, "default", "run-job", &batchv1.Job{}))
+}
@@ -0,0 +1,440 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	"github.com/alexandrevilain/sipp-operator/internal/resource"
+)
+
+// SippTestSuiteReconciler reconciles a SippTestSuite object
+type SippTestSuiteReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=sipp.alexandrevilain.dev,resources=sipptestsuites,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=sipp.alexandrevilain.dev,resources=sipptestsuites/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=endpoints,verbs=get;list;watch
+
+func (r *SippTestSuiteReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("sipptestsuite", req.NamespacedName)
+
+	suite := &v1alpha1.SippTestSuite{}
+	err := r.Get(ctx, req.NamespacedName, suite)
+	if err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if isSuiteOver(suite.Status.Phase) {
+		return ctrl.Result{}, nil
+	}
+
+	// Invalid plans are never started
+	if err := suite.Validate(); err != nil {
+		log.Info("invalid SippTestSuite", "reason", err.Error())
+		v1alpha1.SetCondition(&suite.Status.Conditions, v1alpha1.Condition{
+			Type:    v1alpha1.ConditionValid,
+			Status:  corev1.ConditionFalse,
+			Reason:  "ValidationFailed",
+			Message: err.Error(),
+		})
+		suite.Status.Phase = v1alpha1.TestSuitePhaseFailed
+		return ctrl.Result{}, r.Status().Update(ctx, suite)
+	}
+
+	v1alpha1.SetCondition(&suite.Status.Conditions, v1alpha1.Condition{
+		Type:   v1alpha1.ConditionValid,
+		Status: corev1.ConditionTrue,
+		Reason: "Validated",
+	})
+
+	initStepStatuses(suite)
+
+	// Steps are started and checked until none of them changes,
+	// so the steps depending on a finished one start at once
+	requeueAfter := time.Duration(0)
+	for changed := true; changed; {
+		changed = false
+		for i := range suite.Spec.Steps {
+			status := &suite.Status.Steps[i]
+			phase := status.Phase
+
+			if phase == v1alpha1.TestSuitePhasePending {
+				r.resolveDependencies(suite, i)
+			}
+
+			if status.Phase == v1alpha1.TestSuitePhaseRunning && status.RunName == "" {
+				if err := r.startStep(ctx, log, suite, i); err != nil {
+					return ctrl.Result{}, err
+				}
+			}
+
+			if status.Phase == v1alpha1.TestSuitePhaseRunning {
+				after, err := r.checkStep(ctx, suite, i)
+				if err != nil {
+					return ctrl.Result{}, err
+				}
+				if after > 0 && (requeueAfter == 0 || after < requeueAfter) {
+					requeueAfter = after
+				}
+			}
+
+			changed = changed || status.Phase != phase
+		}
+	}
+
+	suite.Status.Phase = suitePhase(suite.Status.Steps)
+	if isSuiteOver(suite.Status.Phase) {
+		log.Info("SippTestSuite is over", "phase", suite.Status.Phase)
+		if err := r.stopUASRuns(ctx, suite); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	err = r.Status().Update(ctx, suite)
+	if err != nil {
+		log.Error(err, "unable to update SippTestSuite status")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+func isSuiteOver(phase v1alpha1.TestSuitePhase) bool {
+	return phase == v1alpha1.TestSuitePhaseSucceeded || phase == v1alpha1.TestSuitePhaseFailed
+}
+
+// initStepStatuses adds the statuses of the steps not started yet
+func initStepStatuses(suite *v1alpha1.SippTestSuite) {
+	existing := map[string]v1alpha1.TestStepStatus{}
+	for _, status := range suite.Status.Steps {
+		existing[status.Name] = status
+	}
+
+	statuses := make([]v1alpha1.TestStepStatus, 0, len(suite.Spec.Steps))
+	for _, step := range suite.Spec.Steps {
+		status, ok := existing[step.Name]
+		if !ok {
+			status = v1alpha1.TestStepStatus{Name: step.Name, Phase: v1alpha1.TestSuitePhasePending}
+		}
+		statuses = append(statuses, status)
+	}
+	suite.Status.Steps = statuses
+}
+
+// suitePhase returns the phase of the suite from the phases of its steps
+func suitePhase(statuses []v1alpha1.TestStepStatus) v1alpha1.TestSuitePhase {
+	failed := false
+	for _, status := range statuses {
+		switch status.Phase {
+		case v1alpha1.TestSuitePhasePending, v1alpha1.TestSuitePhaseRunning:
+			return v1alpha1.TestSuitePhaseRunning
+		case v1alpha1.TestSuitePhaseFailed:
+			failed = true
+		}
+	}
+
+	if failed {
+		return v1alpha1.TestSuitePhaseFailed
+	}
+	return v1alpha1.TestSuitePhaseSucceeded
+}
+
+// stepStatus returns the status of the named step
+func stepStatus(suite *v1alpha1.SippTestSuite, name string) *v1alpha1.TestStepStatus {
+	for i := range suite.Status.Steps {
+		if suite.Status.Steps[i].Name == name {
+			return &suite.Status.Steps[i]
+		}
+	}
+	return nil
+}
+
+// stepOutputs returns the outputs of the succeeded steps by their placeholder name
+func stepOutputs(suite *v1alpha1.SippTestSuite) map[string]string {
+	outputs := map[string]string{}
+	for _, status := range suite.Status.Steps {
+		for name, value := range status.Outputs {
+			outputs[resource.StepOutputName(status.Name, name)] = value
+		}
+	}
+	return outputs
+}
+
+// resolveDependencies marks the pending step as running once its dependencies
+// succeeded, or as skipped when one of them didn't
+func (r *SippTestSuiteReconciler) resolveDependencies(suite *v1alpha1.SippTestSuite, index int) {
+	status := &suite.Status.Steps[index]
+
+	for _, dependency := range suite.Dependencies(index) {
+		switch stepStatus(suite, dependency).Phase {
+		case v1alpha1.TestSuitePhaseFailed, v1alpha1.TestSuitePhaseSkipped:
+			status.Phase = v1alpha1.TestSuitePhaseSkipped
+			status.Message = fmt.Sprintf("step %s didn't succeed", dependency)
+			return
+		case v1alpha1.TestSuitePhasePending, v1alpha1.TestSuitePhaseRunning:
+			return
+		}
+	}
+
+	now := metav1.Now()
+	status.Phase = v1alpha1.TestSuitePhaseRunning
+	status.StartTime = &now
+}
+
+// startStep creates the resources of a step which just started.
+// They are created once: the run of the step is recorded in its status,
+// and a run missing afterwards fails the step.
+func (r *SippTestSuiteReconciler) startStep(ctx context.Context, log logr.Logger, suite *v1alpha1.SippTestSuite, index int) error {
+	step := suite.Spec.Steps[index]
+	status := &suite.Status.Steps[index]
+
+	spec := step.Run
+	if step.UAS != nil {
+		spec = step.UAS
+	}
+	if spec == nil {
+		return nil
+	}
+
+	log.Info("starting SippTestSuite step", "step", step.Name)
+	spec = resource.ExpandStepOutputs(spec, stepOutputs(suite))
+	builders := []resource.ResourceBuilder{
+		&resource.StepRunBuilder{Suite: suite, Scheme: r.Scheme, Step: step.Name, Spec: spec},
+	}
+	if step.UAS != nil {
+		builders = append(builders, &resource.UASServiceBuilder{Suite: suite, Scheme: r.Scheme, Step: step.Name, Spec: spec})
+	}
+
+	for _, builder := range builders {
+		if err := createResource(ctx, r, builder); err != nil {
+			return err
+		}
+	}
+	status.RunName = suite.StepRunName(step.Name)
+
+	return nil
+}
+
+// checkStep completes the running step once it is over,
+// and returns when to check it again if no event notifies it
+func (r *SippTestSuiteReconciler) checkStep(ctx context.Context, suite *v1alpha1.SippTestSuite, index int) (time.Duration, error) {
+	step := suite.Spec.Steps[index]
+	status := &suite.Status.Steps[index]
+
+	complete := func(phase v1alpha1.TestSuitePhase, message string) {
+		now := metav1.Now()
+		status.Phase = phase
+		status.Message = message
+		status.CompletionTime = &now
+	}
+
+	switch {
+	case step.Wait != nil && step.Wait.Duration != nil:
+		remaining := time.Until(status.StartTime.Add(step.Wait.Duration.Duration))
+		if remaining > 0 {
+			return remaining, nil
+		}
+		complete(v1alpha1.TestSuitePhaseSucceeded, "")
+		return 0, nil
+
+	case step.Wait != nil:
+		run, err := r.getStepRun(ctx, suite, step.Wait.Condition.Step)
+		if err != nil {
+			return 0, err
+		}
+		if run == nil {
+			complete(v1alpha1.TestSuitePhaseFailed, fmt.Sprintf("the run of step %s doesn't exist", step.Wait.Condition.Step))
+			return 0, nil
+		}
+		if step.Wait.Condition.Matches(run.Status.Conditions) {
+			complete(v1alpha1.TestSuitePhaseSucceeded, "")
+		}
+		return 0, nil
+
+	case step.Assert != nil:
+		run, err := r.getStepRun(ctx, suite, step.Assert.Step)
+		if err != nil {
+			return 0, err
+		}
+		if run == nil {
+			complete(v1alpha1.TestSuitePhaseFailed, fmt.Sprintf("the run of step %s doesn't exist", step.Assert.Step))
+			return 0, nil
+		}
+		if failures := assertRun(step.Assert, run); len(failures) > 0 {
+			complete(v1alpha1.TestSuitePhaseFailed, strings.Join(failures, "; "))
+		} else {
+			complete(v1alpha1.TestSuitePhaseSucceeded, "")
+		}
+		return 0, nil
+	}
+
+	run, err := r.getStepRun(ctx, suite, step.Name)
+	if err != nil {
+		return 0, err
+	}
+	if run == nil && !cacheSynced(status.StartTime) {
+		return controlRequeueDelay, nil
+	}
+	if run == nil {
+		complete(v1alpha1.TestSuitePhaseFailed, fmt.Sprintf("the run %s of the step was deleted", status.RunName))
+		return 0, nil
+	}
+
+	if valid := v1alpha1.FindCondition(run.Status.Conditions, v1alpha1.ConditionValid); valid != nil && valid.Status == corev1.ConditionFalse {
+		complete(v1alpha1.TestSuitePhaseFailed, fmt.Sprintf("invalid run: %s", valid.Message))
+		return 0, nil
+	}
+
+	finished := v1alpha1.FindCondition(run.Status.Conditions, v1alpha1.ConditionFinished)
+	switch {
+	case step.UAS != nil && finished != nil && finished.Status == corev1.ConditionTrue:
+		complete(v1alpha1.TestSuitePhaseFailed, "the UAS run finished before the steps depending on it")
+	case step.UAS != nil:
+		// The following steps call the UAS once all its instances answer behind its Service
+		ready, err := r.uasReady(ctx, suite, step.Name, run)
+		if err != nil {
+			return 0, err
+		}
+		if !ready {
+			return controlRequeueDelay, nil
+		}
+		status.Outputs = resource.UASOutputs(suite, step.Name, step.UAS)
+		complete(v1alpha1.TestSuitePhaseSucceeded, "")
+	case step.Run != nil && finished != nil && finished.Status == corev1.ConditionTrue:
+		status.Outputs = map[string]string{"name": run.Name}
+		if finished.Reason == "Failed" {
+			complete(v1alpha1.TestSuitePhaseFailed, fmt.Sprintf("the run failed: %s", finished.Message))
+		} else {
+			complete(v1alpha1.TestSuitePhaseSucceeded, "")
+		}
+	}
+
+	return 0, nil
+}
+
+// uasReady returns whether the Service of the UAS step has a ready endpoint
+// for each instance of its run
+func (r *SippTestSuiteReconciler) uasReady(ctx context.Context, suite *v1alpha1.SippTestSuite, step string, run *v1alpha1.SippScenarioRun) (bool, error) {
+	endpoints := &corev1.Endpoints{}
+	err := r.Get(ctx, types.NamespacedName{Namespace: suite.Namespace, Name: suite.StepRunName(step)}, endpoints)
+	if err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+
+	ready := 0
+	for _, subset := range endpoints.Subsets {
+		ready += len(subset.Addresses)
+	}
+	return ready >= int(run.GetParallelism()), nil
+}
+
+// getStepRun returns the run of the step, or nil when it doesn't exist
+func (r *SippTestSuiteReconciler) getStepRun(ctx context.Context, suite *v1alpha1.SippTestSuite, step string) (*v1alpha1.SippScenarioRun, error) {
+	run := &v1alpha1.SippScenarioRun{}
+	err := r.Get(ctx, types.NamespacedName{Namespace: suite.Namespace, Name: suite.StepRunName(step)}, run)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// assertRun returns the failed assertions on the results of the run
+func assertRun(assert *v1alpha1.AssertStep, run *v1alpha1.SippScenarioRun) []string {
+	failures := []string{}
+
+	finished := v1alpha1.FindCondition(run.Status.Conditions, v1alpha1.ConditionFinished)
+	succeeded := finished != nil && finished.Status == corev1.ConditionTrue && finished.Reason != "Failed"
+	if assert.Succeeded != nil && *assert.Succeeded != succeeded {
+		failures = append(failures, fmt.Sprintf("expected succeeded to be %t", *assert.Succeeded))
+	}
+
+	if len(assert.ExitCodes) > 0 {
+		exitCode := int32(0)
+		if attempts := run.Status.Attempts; len(attempts) > 0 && attempts[len(attempts)-1].ExitCode != nil {
+			exitCode = *attempts[len(attempts)-1].ExitCode
+		}
+
+		expected := false
+		for _, code := range assert.ExitCodes {
+			expected = expected || code == exitCode
+		}
+		if !expected {
+			failures = append(failures, fmt.Sprintf("unexpected exit code %d", exitCode))
+		}
+	}
+
+	for _, condition := range assert.Conditions {
+		if !condition.Matches(run.Status.Conditions) {
+			failures = append(failures, fmt.Sprintf("expected condition %s", condition.Type))
+		}
+	}
+
+	return failures
+}
+
+// stopUASRuns gracefully stops the runs of the UAS steps once the suite is over
+func (r *SippTestSuiteReconciler) stopUASRuns(ctx context.Context, suite *v1alpha1.SippTestSuite) error {
+	for _, step := range suite.Spec.Steps {
+		if step.UAS == nil {
+			continue
+		}
+
+		run, err := r.getStepRun(ctx, suite, step.Name)
+		if run == nil || err != nil {
+			return err
+		}
+		if run.Spec.Stop {
+			continue
+		}
+
+		run.Spec.Stop = true
+		if err := r.Update(ctx, run); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *SippTestSuiteReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.SippTestSuite{}).
+		Owns(&v1alpha1.SippScenarioRun{}).
+		Complete(r)
+}
@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	v1alpha1 "github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+)
+
+// newTestSuite returns a suite of the default namespace running the steps
+func newTestSuite(steps ...v1alpha1.TestStep) *v1alpha1.SippTestSuite {
+	return &v1alpha1.SippTestSuite{
+		ObjectMeta: metav1.ObjectMeta{Name: "suite", Namespace: "default", UID: "suite-uid"},
+		Spec:       v1alpha1.SippTestSuiteSpec{Steps: steps},
+	}
+}
+
+// newTestSuiteReconciler returns a suite reconciler backed by a fake client holding the objects
+func newTestSuiteReconciler(t *testing.T, objects ...runtime.Object) *SippTestSuiteReconciler {
+	scheme := newTestScheme(t)
+	return &SippTestSuiteReconciler{
+		Client: fake.NewFakeClientWithScheme(scheme, objects...),
+		Log:    log.NullLogger{},
+		Scheme: scheme,
+	}
+}
+
+// reconcileSuite reconciles the suite and returns it as stored afterwards
+func reconcileSuite(t *testing.T, r *SippTestSuiteReconciler) (ctrl.Result, *v1alpha1.SippTestSuite) {
+	result, err := r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "suite"}})
+	require.NoError(t, err)
+
+	suite := &v1alpha1.SippTestSuite{}
+	require.True(t, exists(t, r, "default", "suite", suite))
+	return result, suite
+}
+
+func TestReconcileSuiteCreatesRunsOnce(t *testing.T) {
+	suite := newTestSuite(v1alpha1.TestStep{
+		Name: "caller",
+		Run:  &v1alpha1.SippScenarioRunSpec{ScenarioRef: &v1alpha1.ScenarioReference{Name: "uac"}},
+	})
+	r := newTestSuiteReconciler(t, suite)
+
+	_, stored := reconcileSuite(t, r)
+	assert.Equal(t, v1alpha1.TestSuitePhaseRunning, stored.Status.Steps[0].Phase)
+	assert.Equal(t, suite.StepRunName("caller"), stored.Status.Steps[0].RunName)
+
+	// The run isn't applied again once created
+	run := &v1alpha1.SippScenarioRun{}
+	require.True(t, exists(t, r, "default", suite.StepRunName("caller"), run))
+	run.Spec.Stop = true
+	require.NoError(t, r.Update(context.Background(), run))
+
+	_, stored = reconcileSuite(t, r)
+	assert.Equal(t, v1alpha1.TestSuitePhaseRunning, stored.Status.Steps[0].Phase)
+	run = &v1alpha1.SippScenarioRun{}
+	require.True(t, exists(t, r, "default", suite.StepRunName("caller"), run))
+	assert.True(t, run.Spec.Stop)
+
+	// A run deleted afterwards fails its step instead of being created again,
+	// once it can no longer be missing from the cache only
+	require.NoError(t, r.Delete(context.Background(), run))
+	result, stored := reconcileSuite(t, r)
+	assert.Equal(t, controlRequeueDelay, result.RequeueAfter)
+	assert.Equal(t, v1alpha1.TestSuitePhaseRunning, stored.Status.Steps[0].Phase)
+
+	startTime := metav1.NewTime(time.Now().Add(-time.Minute))
+	stored.Status.Steps[0].StartTime = &startTime
+	require.NoError(t, r.Status().Update(context.Background(), stored))
+	_, stored = reconcileSuite(t, r)
+	assert.Equal(t, v1alpha1.TestSuitePhaseFailed, stored.Status.Steps[0].Phase)
+	assert.Equal(t, v1alpha1.TestSuitePhaseFailed, stored.Status.Phase)
+	assert.False(t, exists(t, r, "default", suite.StepRunName("caller"), &v1alpha1.SippScenarioRun{}))
+}
+
+func TestReconcileSuiteWaitsForUAS(t *testing.T) {
+	suite := newTestSuite(v1alpha1.TestStep{
+		Name: "callee",
+		UAS: &v1alpha1.SippScenarioRunSpec{
+			ScenarioRef: &v1alpha1.ScenarioReference{Name: "uas"},
+			Parallelism: pointer.Int32Ptr(2),
+		},
+	})
+	r := newTestSuiteReconciler(t, suite)
+	_, _ = reconcileSuite(t, r)
+
+	// The run is active, but its instances don't answer behind the Service yet
+	run := &v1alpha1.SippScenarioRun{}
+	require.True(t, exists(t, r, "default", suite.StepRunName("callee"), run))
+	run.Status.Active = 2
+	require.NoError(t, r.Status().Update(context.Background(), run))
+
+	result, stored := reconcileSuite(t, r)
+	assert.Equal(t, controlRequeueDelay, result.RequeueAfter)
+	assert.Equal(t, v1alpha1.TestSuitePhaseRunning, stored.Status.Steps[0].Phase)
+
+	endpoints := &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Name: suite.StepRunName("callee"), Namespace: "default"},
+		Subsets: []corev1.EndpointSubset{{
+			Addresses:         []corev1.EndpointAddress{{IP: "10.0.0.1"}},
+			NotReadyAddresses: []corev1.EndpointAddress{{IP: "10.0.0.2"}},
+		}},
+	}
+	require.NoError(t, r.Create(context.Background(), endpoints))
+	result, stored = reconcileSuite(t, r)
+	assert.Equal(t, controlRequeueDelay, result.RequeueAfter)
+	assert.Equal(t, v1alpha1.TestSuitePhaseRunning, stored.Status.Steps[0].Phase)
+
+	// The step succeeds once each instance is ready
+	endpoints.Subsets[0].Addresses = append(endpoints.Subsets[0].Addresses, endpoints.Subsets[0].NotReadyAddresses...)
+	endpoints.Subsets[0].NotReadyAddresses = nil
+	require.NoError(t, r.Update(context.Background(), endpoints))
+	_, stored = reconcileSuite(t, r)
+	assert.Equal(t, v1alpha1.TestSuitePhaseSucceeded, stored.Status.Steps[0].Phase)
+	assert.NotEmpty(t, stored.Status.Steps[0].Outputs["address"])
+	assert.True(t, exists(t, r, "default", suite.StepRunName("callee"), &corev1.Service{}))
+}
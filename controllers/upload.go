@@ -0,0 +1,161 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/go-logr/logr"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	"github.com/alexandrevilain/sipp-operator/internal/resource"
+	"github.com/alexandrevilain/sipp-operator/internal/upload"
+)
+
+// reconcileUpload runs the job uploading the artifacts of a finished run,
+// and reports its outcome in the Uploaded condition and the status uploads
+func (r *SippScenarioRunReconciler) reconcileUpload(ctx context.Context, log logr.Logger, scenarioRun *v1alpha1.SippScenarioRun, childJob *batchv1.Job, settings upload.Settings) error {
+	// The artifacts are uploaded once, whatever the outcome
+	if condition := v1alpha1.FindCondition(scenarioRun.Status.Conditions, v1alpha1.ConditionUploaded); condition != nil && condition.Status != corev1.ConditionUnknown {
+		return nil
+	}
+
+	condition := v1alpha1.Condition{
+		Type:   v1alpha1.ConditionUploaded,
+		Status: corev1.ConditionUnknown,
+		Reason: "Uploading",
+	}
+	defer func() {
+		v1alpha1.SetCondition(&scenarioRun.Status.Conditions, condition)
+	}()
+
+	prefix, err := settings.RenderPrefix(scenarioRun)
+	if err != nil {
+		condition.Status = corev1.ConditionFalse
+		condition.Reason = "InvalidPrefix"
+		condition.Message = err.Error()
+		return nil
+	}
+
+	// The operator credentials are never copied to the namespace of a run,
+	// which must then provide its own
+	if settings.CredentialsSecret.Name != "" && settings.CredentialsSecret.Namespace != scenarioRun.Namespace {
+		condition.Status = corev1.ConditionFalse
+		condition.Reason = "MissingCredentials"
+		condition.Message = "spec.upload.credentialsSecret must name a Secret of the run namespace holding the object storage credentials"
+		return nil
+	}
+
+	summary, err := json.Marshal(newSummary(scenarioRun, childJob))
+	if err != nil {
+		return err
+	}
+
+	err = r.apply(ctx, log, &resource.UploadJobBuilder{
+		Instance:              scenarioRun,
+		Scheme:                r.Scheme,
+		Image:                 r.UploaderImage,
+		Settings:              settings,
+		Prefix:                prefix,
+		CredentialsSecretName: settings.CredentialsSecret.Name,
+		Summary:               summary,
+	})
+	if err != nil {
+		return err
+	}
+
+	uploadJob := &batchv1.Job{}
+	err = r.Get(ctx, types.NamespacedName{Namespace: scenarioRun.Namespace, Name: scenarioRun.ChildResourceName("upload")}, uploadJob)
+	if err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if !isJobFinished(uploadJob) {
+		return nil
+	}
+
+	message, err := r.terminationMessage(ctx, uploadJob)
+	if err != nil {
+		return err
+	}
+
+	if uploadJob.Status.Succeeded > 0 {
+		condition.Status = corev1.ConditionTrue
+		condition.Reason = "Uploaded"
+		scenarioRun.Status.Uploads = strings.Fields(message)
+	} else {
+		condition.Status = corev1.ConditionFalse
+		condition.Reason = "UploadFailed"
+		condition.Message = message
+	}
+
+	return nil
+}
+
+// terminationMessage returns the termination message of the last pod of a job
+// run with the operator image: its output when it succeeded, the error otherwise
+func (r *SippScenarioRunReconciler) terminationMessage(ctx context.Context, job *batchv1.Job) (string, error) {
+	pods := &corev1.PodList{}
+	err := r.List(ctx, pods, client.InNamespace(job.Namespace), client.MatchingLabels{"job-name": job.Name})
+	if err != nil {
+		return "", err
+	}
+
+	message := ""
+	var finishedAt int64
+	for _, pod := range pods.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			terminated := status.State.Terminated
+			if terminated != nil && terminated.FinishedAt.Unix() >= finishedAt {
+				finishedAt = terminated.FinishedAt.Unix()
+				message = terminated.Message
+			}
+		}
+	}
+
+	return message, nil
+}
+
+// newSummary returns the results summary of a finished run
+func newSummary(scenarioRun *v1alpha1.SippScenarioRun, childJob *batchv1.Job) *upload.Summary {
+	summary := &upload.Summary{
+		Namespace:           scenarioRun.Namespace,
+		Run:                 scenarioRun.Name,
+		ScenarioDigest:      scenarioRun.Status.ScenarioDigest,
+		InjectValuesDigests: scenarioRun.Status.InjectValuesDigests,
+		Parallelism:         scenarioRun.GetParallelism(),
+		Succeeded:           scenarioRun.Status.Succeeded,
+		Failed:              scenarioRun.Status.Failed,
+	}
+
+	if scenarioRun.Spec.ScenarioRef != nil {
+		summary.Scenario = scenarioRun.Spec.ScenarioRef.Name
+	}
+	if childJob.Status.StartTime != nil {
+		summary.StartTime = &childJob.Status.StartTime.Time
+	}
+	if childJob.Status.CompletionTime != nil {
+		summary.CompletionTime = &childJob.Status.CompletionTime.Time
+	}
+
+	return summary
+}
@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	v1alpha1 "github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	"github.com/alexandrevilain/sipp-operator/internal/upload"
+)
+
+func TestReconcileUploadCredentials(t *testing.T) {
+	operatorSecret := &corev1.Secret{}
+	operatorSecret.Name = "s3"
+	operatorSecret.Namespace = "sipp-operator-system"
+	r := newTestRunReconciler(t, operatorSecret)
+
+	settings := upload.Settings{
+		Bucket:            "sipp",
+		CredentialsSecret: types.NamespacedName{Namespace: "sipp-operator-system", Name: "s3"},
+	}
+
+	// The operator credentials are never copied to the namespace of the run
+	run := newTestRun("run")
+	require.NoError(t, r.reconcileUpload(context.Background(), log.NullLogger{}, run, &batchv1.Job{}, settings.ForRun(run)))
+
+	condition := v1alpha1.FindCondition(run.Status.Conditions, v1alpha1.ConditionUploaded)
+	require.NotNil(t, condition)
+	assert.Equal(t, corev1.ConditionFalse, condition.Status)
+	assert.Equal(t, "MissingCredentials", condition.Reason)
+	assert.False(t, exists(t, r, "default", "run-upload", &batchv1.Job{}))
+	secrets := &corev1.SecretList{}
+	require.NoError(t, r.List(context.Background(), secrets))
+	assert.Len(t, secrets.Items, 1)
+
+	// A run providing its own credentials uploads with them
+	run = newTestRun("minio-run")
+	run.Spec.Upload = &v1alpha1.ObjectStorage{CredentialsSecret: &corev1.LocalObjectReference{Name: "minio"}}
+	require.NoError(t, r.reconcileUpload(context.Background(), log.NullLogger{}, run, &batchv1.Job{}, settings.ForRun(run)))
+
+	uploadJob := &batchv1.Job{}
+	require.True(t, exists(t, r, "default", "minio-run-upload", uploadJob))
+	env := uploadJob.Spec.Template.Spec.Containers[0].Env
+	assert.Equal(t, "minio", env[1].ValueFrom.SecretKeyRef.Name)
+}
+
+func TestReconcileUploadRejected(t *testing.T) {
+	r := newTestRunReconciler(t)
+	r.Client = rejectingClient{r.Client}
+	settings := upload.Settings{Bucket: "sipp"}
+
+	// The upload is retried until its job is created
+	run := newTestRun("run")
+	assert.Error(t, r.reconcileUpload(context.Background(), log.NullLogger{}, run, &batchv1.Job{}, settings.ForRun(run)))
+
+	condition := v1alpha1.FindCondition(run.Status.Conditions, v1alpha1.ConditionUploaded)
+	require.NotNil(t, condition)
+	assert.Equal(t, corev1.ConditionUnknown, condition.Status)
+	assert.False(t, exists(t, r, "default", "run-upload", &batchv1.Job{}))
+}
@@ -0,0 +1,346 @@
+package capture_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/rand"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexandrevilain/sipp-operator/internal/capture"
+)
+
+const invite = "INVITE sip:bob@10.0.0.2 SIP/2.0\r\n" +
+	"Via: SIP/2.0/UDP 10.0.0.1:5060;branch=z9hG4bK-1\r\n" +
+	"i: call-1@10.0.0.1\r\n" +
+	"CSeq: 1 INVITE\r\n" +
+	"Content-Length: 4\r\n" +
+	"\r\n" +
+	"v=0\n"
+
+func udp(sport, dport uint16, payload []byte) []byte {
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint16(header, sport)
+	binary.BigEndian.PutUint16(header[2:], dport)
+	binary.BigEndian.PutUint16(header[4:], uint16(8+len(payload)))
+	return append(header, payload...)
+}
+
+func tcp(sport, dport uint16, seq uint32, syn bool, payload []byte) []byte {
+	header := make([]byte, 20)
+	binary.BigEndian.PutUint16(header, sport)
+	binary.BigEndian.PutUint16(header[2:], dport)
+	binary.BigEndian.PutUint32(header[4:], seq)
+	header[12] = 5 << 4
+	if syn {
+		header[13] = 0x02
+	}
+	return append(header, payload...)
+}
+
+// ipv4 returns a datagram, or one of its fragments when offset or more is set
+func ipv4(src, dst string, protocol byte, offset int, more bool, payload []byte) []byte {
+	header := make([]byte, 20)
+	header[0] = 0x45
+	binary.BigEndian.PutUint16(header[2:], uint16(20+len(payload)))
+	binary.BigEndian.PutUint16(header[4:], 42)
+	flags := uint16(offset / 8)
+	if more {
+		flags |= 0x2000
+	}
+	binary.BigEndian.PutUint16(header[6:], flags)
+	header[9] = protocol
+	copy(header[12:], net.ParseIP(src).To4())
+	copy(header[16:], net.ParseIP(dst).To4())
+	return append(header, payload...)
+}
+
+func ipv6(src, dst string, protocol byte, payload []byte) []byte {
+	header := make([]byte, 40)
+	header[0] = 0x60
+	binary.BigEndian.PutUint16(header[4:], uint16(len(payload)))
+	header[6] = protocol
+	copy(header[8:], net.ParseIP(src))
+	copy(header[24:], net.ParseIP(dst))
+	return append(header, payload...)
+}
+
+func ethernet(etherType uint16, payload []byte) []byte {
+	header := make([]byte, 14)
+	binary.BigEndian.PutUint16(header[12:], etherType)
+	return append(header, payload...)
+}
+
+// pcap returns a little endian pcap file of the frames, captured one second apart
+func pcap(linkType uint32, frames ...[]byte) []byte {
+	file := &bytes.Buffer{}
+	header := make([]byte, 24)
+	binary.LittleEndian.PutUint32(header, 0xa1b2c3d4)
+	binary.LittleEndian.PutUint16(header[4:], 2)
+	binary.LittleEndian.PutUint16(header[6:], 4)
+	binary.LittleEndian.PutUint32(header[16:], 65535)
+	binary.LittleEndian.PutUint32(header[20:], linkType)
+	file.Write(header)
+
+	for i, frame := range frames {
+		record := make([]byte, 16)
+		binary.LittleEndian.PutUint32(record, uint32(1600000000+i))
+		binary.LittleEndian.PutUint32(record[4:], 500)
+		binary.LittleEndian.PutUint32(record[8:], uint32(len(frame)))
+		binary.LittleEndian.PutUint32(record[12:], uint32(len(frame)))
+		file.Write(record)
+		file.Write(frame)
+	}
+
+	return file.Bytes()
+}
+
+func pcapngBlock(order binary.ByteOrder, blockType uint32, body []byte) []byte {
+	for len(body)%4 != 0 {
+		body = append(body, 0)
+	}
+	block := make([]byte, 8, 12+len(body))
+	order.PutUint32(block, blockType)
+	order.PutUint32(block[4:], uint32(12+len(body)))
+	block = append(block, body...)
+	trailer := make([]byte, 4)
+	order.PutUint32(trailer, uint32(12+len(body)))
+	return append(block, trailer...)
+}
+
+func TestReadPcap(t *testing.T) {
+	arp := ethernet(0x0806, make([]byte, 28))
+	vlan := append([]byte{0, 1, 0x08, 0x00}, ipv4("10.0.0.2", "10.0.0.1", 17, 0, false, udp(5060, 5060, []byte("SIP/2.0 100 Trying\r\n\r\n")))...)
+	file := pcap(1,
+		ethernet(0x0800, ipv4("10.0.0.1", "10.0.0.2", 17, 0, false, udp(5060, 5060, []byte(invite)))),
+		arp,
+		ethernet(0x8100, vlan),
+	)
+
+	packets, err := capture.ReadPackets(bytes.NewReader(file))
+	require.NoError(t, err)
+	require.Len(t, packets, 2)
+
+	assert.Equal(t, time.Unix(1600000000, 500000), packets[0].Time)
+	assert.Equal(t, capture.TransportUDP, packets[0].Transport)
+	assert.Equal(t, "10.0.0.1:5060", packets[0].Src.String())
+	assert.Equal(t, "10.0.0.2:5060", packets[0].Dst.String())
+	assert.Equal(t, invite, string(packets[0].Payload))
+	assert.Equal(t, "10.0.0.2:5060", packets[1].Src.String())
+
+	_, err = capture.ReadPackets(bytes.NewReader([]byte("not a capture file at all")))
+	assert.Error(t, err)
+	_, err = capture.ReadPackets(bytes.NewReader(file[:len(file)-3]))
+	assert.Error(t, err)
+}
+
+func TestReadPcapng(t *testing.T) {
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		section := make([]byte, 16)
+		order.PutUint32(section, 0x1a2b3c4d)
+		order.PutUint16(section[4:], 1)
+		for i := 8; i < 16; i++ {
+			section[i] = 0xff
+		}
+
+		// A raw IP interface with nanosecond timestamps
+		description := make([]byte, 16)
+		order.PutUint16(description, 101)
+		order.PutUint16(description[8:], 9)
+		order.PutUint16(description[10:], 1)
+		description[12] = 9
+
+		frame := ipv6("2001:db8::1", "2001:db8::2", 17, udp(5062, 5060, []byte(invite)))
+		packet := make([]byte, 20)
+		units := uint64(1600000000)*1000000000 + 42
+		order.PutUint32(packet[4:], uint32(units>>32))
+		order.PutUint32(packet[8:], uint32(units))
+		order.PutUint32(packet[12:], uint32(len(frame)))
+		order.PutUint32(packet[16:], uint32(len(frame)))
+
+		file := append(pcapngBlock(order, 0x0a0d0d0a, section), pcapngBlock(order, 1, description)...)
+		file = append(file, pcapngBlock(order, 6, append(packet, frame...))...)
+
+		packets, err := capture.ReadPackets(bytes.NewReader(file))
+		require.NoError(t, err, order)
+		require.Len(t, packets, 1, order)
+		assert.Equal(t, time.Unix(1600000000, 42), packets[0].Time, order)
+		assert.Equal(t, "[2001:db8::1]:5062", packets[0].Src.String(), order)
+		assert.Equal(t, invite, string(packets[0].Payload), order)
+	}
+}
+
+func TestReadIPv4Fragments(t *testing.T) {
+	datagram := udp(5060, 5060, []byte(invite))
+	file := pcap(101,
+		ipv4("10.0.0.1", "10.0.0.2", 17, 32, false, datagram[32:]),
+		ipv4("10.0.0.1", "10.0.0.2", 17, 0, true, datagram[:32]),
+	)
+
+	packets, err := capture.ReadPackets(bytes.NewReader(file))
+	require.NoError(t, err)
+	require.Len(t, packets, 1)
+	assert.Equal(t, invite, string(packets[0].Payload))
+}
+
+func TestMessages(t *testing.T) {
+	ok := "SIP/2.0 200 OK\r\nCall-ID: call-1@10.0.0.1\r\nCSeq: 1 INVITE\r\nContent-Length: 0\r\n\r\n"
+	options := "OPTIONS sip:10.0.0.2 SIP/2.0\r\nCall-ID: call-2\r\nCSeq: 1 OPTIONS\r\n\r\n"
+	stream := invite + ok
+
+	file := pcap(101,
+		ipv4("10.0.0.1", "10.0.0.2", 6, 0, false, tcp(40000, 5060, 999, true, nil)),
+		ipv4("10.0.0.1", "10.0.0.2", 6, 0, false, tcp(40000, 5060, 1000, false, []byte(stream[:50]))),
+		// A retransmission overlapping the previous segment
+		ipv4("10.0.0.1", "10.0.0.2", 6, 0, false, tcp(40000, 5060, 1020, false, []byte(stream[20:120]))),
+		ipv4("10.0.0.1", "10.0.0.2", 6, 0, false, tcp(40000, 5060, 1120, false, []byte(stream[120:]))),
+		// A keep-alive
+		ipv4("10.0.0.1", "10.0.0.2", 6, 0, false, tcp(40000, 5060, 1000+uint32(len(stream)), false, []byte("\r\n\r\n"))),
+		ipv4("10.0.0.1", "10.0.0.2", 17, 0, false, udp(5060, 5060, []byte(options))),
+		ipv4("10.0.0.1", "10.0.0.2", 17, 0, false, udp(5060, 5060, []byte("\r\n\r\n"))),
+	)
+
+	packets, err := capture.ReadPackets(bytes.NewReader(file))
+	require.NoError(t, err)
+	messages := capture.Messages(packets)
+	require.Len(t, messages, 3)
+
+	assert.Equal(t, "INVITE sip:bob@10.0.0.2 SIP/2.0", messages[0].StartLine)
+	assert.True(t, messages[0].IsRequest())
+	assert.Equal(t, "INVITE", messages[0].Method())
+	assert.Equal(t, "call-1@10.0.0.1", messages[0].CallID())
+	assert.Equal(t, "v=0\n", messages[0].Body)
+	assert.Equal(t, capture.TransportTCP, messages[0].Transport)
+
+	assert.False(t, messages[1].IsRequest())
+	assert.Equal(t, 200, messages[1].StatusCode())
+	assert.Equal(t, "INVITE", messages[1].Method())
+	assert.Equal(t, time.Unix(1600000003, 500000), messages[1].Time)
+
+	assert.Equal(t, "OPTIONS", messages[2].Method())
+
+	dialogs := capture.Dialogs(messages)
+	require.Len(t, dialogs, 2)
+	assert.Equal(t, "call-1@10.0.0.1", dialogs[0].CallID)
+	assert.Len(t, dialogs[0].Messages, 2)
+	assert.Equal(t, "call-2", dialogs[1].CallID)
+}
+
+// pcapngFile returns a little endian pcapng capture of a raw IP interface holding the frames
+func pcapngFile(frames ...[]byte) []byte {
+	order := binary.LittleEndian
+	section := make([]byte, 16)
+	order.PutUint32(section, 0x1a2b3c4d)
+	order.PutUint16(section[4:], 1)
+	description := make([]byte, 8)
+	order.PutUint16(description, 101)
+
+	file := append(pcapngBlock(order, 0x0a0d0d0a, section), pcapngBlock(order, 1, description)...)
+	for _, frame := range frames {
+		packet := make([]byte, 20)
+		order.PutUint32(packet[12:], uint32(len(frame)))
+		order.PutUint32(packet[16:], uint32(len(frame)))
+		file = append(file, pcapngBlock(order, 6, append(packet, frame...))...)
+	}
+	return file
+}
+
+func TestReadMalformedPcapng(t *testing.T) {
+	order := binary.LittleEndian
+	valid := pcapngFile(ipv4("10.0.0.1", "10.0.0.2", 17, 0, false, udp(5060, 5060, []byte(invite))))
+	sectionHeader := func(length uint32) []byte {
+		block := make([]byte, 12)
+		order.PutUint32(block, 0x0a0d0d0a)
+		order.PutUint32(block[4:], length)
+		order.PutUint32(block[8:], 0x1a2b3c4d)
+		return append(block, make([]byte, 32)...)
+	}
+	block := func(blockType, length uint32, body []byte) []byte {
+		header := make([]byte, 8)
+		order.PutUint32(header, blockType)
+		order.PutUint32(header[4:], length)
+		return append(append(header, body...), make([]byte, 4)...)
+	}
+	oversized := make([]byte, 24)
+	order.PutUint32(oversized[12:], 0xffffffff)
+
+	tests := map[string][]byte{
+		"section header of 12 bytes":        sectionHeader(12),
+		"section header of 24 bytes":        sectionHeader(24),
+		"block of 8 bytes":                  append(valid[:28], block(1, 8, nil)...),
+		"unaligned block":                   append(valid[:28], block(1, 14, make([]byte, 6))...),
+		"short interface":                   append(valid[:28], block(1, 16, make([]byte, 4))...),
+		"short packet":                      append(valid, block(6, 28, make([]byte, 16))...),
+		"packet of an undeclared interface": append(valid[:28], block(6, 32, make([]byte, 20))...),
+		"oversized captured length":         append(valid, block(6, 36, oversized)...),
+		"invalid byte order magic":          append(valid[:8], make([]byte, 20)...),
+	}
+
+	for name, file := range tests {
+		_, err := capture.ReadPackets(bytes.NewReader(file))
+		assert.Error(t, err, name)
+	}
+}
+
+// TestReadCorruptedCaptures feeds truncated and randomly corrupted captures
+// to the readers, which must return an error or packets, but never panic
+func TestReadCorruptedCaptures(t *testing.T) {
+	frames := [][]byte{
+		ipv4("10.0.0.1", "10.0.0.2", 17, 0, false, udp(5060, 5060, []byte(invite))),
+		ipv4("10.0.0.1", "10.0.0.2", 6, 0, false, tcp(40000, 5060, 1000, false, []byte(invite))),
+		ipv6("2001:db8::1", "2001:db8::2", 17, udp(5062, 5060, []byte(invite))),
+	}
+	files := map[string][]byte{
+		"pcap":   pcap(101, frames...),
+		"pcapng": pcapngFile(frames...),
+	}
+
+	random := rand.New(rand.NewSource(1))
+	for name, file := range files {
+		for length := 0; length < len(file); length++ {
+			readCorrupted(t, name, file[:length])
+		}
+		for i := 0; i < 2000; i++ {
+			corrupted := append([]byte(nil), file...)
+			for j := random.Intn(8); j >= 0; j-- {
+				corrupted[random.Intn(len(corrupted))] = byte(random.Intn(256))
+			}
+			readCorrupted(t, name, corrupted)
+		}
+	}
+}
+
+func readCorrupted(t *testing.T, name string, file []byte) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			t.Fatalf("%s %x: %v", name, file, recovered)
+		}
+	}()
+
+	packets, err := capture.ReadPackets(bytes.NewReader(file))
+	if err == nil {
+		capture.Dialogs(capture.Messages(packets))
+	}
+}
+
+func TestMessagesSequenceWrap(t *testing.T) {
+	// A segment whose sequence number is as far as possible from the expected one
+	wrapped := uint32(0xfffffff0)
+	file := pcap(101,
+		ipv4("10.0.0.1", "10.0.0.2", 6, 0, false, tcp(40000, 5060, 0x80000000, true, nil)),
+		ipv4("10.0.0.1", "10.0.0.2", 6, 0, false, tcp(40000, 5060, 1, false, []byte(invite))),
+		ipv4("10.0.0.1", "10.0.0.2", 6, 0, false, tcp(40000, 5060, wrapped, false, []byte(invite))),
+		ipv4("10.0.0.1", "10.0.0.2", 6, 0, false, tcp(40000, 5060, wrapped+uint32(len(invite)), false, []byte(invite))),
+	)
+
+	packets, err := capture.ReadPackets(bytes.NewReader(file))
+	require.NoError(t, err)
+	messages := capture.Messages(packets)
+	// The repeated segment is left out, the following ones wrap around
+	require.Len(t, messages, 2)
+	assert.Equal(t, "call-1@10.0.0.1", messages[0].CallID())
+}
@@ -0,0 +1,287 @@
+package capture
+
+import (
+	"encoding/binary"
+	"net"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// The link types of the captures, see https://www.tcpdump.org/linktypes.html
+const (
+	linkTypeNull      = 0
+	linkTypeEthernet  = 1
+	linkTypeRaw       = 101
+	linkTypeLinuxSLL  = 113
+	linkTypeIPv4      = 228
+	linkTypeIPv6      = 229
+	linkTypeLinuxSLL2 = 276
+)
+
+const (
+	etherTypeIPv4 = 0x0800
+	etherTypeIPv6 = 0x86dd
+	etherTypeVLAN = 0x8100
+	etherTypeQinQ = 0x88a8
+
+	protocolTCP = 6
+	protocolUDP = 17
+)
+
+// Transport is the transport protocol of a packet
+type Transport string
+
+const (
+	// TransportUDP is the UDP protocol
+	TransportUDP Transport = "UDP"
+	// TransportTCP is the TCP protocol
+	TransportTCP Transport = "TCP"
+)
+
+// Endpoint is the address and port of a side of a packet
+type Endpoint struct {
+	IP   net.IP
+	Port uint16
+}
+
+func (e Endpoint) String() string {
+	return net.JoinHostPort(e.IP.String(), strconv.Itoa(int(e.Port)))
+}
+
+// Equal returns whether both endpoints are the same
+func (e Endpoint) Equal(other Endpoint) bool {
+	return e.Port == other.Port && e.IP.Equal(other.IP)
+}
+
+// Packet is a UDP datagram or a TCP segment of a capture
+type Packet struct {
+	Time      time.Time
+	Transport Transport
+	Src       Endpoint
+	Dst       Endpoint
+	// Seq is the sequence number of a TCP segment
+	Seq uint32
+	// SYN tells whether the TCP segment opens the connection
+	SYN     bool
+	Payload []byte
+}
+
+// fragmentKey identifies the fragments of an IPv4 datagram
+type fragmentKey struct {
+	src, dst string
+	id       uint16
+	protocol byte
+}
+
+// fragment is a part of an IPv4 datagram
+type fragment struct {
+	offset int
+	data   []byte
+}
+
+// decoder decodes the link layer frames of a capture into packets
+type decoder struct {
+	packets   []Packet
+	fragments map[fragmentKey][]fragment
+	// fragmentsLength are the lengths of the datagrams whose last fragment was seen
+	fragmentsLength map[fragmentKey]int
+}
+
+func newDecoder() *decoder {
+	return &decoder{
+		fragments:       map[fragmentKey][]fragment{},
+		fragmentsLength: map[fragmentKey]int{},
+	}
+}
+
+// decode adds the packet of the frame, the frames of other protocols are skipped
+func (d *decoder) decode(linkType uint32, frame []byte, timestamp time.Time) {
+	switch linkType {
+	case linkTypeEthernet:
+		if len(frame) < 14 {
+			return
+		}
+		etherType := binary.BigEndian.Uint16(frame[12:])
+		frame = frame[14:]
+		for (etherType == etherTypeVLAN || etherType == etherTypeQinQ) && len(frame) >= 4 {
+			etherType = binary.BigEndian.Uint16(frame[2:])
+			frame = frame[4:]
+		}
+		d.decodeEtherType(etherType, frame, timestamp)
+	case linkTypeLinuxSLL:
+		if len(frame) < 16 {
+			return
+		}
+		d.decodeEtherType(binary.BigEndian.Uint16(frame[14:]), frame[16:], timestamp)
+	case linkTypeLinuxSLL2:
+		if len(frame) < 20 {
+			return
+		}
+		d.decodeEtherType(binary.BigEndian.Uint16(frame), frame[20:], timestamp)
+	case linkTypeNull:
+		// The address family is in the byte order of the capturing host,
+		// the IPv6 families differ between systems
+		if len(frame) < 4 {
+			return
+		}
+		family := binary.LittleEndian.Uint32(frame)
+		if family > 0xffff {
+			family = binary.BigEndian.Uint32(frame)
+		}
+		switch family {
+		case 2:
+			d.decodeIPv4(frame[4:], timestamp)
+		case 10, 24, 28, 30:
+			d.decodeIPv6(frame[4:], timestamp)
+		}
+	case linkTypeRaw, linkTypeIPv4, linkTypeIPv6:
+		if len(frame) == 0 {
+			return
+		}
+		switch frame[0] >> 4 {
+		case 4:
+			d.decodeIPv4(frame, timestamp)
+		case 6:
+			d.decodeIPv6(frame, timestamp)
+		}
+	}
+}
+
+func (d *decoder) decodeEtherType(etherType uint16, data []byte, timestamp time.Time) {
+	switch etherType {
+	case etherTypeIPv4:
+		d.decodeIPv4(data, timestamp)
+	case etherTypeIPv6:
+		d.decodeIPv6(data, timestamp)
+	}
+}
+
+func (d *decoder) decodeIPv4(data []byte, timestamp time.Time) {
+	if len(data) < 20 || data[0]>>4 != 4 {
+		return
+	}
+	headerLength := int(data[0]&0x0f) * 4
+	totalLength := int(binary.BigEndian.Uint16(data[2:]))
+	if headerLength < 20 || totalLength < headerLength || totalLength > len(data) {
+		return
+	}
+
+	src, dst := net.IP(data[12:16]), net.IP(data[16:20])
+	protocol := data[9]
+	payload := data[headerLength:totalLength]
+
+	flags := binary.BigEndian.Uint16(data[6:])
+	moreFragments := flags&0x2000 != 0
+	offset := int(flags&0x1fff) * 8
+	if moreFragments || offset > 0 {
+		key := fragmentKey{src: src.String(), dst: dst.String(), id: binary.BigEndian.Uint16(data[4:]), protocol: protocol}
+		if payload = d.reassemble(key, offset, payload, moreFragments); payload == nil {
+			return
+		}
+	}
+
+	d.decodeTransport(protocol, src, dst, payload, timestamp)
+}
+
+// reassemble adds the fragment to the datagram,
+// and returns the datagram payload once all its fragments were seen
+func (d *decoder) reassemble(key fragmentKey, offset int, data []byte, moreFragments bool) []byte {
+	d.fragments[key] = append(d.fragments[key], fragment{offset: offset, data: append([]byte{}, data...)})
+	if !moreFragments {
+		d.fragmentsLength[key] = offset + len(data)
+	}
+
+	length, ok := d.fragmentsLength[key]
+	if !ok {
+		return nil
+	}
+
+	fragments := d.fragments[key]
+	sort.Slice(fragments, func(i, j int) bool { return fragments[i].offset < fragments[j].offset })
+	payload := make([]byte, length)
+	covered := 0
+	for _, f := range fragments {
+		if f.offset > covered {
+			return nil
+		}
+		if end := f.offset + len(f.data); end > covered && end <= length {
+			copy(payload[f.offset:], f.data)
+			covered = end
+		}
+	}
+	if covered < length {
+		return nil
+	}
+
+	delete(d.fragments, key)
+	delete(d.fragmentsLength, key)
+	return payload
+}
+
+func (d *decoder) decodeIPv6(data []byte, timestamp time.Time) {
+	if len(data) < 40 || data[0]>>4 != 6 {
+		return
+	}
+	payloadLength := int(binary.BigEndian.Uint16(data[4:]))
+	if 40+payloadLength > len(data) {
+		return
+	}
+
+	src, dst := net.IP(data[8:24]), net.IP(data[24:40])
+	next := data[6]
+	payload := data[40 : 40+payloadLength]
+
+	// The hop-by-hop, routing and destination options extension headers are skipped,
+	// the fragmented datagrams are not supported
+	for next == 0 || next == 43 || next == 60 {
+		if len(payload) < 8 {
+			return
+		}
+		length := (int(payload[1]) + 1) * 8
+		if length > len(payload) {
+			return
+		}
+		next = payload[0]
+		payload = payload[length:]
+	}
+
+	d.decodeTransport(next, src, dst, payload, timestamp)
+}
+
+func (d *decoder) decodeTransport(protocol byte, src, dst net.IP, data []byte, timestamp time.Time) {
+	switch protocol {
+	case protocolUDP:
+		if len(data) < 8 {
+			return
+		}
+		length := int(binary.BigEndian.Uint16(data[4:]))
+		if length < 8 || length > len(data) {
+			length = len(data)
+		}
+		d.packets = append(d.packets, Packet{
+			Time:      timestamp,
+			Transport: TransportUDP,
+			Src:       Endpoint{IP: src, Port: binary.BigEndian.Uint16(data)},
+			Dst:       Endpoint{IP: dst, Port: binary.BigEndian.Uint16(data[2:])},
+			Payload:   data[8:length],
+		})
+	case protocolTCP:
+		if len(data) < 20 {
+			return
+		}
+		offset := int(data[12]>>4) * 4
+		if offset < 20 || offset > len(data) {
+			return
+		}
+		d.packets = append(d.packets, Packet{
+			Time:      timestamp,
+			Transport: TransportTCP,
+			Src:       Endpoint{IP: src, Port: binary.BigEndian.Uint16(data)},
+			Dst:       Endpoint{IP: dst, Port: binary.BigEndian.Uint16(data[2:])},
+			Seq:       binary.BigEndian.Uint32(data[4:]),
+			SYN:       data[13]&0x02 != 0,
+			Payload:   data[offset:],
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package capture
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+const (
+	pcapMagicMicroseconds = 0xa1b2c3d4
+	pcapMagicNanoseconds  = 0xa1b23c4d
+
+	pcapngSectionHeader        = 0x0a0d0d0a
+	pcapngInterfaceDescription = 0x00000001
+	pcapngPacket               = 0x00000002
+	pcapngSimplePacket         = 0x00000003
+	pcapngEnhancedPacket       = 0x00000006
+	pcapngByteOrderMagic       = 0x1a2b3c4d
+
+	// pcapngTimestampResolution is the if_tsresol option of the interfaces
+	pcapngTimestampResolution = 9
+
+	// maxBlockSize bounds the records of corrupted files
+	maxBlockSize = 16 << 20
+)
+
+// ReadPackets returns the UDP datagrams and TCP segments of a pcap or pcapng capture,
+// in capture order. The other packets are left out.
+func ReadPackets(r io.Reader) ([]Packet, error) {
+	reader := bufio.NewReader(r)
+	magic, err := reader.Peek(4)
+	if err != nil {
+		return nil, fmt.Errorf("not a capture file: %v", err)
+	}
+
+	d := newDecoder()
+	if binary.LittleEndian.Uint32(magic) == pcapngSectionHeader {
+		err = readPcapng(reader, d)
+	} else {
+		err = readPcap(reader, d)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return d.packets, nil
+}
+
+func readPcap(r io.Reader, d *decoder) error {
+	header := make([]byte, 24)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return fmt.Errorf("invalid pcap header: %v", err)
+	}
+
+	var order binary.ByteOrder
+	var unit time.Duration
+	switch {
+	case binary.LittleEndian.Uint32(header) == pcapMagicMicroseconds:
+		order, unit = binary.LittleEndian, time.Microsecond
+	case binary.BigEndian.Uint32(header) == pcapMagicMicroseconds:
+		order, unit = binary.BigEndian, time.Microsecond
+	case binary.LittleEndian.Uint32(header) == pcapMagicNanoseconds:
+		order, unit = binary.LittleEndian, time.Nanosecond
+	case binary.BigEndian.Uint32(header) == pcapMagicNanoseconds:
+		order, unit = binary.BigEndian, time.Nanosecond
+	default:
+		return fmt.Errorf("not a pcap or pcapng file")
+	}
+	linkType := order.Uint32(header[20:])
+
+	record := make([]byte, 16)
+	for {
+		if _, err := io.ReadFull(r, record); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("truncated pcap record: %v", err)
+		}
+
+		length := order.Uint32(record[8:])
+		if length > maxBlockSize {
+			return fmt.Errorf("invalid pcap record length %d", length)
+		}
+		data := make([]byte, length)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return fmt.Errorf("truncated pcap record: %v", err)
+		}
+
+		timestamp := time.Unix(int64(order.Uint32(record)), int64(order.Uint32(record[4:]))*int64(unit))
+		d.decode(linkType, data, timestamp)
+	}
+}
+
+// pcapngInterface is an interface of a pcapng section
+type pcapngInterface struct {
+	linkType uint32
+	// resolution is the number of timestamp units per second
+	resolution uint64
+}
+
+func readPcapng(r io.Reader, d *decoder) error {
+	var order binary.ByteOrder = binary.LittleEndian
+	interfaces := []pcapngInterface{}
+
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, header); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("truncated pcapng block: %v", err)
+		}
+
+		blockType := order.Uint32(header)
+		if blockType == pcapngSectionHeader {
+			// The byte order of a section is given by its header
+			magic := make([]byte, 4)
+			if _, err := io.ReadFull(r, magic); err != nil {
+				return fmt.Errorf("truncated pcapng section header: %v", err)
+			}
+			if binary.BigEndian.Uint32(magic) == pcapngByteOrderMagic {
+				order = binary.BigEndian
+			} else if binary.LittleEndian.Uint32(magic) == pcapngByteOrderMagic {
+				order = binary.LittleEndian
+			} else {
+				return fmt.Errorf("invalid pcapng byte order magic")
+			}
+			interfaces = []pcapngInterface{}
+		}
+
+		// A block holds at least its type, its length twice,
+		// and a section header its magic, version and section length
+		length := order.Uint32(header[4:])
+		read, minLength := 8, uint32(12)
+		if blockType == pcapngSectionHeader {
+			read, minLength = 12, 28
+		}
+		if length < minLength || length > maxBlockSize || length%4 != 0 {
+			return fmt.Errorf("invalid pcapng block length %d", length)
+		}
+		body := make([]byte, int(length)-read)
+		if _, err := io.ReadFull(r, body); err != nil {
+			return fmt.Errorf("truncated pcapng block: %v", err)
+		}
+		// The body is followed by the repeated block length
+		body = body[:len(body)-4]
+
+		switch blockType {
+		case pcapngInterfaceDescription:
+			if len(body) < 8 {
+				return fmt.Errorf("invalid pcapng interface block")
+			}
+			interfaces = append(interfaces, pcapngInterface{
+				linkType:   uint32(order.Uint16(body)),
+				resolution: timestampResolution(order, body[8:]),
+			})
+		case pcapngEnhancedPacket, pcapngPacket:
+			if len(body) < 20 {
+				return fmt.Errorf("invalid pcapng packet block")
+			}
+			// The obsolete packet blocks have a 16 bits interface id followed by a drops count
+			id := order.Uint32(body)
+			if blockType == pcapngPacket {
+				id = uint32(order.Uint16(body))
+			}
+			if int(id) >= len(interfaces) {
+				return fmt.Errorf("pcapng packet of the undeclared interface %d", id)
+			}
+			length := order.Uint32(body[12:])
+			if int(length) > len(body)-20 {
+				return fmt.Errorf("invalid pcapng packet length %d", length)
+			}
+			units := uint64(order.Uint32(body[4:]))<<32 | uint64(order.Uint32(body[8:]))
+			d.decode(interfaces[id].linkType, body[20:20+length], timestampOf(units, interfaces[id].resolution))
+		case pcapngSimplePacket:
+			// Simple packets have no timestamp and belong to the first interface
+			if len(body) < 4 || len(interfaces) == 0 {
+				return fmt.Errorf("invalid pcapng simple packet block")
+			}
+			length := order.Uint32(body)
+			if int(length) > len(body)-4 {
+				length = uint32(len(body) - 4)
+			}
+			d.decode(interfaces[0].linkType, body[4:4+length], time.Time{})
+		}
+	}
+}
+
+// timestampResolution returns the number of timestamp units per second
+// set by the options of an interface, which defaults to microseconds
+func timestampResolution(order binary.ByteOrder, options []byte) uint64 {
+	for len(options) >= 4 {
+		code := order.Uint16(options)
+		length := int(order.Uint16(options[2:]))
+		if code == 0 || 4+length > len(options) {
+			break
+		}
+		if code == pcapngTimestampResolution && length >= 1 {
+			value := options[4]
+			exponent := float64(value & 0x7f)
+			if value&0x80 != 0 && exponent < 64 {
+				return uint64(math.Pow(2, exponent))
+			}
+			if value&0x80 == 0 && exponent < 20 {
+				return uint64(math.Pow(10, exponent))
+			}
+			break
+		}
+		// The option values are padded to 32 bits
+		next := 4 + (length+3)/4*4
+		if next > len(options) {
+			break
+		}
+		options = options[next:]
+	}
+	return 1000000
+}
+
+func timestampOf(units, resolution uint64) time.Time {
+	seconds := units / resolution
+	nanoseconds := (units % resolution) * uint64(time.Second) / resolution
+	return time.Unix(int64(seconds), int64(nanoseconds))
+}
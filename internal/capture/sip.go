@@ -0,0 +1,269 @@
+package capture
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// compactHeaders are the full names of the SIP compact header forms
+var compactHeaders = map[string]string{
+	"i": "call-id",
+	"m": "contact",
+	"e": "content-encoding",
+	"l": "content-length",
+	"c": "content-type",
+	"f": "from",
+	"s": "subject",
+	"k": "supported",
+	"t": "to",
+	"v": "via",
+}
+
+// Header is a header line of a SIP message
+type Header struct {
+	Name  string
+	Value string
+}
+
+// CanonicalName returns the lower case full name of the header
+func (h Header) CanonicalName() string {
+	name := strings.ToLower(h.Name)
+	if full, ok := compactHeaders[name]; ok {
+		return full
+	}
+	return name
+}
+
+// Message is a SIP message of a capture
+type Message struct {
+	Time      time.Time
+	Transport Transport
+	Src       Endpoint
+	Dst       Endpoint
+	// StartLine is the request or status line
+	StartLine string
+	Headers   []Header
+	Body      string
+}
+
+// IsRequest returns whether the message is a request
+func (m *Message) IsRequest() bool {
+	return !strings.HasPrefix(m.StartLine, "SIP/2.0 ")
+}
+
+// Method returns the method of a request, or the CSeq method of a response
+func (m *Message) Method() string {
+	if m.IsRequest() {
+		return strings.SplitN(m.StartLine, " ", 2)[0]
+	}
+	fields := strings.Fields(m.Header("cseq"))
+	if len(fields) < 2 {
+		return ""
+	}
+	return fields[1]
+}
+
+// StatusCode returns the status code of a response, or 0 for a request
+func (m *Message) StatusCode() int {
+	if m.IsRequest() {
+		return 0
+	}
+	fields := strings.Fields(m.StartLine)
+	if len(fields) < 2 {
+		return 0
+	}
+	code, _ := strconv.Atoi(fields[1])
+	return code
+}
+
+// Header returns the value of the first header of the name, compact forms included
+func (m *Message) Header(name string) string {
+	name = strings.ToLower(name)
+	for _, header := range m.Headers {
+		if header.CanonicalName() == name {
+			return header.Value
+		}
+	}
+	return ""
+}
+
+// CallID returns the Call-ID of the message
+func (m *Message) CallID() string {
+	return m.Header("call-id")
+}
+
+// Dialog holds the messages of a Call-ID
+type Dialog struct {
+	CallID   string
+	Messages []*Message
+}
+
+// Dialogs groups the messages by Call-ID, in the order the Call-IDs appear
+func Dialogs(messages []*Message) []*Dialog {
+	dialogs := []*Dialog{}
+	byCallID := map[string]*Dialog{}
+
+	for _, message := range messages {
+		callID := message.CallID()
+		dialog, ok := byCallID[callID]
+		if !ok {
+			dialog = &Dialog{CallID: callID}
+			byCallID[callID] = dialog
+			dialogs = append(dialogs, dialog)
+		}
+		dialog.Messages = append(dialog.Messages, message)
+	}
+
+	return dialogs
+}
+
+// tcpStream is the data sent by a side of a TCP connection
+type tcpStream struct {
+	next   uint32
+	buffer []byte
+}
+
+// Messages returns the SIP messages of the packets.
+// The TCP segments are reassembled in order, the retransmitted data is skipped.
+func Messages(packets []Packet) []*Message {
+	messages := []*Message{}
+	streams := map[string]*tcpStream{}
+
+	for _, packet := range packets {
+		if packet.Transport == TransportUDP {
+			if message, _ := parseMessage(packet.Payload, false); message != nil {
+				messages = append(messages, message.from(packet))
+			}
+			continue
+		}
+
+		key := packet.Src.String() + ">" + packet.Dst.String()
+		stream, ok := streams[key]
+		if packet.SYN {
+			streams[key] = &tcpStream{next: packet.Seq + 1}
+			continue
+		}
+		if !ok {
+			stream = &tcpStream{next: packet.Seq}
+			streams[key] = stream
+		}
+
+		payload := packet.Payload
+		// The sequence numbers wrap around, their difference tells their order
+		switch delta := int32(packet.Seq - stream.next); {
+		case delta < 0:
+			// Compared unsigned, as the opposite of math.MinInt32 overflows
+			repeated := stream.next - packet.Seq
+			if uint64(repeated) >= uint64(len(payload)) {
+				continue
+			}
+			payload = payload[repeated:]
+		case delta > 0:
+			// Data is missing, the messages are looked for in the following data
+			stream.buffer = nil
+		}
+		stream.next = packet.Seq + uint32(len(packet.Payload))
+		stream.buffer = append(stream.buffer, payload...)
+
+		for {
+			message, length := parseMessage(stream.buffer, true)
+			if length == 0 {
+				break
+			}
+			stream.buffer = stream.buffer[length:]
+			if message != nil {
+				messages = append(messages, message.from(packet))
+			}
+		}
+	}
+
+	return messages
+}
+
+func (m *Message) from(packet Packet) *Message {
+	m.Time = packet.Time
+	m.Transport = packet.Transport
+	m.Src = packet.Src
+	m.Dst = packet.Dst
+	return m
+}
+
+// parseMessage parses the SIP message at the start of the data and returns the length it used.
+// In a stream, the message body length is given by the Content-Length header, and a length of 0
+// is returned until the message is complete. Data which isn't a SIP message is skipped.
+func parseMessage(data []byte, stream bool) (*Message, int) {
+	// The keep-alive line breaks are skipped
+	start := len(data) - len(bytes.TrimLeft(data, "\r\n"))
+	if start == len(data) {
+		return nil, start
+	}
+
+	end := bytes.Index(data[start:], []byte("\r\n\r\n"))
+	separator := 4
+	if end < 0 {
+		end = bytes.Index(data[start:], []byte("\n\n"))
+		separator = 2
+	}
+	if end < 0 {
+		if !stream {
+			return nil, len(data)
+		}
+		// A stream buffer which doesn't start with a SIP message is dropped
+		if line := bytes.SplitN(data[start:], []byte("\n"), 2); len(line) == 2 && !isStartLine(string(line[0])) {
+			return nil, len(data)
+		}
+		return nil, 0
+	}
+
+	lines := strings.Split(strings.Replace(string(data[start:start+end]), "\r\n", "\n", -1), "\n")
+	if !isStartLine(lines[0]) {
+		return nil, len(data)
+	}
+
+	message := &Message{StartLine: strings.TrimSpace(lines[0])}
+	for _, line := range lines[1:] {
+		// The folded lines continue the previous header
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(message.Headers) > 0 {
+			message.Headers[len(message.Headers)-1].Value += " " + strings.TrimSpace(line)
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		message.Headers = append(message.Headers, Header{Name: strings.TrimSpace(parts[0]), Value: strings.TrimSpace(parts[1])})
+	}
+
+	bodyStart := start + end + separator
+	bodyLength := len(data) - bodyStart
+	if value := message.Header("content-length"); value != "" {
+		if length, err := strconv.Atoi(value); err == nil && length >= 0 {
+			if stream && bodyStart+length > len(data) {
+				return nil, 0
+			}
+			if length < bodyLength {
+				bodyLength = length
+			}
+		}
+	} else if stream {
+		bodyLength = 0
+	}
+	message.Body = string(data[bodyStart : bodyStart+bodyLength])
+
+	if !stream {
+		return message, len(data)
+	}
+	return message, bodyStart + bodyLength
+}
+
+// isStartLine returns whether the line is a SIP request or status line
+func isStartLine(line string) bool {
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "SIP/2.0 ") {
+		return true
+	}
+	fields := strings.Fields(line)
+	return len(fields) == 3 && fields[2] == "SIP/2.0"
+}
@@ -0,0 +1,321 @@
+package cmdline
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	"github.com/alexandrevilain/sipp-operator/internal/content"
+)
+
+// valueCounts are the number of values of the sipp flags which don't take exactly one
+var valueCounts = map[string]int{
+	"-aa":                0,
+	"-bg":                0,
+	"-h":                 0,
+	"-nd":                0,
+	"-no_rate_quit":      0,
+	"-nostdin":           0,
+	"-nr":                0,
+	"-pause_msg_ign":     0,
+	"-periodic_rtd":      0,
+	"-rtp_echo":          0,
+	"-skip_rlimit":       0,
+	"-timeout_error":     0,
+	"-trace_calldebug":   0,
+	"-trace_counts":      0,
+	"-trace_err":         0,
+	"-trace_error_codes": 0,
+	"-trace_logs":        0,
+	"-trace_msg":         0,
+	"-trace_rtt":         0,
+	"-trace_screen":      0,
+	"-trace_shortmsg":    0,
+	"-trace_stat":        0,
+	"-v":                 0,
+	"-infindex":          2,
+	"-key":               2,
+	"-set":               2,
+}
+
+// droppedFlags are the sipp flags which would break the runs managed by the operator
+var droppedFlags = map[string]string{
+	"-bg": "sipp must stay in the foreground of its container",
+	"-cp": "the operator sets the remote control port of the instances",
+}
+
+// transports are the sipp transport modes of the -t flag
+var transports = map[string]v1alpha1.Transport{
+	"u1": {Protocol: v1alpha1.ProtocolUDP, Socket: v1alpha1.SocketOne},
+	"un": {Protocol: v1alpha1.ProtocolUDP, Socket: v1alpha1.SocketOnePerCall},
+	"ui": {Protocol: v1alpha1.ProtocolUDP, Socket: v1alpha1.SocketOnePerIP},
+	"t1": {Protocol: v1alpha1.ProtocolTCP, Socket: v1alpha1.SocketOne},
+	"tn": {Protocol: v1alpha1.ProtocolTCP, Socket: v1alpha1.SocketOnePerCall},
+	"l1": {Protocol: v1alpha1.ProtocolTLS, Socket: v1alpha1.SocketOne},
+	"ln": {Protocol: v1alpha1.ProtocolTLS, Socket: v1alpha1.SocketOnePerCall},
+	"c1": {Protocol: v1alpha1.ProtocolUDP, Socket: v1alpha1.SocketOne},
+	"cn": {Protocol: v1alpha1.ProtocolUDP, Socket: v1alpha1.SocketOnePerCall},
+}
+
+// Result holds the resources equivalent to a sipp command line
+type Result struct {
+	// ScenarioFile is the path of the -sf scenario file, empty with a built-in scenario
+	ScenarioFile string
+	// Scenario holds the scenario file and the injection files
+	Scenario *v1alpha1.SippScenarioSpec
+	// Run holds the other flags, its scenarioRef is left to the caller
+	Run *v1alpha1.SippScenarioRunSpec
+	// Warnings report what couldn't be converted as is
+	Warnings []string
+}
+
+func (r *Result) warn(format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...))
+}
+
+// extra keeps the flag and its values as extra args of the run
+func (r *Result) extra(flag string, values []string, reason string) {
+	r.Run.ExtraArgs = append(r.Run.ExtraArgs, flag)
+	r.Run.ExtraArgs = append(r.Run.ExtraArgs, values...)
+	r.warn("%s kept in extraArgs: %s", strings.TrimSpace(flag+" "+strings.Join(values, " ")), reason)
+}
+
+// importer converts the flags one by one
+type importer struct {
+	result   *Result
+	readFile func(path string) ([]byte, error)
+	// injectFiles are the indexes of the injection files by path
+	injectFiles map[string]int
+	// indexes are the -infindex values waiting for all the injection files
+	indexes [][]string
+	// rtpPorts are the -min_rtp_port and -max_rtp_port values
+	rtpPorts map[string]string
+}
+
+// Import returns the resources equivalent to the arguments of a sipp command line,
+// without the sipp command itself. The files it references are read with readFile.
+// The flags the API doesn't model are kept as extra args of the run, with a warning.
+func Import(args []string, readFile func(path string) ([]byte, error)) (*Result, error) {
+	i := &importer{
+		result: &Result{
+			Scenario: &v1alpha1.SippScenarioSpec{},
+			Run:      &v1alpha1.SippScenarioRunSpec{},
+		},
+		readFile:    readFile,
+		injectFiles: map[string]int{},
+		rtpPorts:    map[string]string{},
+	}
+
+	for n := 0; n < len(args); n++ {
+		arg := args[n]
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			i.destination(arg)
+			continue
+		}
+
+		count, ok := valueCounts[arg]
+		if !ok {
+			count = 1
+		}
+		if n+count >= len(args) {
+			return nil, fmt.Errorf("%s expects %d values", arg, count)
+		}
+		values := args[n+1 : n+1+count]
+		n += count
+
+		if err := i.flag(arg, values); err != nil {
+			return nil, err
+		}
+	}
+
+	i.resolveIndexes()
+	i.resolveRTPPorts()
+
+	for _, arg := range args {
+		if strings.Contains(arg, "$") {
+			i.result.warn("%s references shell variables or substitutions, which are kept unexpanded", arg)
+		}
+	}
+
+	return i.result, nil
+}
+
+func (i *importer) destination(arg string) {
+	if i.result.Run.Destination != "" {
+		i.result.Run.ExtraArgs = append(i.result.Run.ExtraArgs, arg)
+		i.result.warn("%s kept in extraArgs: sipp takes a single remote host", arg)
+		return
+	}
+	i.result.Run.Destination = arg
+}
+
+// flag converts a flag and its values
+func (i *importer) flag(flag string, values []string) error {
+	result := i.result
+	spec := result.Run
+
+	if reason, ok := droppedFlags[flag]; ok {
+		result.warn("%s dropped: %s", strings.TrimSpace(flag+" "+strings.Join(values, " ")), reason)
+		return nil
+	}
+
+	switch flag {
+	case "-sf":
+		if result.ScenarioFile != "" {
+			return fmt.Errorf("-sf is set twice")
+		}
+		data, err := i.readFile(values[0])
+		if err != nil {
+			return err
+		}
+		result.ScenarioFile = values[0]
+		result.Scenario.ScenarioFileContent = string(data)
+	case "-sn":
+		result.extra(flag, values, "built-in scenarios have no SippScenario file")
+	case "-inf":
+		data, err := i.readFile(values[0])
+		if err != nil {
+			return err
+		}
+		file, err := content.ParseInjectionFile(string(data))
+		if err != nil {
+			return fmt.Errorf("%s: %v", values[0], err)
+		}
+		i.injectFiles[values[0]] = len(result.Scenario.InjectValues)
+		result.Scenario.InjectValues = append(result.Scenario.InjectValues, *file)
+	case "-infindex":
+		i.indexes = append(i.indexes, values)
+	case "-t":
+		transport, ok := transports[values[0]]
+		if !ok {
+			result.extra(flag, values, "the transport mode has no field")
+			return nil
+		}
+		if strings.HasPrefix(values[0], "c") {
+			compression := true
+			transport.Compression = &compression
+		}
+		spec.Transport = &transport
+	case "-m":
+		if values[0] != "1" {
+			result.extra(flag, values, "only -m 1 is modelled, by exitWhenCallsProcessed")
+			return nil
+		}
+		exit := true
+		spec.ExitWhenCallsProcessed = &exit
+	case "-d":
+		i.int32Field(&spec.CallLength, flag, values)
+	case "-r":
+		i.int32Field(&spec.Rate, flag, values)
+	case "-i":
+		spec.LocalIP = values[0]
+	case "-p":
+		i.int32Field(&spec.LocalPort, flag, values)
+	case "-mi":
+		spec.MediaIP = values[0]
+	case "-mp":
+		i.int32Field(&spec.MediaPort, flag, values)
+	case "-min_rtp_port", "-max_rtp_port":
+		i.rtpPorts[flag] = values[0]
+	case "-rtp_echo":
+		i.rtp().Echo = true
+	case "-rtp_payload":
+		i.int32Field(&i.rtp().Payload, flag, values)
+	case "-rtp_threadtasks":
+		i.int32Field(&i.rtp().ThreadTasks, flag, values)
+	case "-rtp_buffsize":
+		i.int32Field(&i.rtp().BufferSize, flag, values)
+	case "-trace_err":
+		i.traces().Errors = true
+	case "-trace_msg":
+		i.traces().Messages = true
+	case "-trace_stat":
+		i.traces().Statistics = true
+	case "-trace_rtt":
+		i.traces().ResponseTimes = true
+	case "-trace_screen":
+		i.traces().Screen = true
+	case "-trace_counts":
+		i.traces().Counts = true
+	case "-trace_logs":
+		i.traces().Logs = true
+	case "-ringbuffer_size":
+		size, err := strconv.ParseInt(values[0], 10, 64)
+		if err != nil || size <= 0 {
+			result.extra(flag, values, "the value is not a positive number of bytes")
+			return nil
+		}
+		i.traces().MaxFileSize = resource.NewQuantity(size, resource.BinarySI)
+	case "-ringbuffer_files":
+		i.int32Field(&i.traces().MaxFiles, flag, values)
+	default:
+		result.extra(flag, values, "the flag has no field")
+	}
+
+	return nil
+}
+
+// int32Field sets the field to the value of the flag,
+// or keeps the flag as extra args when it isn't an integer
+func (i *importer) int32Field(field **int32, flag string, values []string) {
+	value, err := strconv.ParseInt(values[0], 10, 32)
+	if err != nil {
+		i.result.extra(flag, values, "the field only takes integers")
+		return
+	}
+	v := int32(value)
+	*field = &v
+}
+
+func (i *importer) rtp() *v1alpha1.RTPOptions {
+	if i.result.Run.RTP == nil {
+		i.result.Run.RTP = &v1alpha1.RTPOptions{}
+	}
+	return i.result.Run.RTP
+}
+
+func (i *importer) traces() *v1alpha1.TraceOptions {
+	if i.result.Run.Traces == nil {
+		i.result.Run.Traces = &v1alpha1.TraceOptions{}
+	}
+	return i.result.Run.Traces
+}
+
+// resolveIndexes sets the -infindex fields on the injection files they reference
+func (i *importer) resolveIndexes() {
+	for _, values := range i.indexes {
+		index, ok := i.injectFiles[values[0]]
+		field, err := strconv.ParseInt(values[1], 10, 32)
+		if !ok || err != nil || field < 0 {
+			i.result.extra("-infindex", values, "it doesn't index an -inf file by a field number")
+			continue
+		}
+		f := int32(field)
+		i.result.Scenario.InjectValues[index].Index = &f
+	}
+}
+
+// resolveRTPPorts sets the RTP port range once both its bounds are known
+func (i *importer) resolveRTPPorts() {
+	min, hasMin := i.rtpPorts["-min_rtp_port"]
+	max, hasMax := i.rtpPorts["-max_rtp_port"]
+	if !hasMin && !hasMax {
+		return
+	}
+
+	minPort, minErr := strconv.ParseInt(min, 10, 32)
+	maxPort, maxErr := strconv.ParseInt(max, 10, 32)
+	if minErr != nil || maxErr != nil {
+		for _, flag := range []string{"-min_rtp_port", "-max_rtp_port"} {
+			if value, ok := i.rtpPorts[flag]; ok {
+				i.result.extra(flag, []string{value}, "rtpPortRange needs both bounds as integers")
+			}
+		}
+		return
+	}
+
+	i.result.Run.RTPPortRange = &v1alpha1.PortRange{Min: int32(minPort), Max: int32(maxPort)}
+}
@@ -0,0 +1,172 @@
+package cmdline_test
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	"github.com/alexandrevilain/sipp-operator/internal/cmdline"
+)
+
+var files = map[string]string{
+	"uac.xml":   "<scenario name=\"uac\"></scenario>",
+	"users.csv": "SEQUENTIAL\nalice;secret\nbob;secret\n",
+}
+
+func readFile(path string) ([]byte, error) {
+	content, ok := files[path]
+	if !ok {
+		return nil, fmt.Errorf("open %s: no such file or directory", path)
+	}
+	return []byte(content), nil
+}
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func TestImport(t *testing.T) {
+	result, err := cmdline.Import([]string{
+		"-sf", "uac.xml", "-inf", "users.csv", "-infindex", "users.csv", "0",
+		"-t", "tn", "-r", "50", "-rp", "1000", "-m", "1000", "-d", "2000",
+		"-i", "10.0.0.1", "-p", "5070", "-min_rtp_port", "16000", "-max_rtp_port", "16100",
+		"-rtp_echo", "-trace_err", "-trace_stat", "-ringbuffer_size", "1048576",
+		"-key", "domain", "example.com", "-bg", "-nostdin",
+		"host:5060",
+	}, readFile)
+	require.NoError(t, err)
+
+	assert.Equal(t, "uac.xml", result.ScenarioFile)
+	assert.Equal(t, files["uac.xml"], result.Scenario.ScenarioFileContent)
+	require.Len(t, result.Scenario.InjectValues, 1)
+	assert.Equal(t, v1alpha1.InjectionFile{
+		Mode:  v1alpha1.InjectionModeSequential,
+		Rows:  [][]string{{"alice", "secret"}, {"bob", "secret"}},
+		Index: int32Ptr(0),
+	}, result.Scenario.InjectValues[0])
+
+	size := resource.NewQuantity(1048576, resource.BinarySI)
+	assert.Equal(t, &v1alpha1.SippScenarioRunSpec{
+		Destination:  "host:5060",
+		Transport:    &v1alpha1.Transport{Protocol: v1alpha1.ProtocolTCP, Socket: v1alpha1.SocketOnePerCall},
+		Rate:         int32Ptr(50),
+		CallLength:   int32Ptr(2000),
+		LocalIP:      "10.0.0.1",
+		LocalPort:    int32Ptr(5070),
+		RTPPortRange: &v1alpha1.PortRange{Min: 16000, Max: 16100},
+		RTP:          &v1alpha1.RTPOptions{Echo: true},
+		Traces:       &v1alpha1.TraceOptions{Errors: true, Statistics: true, MaxFileSize: size},
+		ExtraArgs:    []string{"-rp", "1000", "-m", "1000", "-key", "domain", "example.com", "-nostdin"},
+	}, result.Run)
+
+	assert.Equal(t, []string{
+		"-rp 1000 kept in extraArgs: the flag has no field",
+		"-m 1000 kept in extraArgs: only -m 1 is modelled, by exitWhenCallsProcessed",
+		"-key domain example.com kept in extraArgs: the flag has no field",
+		"-bg dropped: sipp must stay in the foreground of its container",
+		"-nostdin kept in extraArgs: the flag has no field",
+	}, result.Warnings)
+}
+
+// sippOptions returns the sorted options of a sipp command line with their values,
+// the remote host being an option of its own
+func sippOptions(args []string) []string {
+	counts := map[string]int{"-rtp_echo": 0, "-trace_err": 0, "-trace_stat": 0, "-nostdin": 0, "-infindex": 2, "-key": 2}
+	options := []string{}
+	for n := 0; n < len(args); n++ {
+		count, ok := counts[args[n]]
+		if !ok {
+			count = 1
+		}
+		if !strings.HasPrefix(args[n], "-") {
+			count = 0
+		}
+		options = append(options, strings.Join(args[n:n+1+count], " "))
+		n += count
+	}
+	sort.Strings(options)
+	return options
+}
+
+func TestImportRoundTrip(t *testing.T) {
+	args := []string{
+		"-sf", "uac.xml", "-inf", "users.csv", "-infindex", "users.csv", "0",
+		"-t", "tn", "-r", "50", "-rp", "1000", "-m", "1000", "-d", "2000",
+		"-i", "10.0.0.1", "-p", "5070", "-min_rtp_port", "16000", "-max_rtp_port", "16100",
+		"-rtp_echo", "-trace_err", "-trace_stat", "-ringbuffer_size", "1048576",
+		"-key", "domain", "example.com", "-nostdin",
+		"host:5060",
+	}
+	result, err := cmdline.Import(args, readFile)
+	require.NoError(t, err)
+
+	// The sipp command line of the imported run holds the same options
+	run := &v1alpha1.SippScenarioRun{Spec: *result.Run}
+	scenario := &v1alpha1.SippScenario{Spec: *result.Scenario}
+	regenerated := append(run.ToSippArgs(), scenario.ToSippArgs(".")...)
+	regenerated = append(regenerated, run.Spec.ExtraArgs...)
+	for n, arg := range regenerated {
+		switch arg {
+		case "./" + v1alpha1.ScenarioFilename:
+			regenerated[n] = "uac.xml"
+		case "./" + scenario.GetInjectedValueFilename(0):
+			regenerated[n] = "users.csv"
+		}
+	}
+
+	assert.Equal(t, sippOptions(args), sippOptions(regenerated))
+}
+
+func TestImportConversions(t *testing.T) {
+	compression := true
+	tests := []struct {
+		Args     []string
+		Expected *v1alpha1.SippScenarioRunSpec
+		Warnings int
+	}{
+		{
+			Args:     []string{"-t", "c1", "-m", "1"},
+			Expected: &v1alpha1.SippScenarioRunSpec{Transport: &v1alpha1.Transport{Protocol: v1alpha1.ProtocolUDP, Socket: v1alpha1.SocketOne, Compression: &compression}, ExitWhenCallsProcessed: &compression},
+		},
+		{
+			Args:     []string{"-sn", "uas", "-r", "0.5", "-t", "s1"},
+			Expected: &v1alpha1.SippScenarioRunSpec{ExtraArgs: []string{"-sn", "uas", "-r", "0.5", "-t", "s1"}},
+			Warnings: 3,
+		},
+		{
+			Args:     []string{"-min_rtp_port", "16000", "-infindex", "other.csv", "1", "host", "other"},
+			Expected: &v1alpha1.SippScenarioRunSpec{Destination: "host", ExtraArgs: []string{"other", "-infindex", "other.csv", "1", "-min_rtp_port", "16000"}},
+			Warnings: 3,
+		},
+		{
+			Args:     []string{"-s", "$SERVICE", "$TARGET"},
+			Expected: &v1alpha1.SippScenarioRunSpec{Destination: "$TARGET", ExtraArgs: []string{"-s", "$SERVICE"}},
+			Warnings: 3,
+		},
+	}
+
+	for _, test := range tests {
+		result, err := cmdline.Import(test.Args, readFile)
+		require.NoError(t, err, test.Args)
+		assert.Equal(t, test.Expected, result.Run, test.Args)
+		assert.Len(t, result.Warnings, test.Warnings, test.Args)
+	}
+}
+
+func TestImportErrors(t *testing.T) {
+	for _, args := range [][]string{
+		{"-sf", "missing.xml"},
+		{"-sf", "uac.xml", "-sf", "uac.xml"},
+		{"-inf", "uac.xml"},
+		{"host", "-key", "name"},
+	} {
+		_, err := cmdline.Import(args, readFile)
+		assert.Error(t, err, args)
+	}
+}
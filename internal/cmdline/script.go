@@ -0,0 +1,127 @@
+package cmdline
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/alexandrevilain/sipp-operator/internal/shellwords"
+)
+
+// commandPrefixes are the commands which run the command following them
+var commandPrefixes = map[string]bool{
+	"command": true,
+	"exec":    true,
+	"nohup":   true,
+	"sudo":    true,
+	"time":    true,
+}
+
+// commandSeparators end a command of a shell line
+var commandSeparators = map[string]bool{
+	";":  true,
+	"&":  true,
+	"&&": true,
+	"||": true,
+	"|":  true,
+}
+
+// Invocation is a sipp command line of a shell script
+type Invocation struct {
+	// Line is the number of the line the command starts on, from 1
+	Line int
+	// Args are the arguments of sipp, without the sipp command itself
+	Args []string
+}
+
+// FindInvocations returns the sipp command lines of a shell script.
+// The lines continued with a backslash are joined, the shell variables are not expanded
+// and the redirections and the commands chained after sipp are left out.
+func FindInvocations(script string) ([]Invocation, error) {
+	invocations := []Invocation{}
+
+	lines := strings.Split(script, "\n")
+	for n := 0; n < len(lines); n++ {
+		start := n
+		line := strings.TrimRight(lines[n], "\r")
+		for strings.HasSuffix(line, "\\") && n+1 < len(lines) {
+			n++
+			line = strings.TrimSuffix(line, "\\") + " " + strings.TrimRight(lines[n], "\r")
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || !strings.Contains(trimmed, "sipp") {
+			continue
+		}
+
+		words, err := shellwords.Split(trimmed)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", start+1, err)
+		}
+
+		for _, args := range sippCommands(words) {
+			invocations = append(invocations, Invocation{Line: start + 1, Args: args})
+		}
+	}
+
+	return invocations, nil
+}
+
+// sippCommands returns the arguments of the sipp commands of a shell line
+func sippCommands(words []string) [][]string {
+	commands := [][]string{}
+	var args []string
+	inCommand := false
+	atStart := true
+
+	endCommand := func() {
+		if inCommand {
+			commands = append(commands, args)
+		}
+		inCommand, atStart, args = false, true, nil
+	}
+
+	for _, word := range words {
+		if commandSeparators[word] {
+			endCommand()
+			continue
+		}
+		// The separators may also stick to the last word of a command
+		trimmed := strings.TrimRight(word, ";&|")
+		separated := trimmed != word && !isRedirection(word)
+		if separated {
+			word = trimmed
+		}
+
+		switch {
+		case word == "":
+		case inCommand && isRedirection(word):
+			// The redirections end the arguments of sipp
+			commands = append(commands, args)
+			inCommand, atStart, args = false, false, nil
+		case inCommand:
+			args = append(args, word)
+		case atStart && (commandPrefixes[word] || isAssignment(word)):
+		case atStart && path.Base(word) == "sipp":
+			inCommand, args = true, []string{}
+		default:
+			atStart = false
+		}
+
+		if separated {
+			endCommand()
+		}
+	}
+
+	endCommand()
+	return commands
+}
+
+func isRedirection(word string) bool {
+	return strings.HasPrefix(strings.TrimLeft(word, "0123456789&"), ">") || strings.HasPrefix(word, "<")
+}
+
+func isAssignment(word string) bool {
+	equal := strings.Index(word, "=")
+	return equal > 0 && !strings.HasPrefix(word, "-")
+}
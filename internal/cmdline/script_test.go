@@ -0,0 +1,32 @@
+package cmdline_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexandrevilain/sipp-operator/internal/cmdline"
+)
+
+func TestFindInvocations(t *testing.T) {
+	script := `#!/bin/sh
+# sipp -sf commented.xml
+TARGET=10.0.0.1:5060
+cd /opt/sipp && ./sipp -sf uac.xml \
+  -inf users.csv \
+  -r 50 $TARGET > uac.log 2>&1 &
+SIPP_OPTS=-nd exec /usr/bin/sipp -sn uas -p 5070; echo done
+echo "sipp is done" | mail -s sipp root
+`
+
+	invocations, err := cmdline.FindInvocations(script)
+	require.NoError(t, err)
+	assert.Equal(t, []cmdline.Invocation{
+		{Line: 4, Args: []string{"-sf", "uac.xml", "-inf", "users.csv", "-r", "50", "$TARGET"}},
+		{Line: 7, Args: []string{"-sn", "uas", "-p", "5070"}},
+	}, invocations)
+
+	_, err = cmdline.FindInvocations("sipp -sf 'uac.xml")
+	assert.Error(t, err)
+}
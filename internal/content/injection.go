@@ -0,0 +1,26 @@
+package content
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+)
+
+// ParseInjectionFile returns the injection file of the content of a sipp CSV file:
+// a line with the mode, followed by the semicolon separated rows
+func ParseInjectionFile(content string) (*v1alpha1.InjectionFile, error) {
+	file, header := v1alpha1.ParseInjectionContent(content)
+	if header == "" {
+		first := strings.TrimSpace(strings.SplitN(strings.TrimLeft(content, "\r\n"), "\n", 2)[0])
+		return nil, fmt.Errorf("invalid mode %q, the first line must be SEQUENTIAL, RANDOM or USER", first)
+	}
+	if err := v1alpha1.CheckInjectionHeader(header); err != nil {
+		return nil, err
+	}
+	if len(file.Rows) == 0 {
+		return nil, fmt.Errorf("no rows")
+	}
+
+	return file, nil
+}
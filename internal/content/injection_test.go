@@ -0,0 +1,27 @@
+package content_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	"github.com/alexandrevilain/sipp-operator/internal/content"
+)
+
+func TestParseInjectionFile(t *testing.T) {
+	file, err := content.ParseInjectionFile("RANDOM\r\n# users\r\nalice;secret;\r\nbob;password\r\n")
+	assert.NoError(t, err)
+	assert.Equal(t, v1alpha1.InjectionModeRandom, file.Mode)
+	assert.Equal(t, [][]string{{"alice", "secret"}, {"bob", "password"}}, file.Rows)
+	assert.Equal(t, "RANDOM\nalice;secret\nbob;password\n", file.Render())
+
+	_, err = content.ParseInjectionFile("SEQUENTIAL,PRINTF=10\nuser%04d\n")
+	assert.Error(t, err)
+
+	_, err = content.ParseInjectionFile("alice;secret\n")
+	assert.Error(t, err)
+
+	_, err = content.ParseInjectionFile("USER\n")
+	assert.Error(t, err)
+}
@@ -0,0 +1,219 @@
+package content
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+)
+
+const (
+	// maxURLContentSize is the maximum size of a file downloaded from an URL
+	maxURLContentSize  = 64 << 20
+	defaultHTTPTimeout = 30 * time.Second
+	// maxURLCacheSize is the maximum size of the downloaded content kept in memory
+	maxURLCacheSize = 256 << 20
+)
+
+// Digest returns the sha256 digest of the content, formatted as sha256:<hex digest>
+func Digest(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// Resolver fetches the scenario files stored outside of a SippScenario
+type Resolver struct {
+	Client     client.Client
+	HTTPClient *http.Client
+
+	// The content downloaded from URLs never changes once its checksum is verified,
+	// it is cached by URL and checksum instead of being downloaded on every reconcile
+	cacheLock sync.Mutex
+	cache     map[v1alpha1.URLSource]string
+	cacheSize int
+}
+
+// NewResolver returns a resolver reading resources with the provided client
+func NewResolver(c client.Client) *Resolver {
+	return &Resolver{
+		Client:     c,
+		HTTPClient: &http.Client{Timeout: defaultHTTPTimeout},
+	}
+}
+
+// SecretFiles returns the names of the scenario files loaded from Secrets,
+// which must not be stored in ConfigMaps once resolved
+func SecretFiles(scenario *v1alpha1.SippScenario) map[string]bool {
+	files := map[string]bool{}
+
+	if from := scenario.Spec.ScenarioFrom; from != nil && from.SecretKeyRef != nil {
+		files[v1alpha1.ScenarioFilename] = true
+	}
+
+	for i, values := range scenario.Spec.InjectValues {
+		if values.From != nil && values.From.SecretKeyRef != nil {
+			files[scenario.GetInjectedValueFilename(i)] = true
+		}
+	}
+
+	return files
+}
+
+// Resolve returns a copy of the scenario where the ScenarioFrom and InjectValues
+// sources are replaced by their content in ScenarioFileContent and the InjectValues rows
+func (r *Resolver) Resolve(ctx context.Context, scenario *v1alpha1.SippScenario) (*v1alpha1.SippScenario, error) {
+	resolved := scenario.DeepCopy()
+
+	if scenario.Spec.ScenarioFrom != nil {
+		content, err := r.Fetch(ctx, scenario.Namespace, scenario.Spec.ScenarioFrom)
+		if err != nil {
+			return nil, errors.Wrap(err, "can't resolve scenarioFrom")
+		}
+		resolved.Spec.ScenarioFileContent = string(content)
+		resolved.Spec.ScenarioFrom = nil
+	}
+
+	for i := range resolved.Spec.InjectValues {
+		values := &resolved.Spec.InjectValues[i]
+		if values.From == nil {
+			continue
+		}
+
+		content, err := r.Fetch(ctx, scenario.Namespace, values.From)
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't resolve injectValues[%d].from", i)
+		}
+		// Files written for sipp start with the mode header,
+		// the mode of the spec wins over it
+		file, header := v1alpha1.ParseInjectionContent(string(content))
+		if err := v1alpha1.CheckInjectionHeader(header); err != nil {
+			return nil, errors.Wrapf(err, "can't resolve injectValues[%d].from", i)
+		}
+		values.Rows = file.Rows
+		if values.Mode == "" {
+			values.Mode = file.Mode
+		}
+		values.From = nil
+	}
+
+	return resolved, nil
+}
+
+// Fetch returns the content selected by the source
+func (r *Resolver) Fetch(ctx context.Context, namespace string, source *v1alpha1.ContentSource) ([]byte, error) {
+	switch {
+	case source.ConfigMapKeyRef != nil:
+		return r.fetchConfigMapKey(ctx, namespace, source.ConfigMapKeyRef)
+	case source.SecretKeyRef != nil:
+		return r.fetchSecretKey(ctx, namespace, source.SecretKeyRef)
+	case source.URL != nil:
+		return r.fetchURL(ctx, source.URL)
+	}
+
+	return nil, errors.New("no content source set")
+}
+
+func (r *Resolver) fetchConfigMapKey(ctx context.Context, namespace string, selector *corev1.ConfigMapKeySelector) ([]byte, error) {
+	configMap := &corev1.ConfigMap{}
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: selector.Name}, configMap)
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't get ConfigMap %s", selector.Name)
+	}
+
+	if value, ok := configMap.Data[selector.Key]; ok {
+		return []byte(value), nil
+	}
+
+	if value, ok := configMap.BinaryData[selector.Key]; ok {
+		return value, nil
+	}
+
+	return nil, fmt.Errorf("key %s not found in ConfigMap %s", selector.Key, selector.Name)
+}
+
+func (r *Resolver) fetchSecretKey(ctx context.Context, namespace string, selector *corev1.SecretKeySelector) ([]byte, error) {
+	secret := &corev1.Secret{}
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: selector.Name}, secret)
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't get Secret %s", selector.Name)
+	}
+
+	value, ok := secret.Data[selector.Key]
+	if !ok {
+		return nil, fmt.Errorf("key %s not found in Secret %s", selector.Key, selector.Name)
+	}
+
+	return value, nil
+}
+
+func (r *Resolver) fetchURL(ctx context.Context, source *v1alpha1.URLSource) ([]byte, error) {
+	if body, ok := r.cached(source); ok {
+		return body, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, source.URL, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid URL %s", source.URL)
+	}
+
+	resp, err := r.HTTPClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't download %s", source.URL)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("can't download %s: unexpected status %s", source.URL, resp.Status)
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxURLContentSize+1))
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't download %s", source.URL)
+	}
+
+	if len(body) > maxURLContentSize {
+		return nil, fmt.Errorf("can't download %s: content is larger than %d bytes", source.URL, maxURLContentSize)
+	}
+
+	if digest := Digest(body); digest != source.Checksum {
+		return nil, fmt.Errorf("checksum mismatch for %s: expected %s, got %s", source.URL, source.Checksum, digest)
+	}
+
+	r.store(source, body)
+	return body, nil
+}
+
+// cached returns the content downloaded for the source
+func (r *Resolver) cached(source *v1alpha1.URLSource) ([]byte, bool) {
+	r.cacheLock.Lock()
+	defer r.cacheLock.Unlock()
+
+	body, ok := r.cache[*source]
+	return []byte(body), ok
+}
+
+// store caches the content downloaded for the source,
+// the cache is emptied when it grows too large
+func (r *Resolver) store(source *v1alpha1.URLSource, body []byte) {
+	r.cacheLock.Lock()
+	defer r.cacheLock.Unlock()
+
+	if r.cache == nil || r.cacheSize+len(body) > maxURLCacheSize {
+		r.cache = map[v1alpha1.URLSource]string{}
+		r.cacheSize = 0
+	}
+	r.cache[*source] = string(body)
+	r.cacheSize += len(body)
+}
@@ -0,0 +1,227 @@
+package content_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	"github.com/alexandrevilain/sipp-operator/internal/content"
+)
+
+func TestResolve(t *testing.T) {
+	scenarioXML := `<scenario name="Basic Sipstone UAC"></scenario>`
+	users := "# user;password\nSarah;sipphone32\r\n\nBob;sipphone42;\n"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(users))
+	}))
+	defer server.Close()
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "scenarios", Namespace: "default"},
+		Data:       map[string]string{"uac.xml": scenarioXML},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "credentials", Namespace: "default"},
+		Data:       map[string][]byte{"auth.csv": []byte("alice;secret\n")},
+	}
+
+	resolver := content.NewResolver(fake.NewFakeClient(configMap, secret))
+
+	scenario := &v1alpha1.SippScenario{
+		ObjectMeta: metav1.ObjectMeta{Name: "uac", Namespace: "default"},
+		Spec: v1alpha1.SippScenarioSpec{
+			ScenarioFrom: &v1alpha1.ContentSource{
+				ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "scenarios"},
+					Key:                  "uac.xml",
+				},
+			},
+			InjectValues: []v1alpha1.InjectionFile{
+				{Mode: v1alpha1.InjectionModeRandom, Rows: [][]string{{"Bob", "sipphone42"}}},
+				{
+					From: &v1alpha1.ContentSource{
+						SecretKeyRef: &corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "credentials"},
+							Key:                  "auth.csv",
+						},
+					},
+				},
+				{
+					From: &v1alpha1.ContentSource{
+						URL: &v1alpha1.URLSource{
+							URL:      server.URL + "/users.csv",
+							Checksum: content.Digest([]byte(users)),
+						},
+					},
+					Index: pointer.Int32Ptr(0),
+				},
+			},
+		},
+	}
+
+	resolved, err := resolver.Resolve(context.Background(), scenario)
+	assert.NoError(t, err)
+	assert.Equal(t, scenarioXML, resolved.Spec.ScenarioFileContent)
+	assert.Nil(t, resolved.Spec.ScenarioFrom)
+	assert.Equal(t, []v1alpha1.InjectionFile{
+		{Mode: v1alpha1.InjectionModeRandom, Rows: [][]string{{"Bob", "sipphone42"}}},
+		{Rows: [][]string{{"alice", "secret"}}},
+		{Rows: [][]string{{"Sarah", "sipphone32"}, {"Bob", "sipphone42"}}, Index: pointer.Int32Ptr(0)},
+	}, resolved.Spec.InjectValues)
+	assert.NoError(t, resolved.Validate())
+
+	// The resolved scenario passes the same args to sipp
+	assert.Equal(t, scenario.ToSippArgs("/etc/test"), resolved.ToSippArgs("/etc/test"))
+
+	// The original scenario is left untouched
+	assert.NotNil(t, scenario.Spec.ScenarioFrom)
+	assert.NotNil(t, scenario.Spec.InjectValues[1].From)
+}
+
+func TestFetchURLChecksumMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("tampered"))
+	}))
+	defer server.Close()
+
+	resolver := content.NewResolver(fake.NewFakeClient())
+
+	_, err := resolver.Fetch(context.Background(), "default", &v1alpha1.ContentSource{
+		URL: &v1alpha1.URLSource{
+			URL:      server.URL,
+			Checksum: content.Digest([]byte("expected")),
+		},
+	})
+	assert.Error(t, err)
+}
+
+func TestFetchMissingKey(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "scenarios", Namespace: "default"},
+		BinaryData: map[string][]byte{"uac.xml": []byte("<scenario/>")},
+	}
+
+	resolver := content.NewResolver(fake.NewFakeClient(configMap))
+
+	result, err := resolver.Fetch(context.Background(), "default", &v1alpha1.ContentSource{
+		ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "scenarios"},
+			Key:                  "uac.xml",
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "<scenario/>", string(result))
+
+	_, err = resolver.Fetch(context.Background(), "default", &v1alpha1.ContentSource{
+		ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "scenarios"},
+			Key:                  "uas.xml",
+		},
+	})
+	assert.Error(t, err)
+}
+
+func TestFetchURLCache(t *testing.T) {
+	downloads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads++
+		_, _ = w.Write([]byte("Sarah;sipphone32\n"))
+	}))
+	defer server.Close()
+
+	resolver := content.NewResolver(fake.NewFakeClient())
+	source := &v1alpha1.ContentSource{
+		URL: &v1alpha1.URLSource{
+			URL:      server.URL,
+			Checksum: content.Digest([]byte("Sarah;sipphone32\n")),
+		},
+	}
+
+	for i := 0; i < 3; i++ {
+		result, err := resolver.Fetch(context.Background(), "default", source)
+		assert.NoError(t, err)
+		assert.Equal(t, "Sarah;sipphone32\n", string(result))
+	}
+	assert.Equal(t, 1, downloads)
+
+	// Another checksum is downloaded again, and rejected
+	source.URL.Checksum = content.Digest([]byte("Bob;sipphone42\n"))
+	_, err := resolver.Fetch(context.Background(), "default", source)
+	assert.Error(t, err)
+	assert.Equal(t, 2, downloads)
+}
+
+func TestSecretFiles(t *testing.T) {
+	secretSource := &v1alpha1.ContentSource{
+		SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "credentials"},
+			Key:                  "auth.csv",
+		},
+	}
+	scenario := &v1alpha1.SippScenario{
+		Spec: v1alpha1.SippScenarioSpec{
+			ScenarioFileContent: `<scenario name="Basic Sipstone UAC"></scenario>`,
+			InjectValues: []v1alpha1.InjectionFile{
+				{Rows: [][]string{{"Sarah"}}},
+				{From: secretSource},
+			},
+		},
+	}
+	assert.Equal(t, map[string]bool{"values_1.csv": true}, content.SecretFiles(scenario))
+
+	scenario.Spec.ScenarioFileContent = ""
+	scenario.Spec.ScenarioFrom = secretSource
+	assert.Equal(t, map[string]bool{"scenario.xml": true, "values_1.csv": true}, content.SecretFiles(scenario))
+}
+
+func TestResolveInjectionFile(t *testing.T) {
+	// The example of the sipp documentation
+	users := "SEQUENTIAL\n# This line will be ignored\nSarah;sipphone32\nBob;sipphone12\n#This line too\nFred;sipphone94\n"
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "users", Namespace: "default"},
+		Data:       map[string]string{"users.csv": users, "random.csv": "RANDOM\nSarah;sipphone32\n", "printf.csv": "SEQUENTIAL,PRINTF=10\nuser%04d\n"},
+	}
+	resolver := content.NewResolver(fake.NewFakeClient(configMap))
+
+	from := func(key string) *v1alpha1.ContentSource {
+		return &v1alpha1.ContentSource{
+			ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "users"},
+				Key:                  key,
+			},
+		}
+	}
+	scenario := &v1alpha1.SippScenario{
+		ObjectMeta: metav1.ObjectMeta{Name: "uac", Namespace: "default"},
+		Spec: v1alpha1.SippScenarioSpec{
+			ScenarioFileContent: `<scenario name="Basic Sipstone UAC"></scenario>`,
+			InjectValues: []v1alpha1.InjectionFile{
+				{From: from("users.csv")},
+				{From: from("random.csv")},
+				{Mode: v1alpha1.InjectionModeUser, From: from("random.csv")},
+			},
+		},
+	}
+
+	resolved, err := resolver.Resolve(context.Background(), scenario)
+	assert.NoError(t, err)
+	assert.Equal(t, []v1alpha1.InjectionFile{
+		{Mode: v1alpha1.InjectionModeSequential, Rows: [][]string{{"Sarah", "sipphone32"}, {"Bob", "sipphone12"}, {"Fred", "sipphone94"}}},
+		{Mode: v1alpha1.InjectionModeRandom, Rows: [][]string{{"Sarah", "sipphone32"}}},
+		{Mode: v1alpha1.InjectionModeUser, Rows: [][]string{{"Sarah", "sipphone32"}}},
+	}, resolved.Spec.InjectValues)
+	assert.Equal(t, "SEQUENTIAL\nSarah;sipphone32\nBob;sipphone12\nFred;sipphone94\n", resolved.Spec.InjectValues[0].Render())
+
+	scenario.Spec.InjectValues = []v1alpha1.InjectionFile{{From: from("printf.csv")}}
+	_, err = resolver.Resolve(context.Background(), scenario)
+	assert.Error(t, err)
+}
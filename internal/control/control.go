@@ -0,0 +1,78 @@
+package control
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+)
+
+// DefaultPort is the UDP port sipp listens on for remote control commands
+const DefaultPort = 8888
+
+// PortName is the name of the remote control port of the sipp containers
+const PortName = "sipp-control"
+
+// Command is a sipp remote control command, sent like a key pressed on its console
+type Command string
+
+const (
+	// Pause pauses the traffic, or resumes it when it is paused
+	Pause Command = "p"
+	// SoftQuit stops placing new calls and quits once the calls in progress are over
+	SoftQuit Command = "q"
+	// Quit quits immediately, aborting the calls in progress
+	Quit Command = "Q"
+)
+
+// Sender sends remote control commands to sipp instances
+type Sender interface {
+	Send(ctx context.Context, address string, command Command) error
+}
+
+// UDPSender sends the commands to the remote control port of the instances.
+// Like sipp itself, it doesn't wait for any acknowledgement.
+type UDPSender struct {
+	Timeout time.Duration
+}
+
+// NewUDPSender returns a sender to the remote control ports
+func NewUDPSender() *UDPSender {
+	return &UDPSender{
+		Timeout: 5 * time.Second,
+	}
+}
+
+// Address returns the address of the remote control port on host
+func Address(host string, port int32) string {
+	return net.JoinHostPort(host, strconv.Itoa(int(port)))
+}
+
+// Send sends the command to the instance listening on the host:port address
+func (s *UDPSender) Send(ctx context.Context, address string, command Command) error {
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "udp", address)
+	if err != nil {
+		return fmt.Errorf("can't reach sipp remote control on %s: %v", address, err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetWriteDeadline(deadline); err != nil {
+			return err
+		}
+	}
+
+	if _, err := conn.Write([]byte(command)); err != nil {
+		return fmt.Errorf("can't send %q to sipp remote control on %s: %v", command, address, err)
+	}
+
+	return nil
+}
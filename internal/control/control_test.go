@@ -0,0 +1,28 @@
+package control_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/alexandrevilain/sipp-operator/internal/control"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUDPSender(t *testing.T) {
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	sender := control.NewUDPSender()
+	port := int32(listener.LocalAddr().(*net.UDPAddr).Port)
+
+	assert.NoError(t, sender.Send(context.Background(), control.Address("127.0.0.1", port), control.SoftQuit))
+
+	assert.NoError(t, listener.SetReadDeadline(time.Now().Add(5*time.Second)))
+	buf := make([]byte, 16)
+	n, _, err := listener.ReadFrom(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "q", string(buf[:n]))
+}
@@ -0,0 +1,225 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+)
+
+// statisticsFileRegexp matches the files written by -trace_stat,
+// named <scenario>_<pid>_.csv beside the other trace files
+var statisticsFileRegexp = regexp.MustCompile(`_[0-9]+_\.csv$`)
+
+// responseTimeBucketRegexp matches the columns of the first response time repartition,
+// such as ResponseTimeRepartition1_<10 or ResponseTimeRepartition1_>=200
+var responseTimeBucketRegexp = regexp.MustCompile(`^ResponseTimeRepartition1_(<|>=)([0-9]+)$`)
+
+// Bucket counts the response times below its bound, or above it for the overflow bucket
+type Bucket struct {
+	Bound    time.Duration
+	Overflow bool
+	Count    int64
+}
+
+// Statistics are the cumulative statistics of a sipp instance
+type Statistics struct {
+	SuccessfulCalls int64
+	FailedCalls     int64
+	CallRate        float64
+	ResponseTimes   []Bucket
+}
+
+// ParseStatistics reads the last line of a sipp statistics file,
+// which holds the cumulative values of the whole execution
+func ParseStatistics(r io.Reader) (*Statistics, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var header, last string
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if header == "" {
+			header = line
+			continue
+		}
+		last = line
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if last == "" {
+		return nil, fmt.Errorf("no statistics recorded")
+	}
+
+	columns := strings.Split(header, ";")
+	values := strings.Split(last, ";")
+	value := func(column string) (string, bool) {
+		for i := range columns {
+			if columns[i] == column && i < len(values) {
+				return strings.TrimSpace(values[i]), true
+			}
+		}
+		return "", false
+	}
+	integer := func(column string) (int64, error) {
+		raw, ok := value(column)
+		if !ok {
+			return 0, fmt.Errorf("missing column %s", column)
+		}
+		return strconv.ParseInt(raw, 10, 64)
+	}
+
+	stats := &Statistics{}
+	var err error
+	if stats.SuccessfulCalls, err = integer("SuccessfulCall(C)"); err != nil {
+		return nil, err
+	}
+	if stats.FailedCalls, err = integer("FailedCall(C)"); err != nil {
+		return nil, err
+	}
+	if raw, ok := value("CallRate(C)"); ok {
+		if stats.CallRate, err = strconv.ParseFloat(raw, 64); err != nil {
+			return nil, fmt.Errorf("invalid CallRate(C): %v", err)
+		}
+	}
+
+	for i, column := range columns {
+		match := responseTimeBucketRegexp.FindStringSubmatch(column)
+		if match == nil || i >= len(values) {
+			continue
+		}
+		bound, _ := strconv.Atoi(match[2])
+		count, err := strconv.ParseInt(strings.TrimSpace(values[i]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", column, err)
+		}
+		stats.ResponseTimes = append(stats.ResponseTimes, Bucket{
+			Bound:    time.Duration(bound) * time.Millisecond,
+			Overflow: match[1] == ">=",
+			Count:    count,
+		})
+	}
+
+	return stats, nil
+}
+
+// Percentile returns the bound of the bucket holding the percentile of the response times,
+// the overflow bucket gives its lower bound. It returns false when no response time was measured.
+func Percentile(buckets []Bucket, percentile float64) (time.Duration, bool) {
+	total := int64(0)
+	for _, bucket := range buckets {
+		total += bucket.Count
+	}
+	if total == 0 {
+		return 0, false
+	}
+
+	rank := percentile / 100 * float64(total)
+	cumulative := int64(0)
+	for _, bucket := range buckets {
+		cumulative += bucket.Count
+		if float64(cumulative) >= rank {
+			return bucket.Bound, true
+		}
+	}
+	return buckets[len(buckets)-1].Bound, true
+}
+
+// Aggregate returns the metrics of a run from the statistics of its sipp instances
+func Aggregate(stats []*Statistics) *v1alpha1.RunMetrics {
+	metrics := &v1alpha1.RunMetrics{}
+	callRate := 0.0
+	buckets := map[Bucket]int64{}
+
+	for _, stat := range stats {
+		metrics.SuccessfulCalls += stat.SuccessfulCalls
+		metrics.FailedCalls += stat.FailedCalls
+		callRate += stat.CallRate
+		for _, bucket := range stat.ResponseTimes {
+			key := bucket
+			key.Count = 0
+			buckets[key] += bucket.Count
+		}
+	}
+	metrics.CallRate = strconv.FormatFloat(callRate, 'f', 2, 64)
+
+	merged := make([]Bucket, 0, len(buckets))
+	for bucket, count := range buckets {
+		bucket.Count = count
+		merged = append(merged, bucket)
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		if merged[i].Overflow != merged[j].Overflow {
+			return !merged[i].Overflow
+		}
+		return merged[i].Bound < merged[j].Bound
+	})
+
+	percentile := func(p float64) *metav1.Duration {
+		if value, ok := Percentile(merged, p); ok {
+			return &metav1.Duration{Duration: value}
+		}
+		return nil
+	}
+	metrics.ResponseTimeP50 = percentile(50)
+	metrics.ResponseTimeP90 = percentile(90)
+	metrics.ResponseTimeP99 = percentile(99)
+
+	return metrics
+}
+
+// CollectDir returns the metrics of a run from the statistics files
+// in the directories of the given pods
+func CollectDir(dir string, pods []string) (*v1alpha1.RunMetrics, error) {
+	stats := []*Statistics{}
+
+	for _, pod := range pods {
+		files, err := ioutil.ReadDir(filepath.Join(dir, pod))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+			if file.IsDir() || !statisticsFileRegexp.MatchString(file.Name()) {
+				continue
+			}
+
+			stat, err := parseFile(filepath.Join(dir, pod, file.Name()))
+			if err != nil {
+				return nil, fmt.Errorf("%s/%s: %v", pod, file.Name(), err)
+			}
+			stats = append(stats, stat)
+		}
+	}
+
+	if len(stats) == 0 {
+		return nil, fmt.Errorf("no sipp statistics file found")
+	}
+
+	return Aggregate(stats), nil
+}
+
+func parseFile(path string) (*Statistics, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ParseStatistics(file)
+}
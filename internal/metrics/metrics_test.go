@@ -0,0 +1,87 @@
+package metrics_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexandrevilain/sipp-operator/internal/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseStatistics(t *testing.T) {
+	file, err := os.Open(filepath.Join("testdata", "uac_12_.csv"))
+	assert.NoError(t, err)
+	defer file.Close()
+
+	stats, err := metrics.ParseStatistics(file)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1188), stats.SuccessfulCalls)
+	assert.Equal(t, int64(11), stats.FailedCalls)
+	assert.Equal(t, 9.99, stats.CallRate)
+	assert.Equal(t, []metrics.Bucket{
+		{Bound: 10 * time.Millisecond, Count: 600},
+		{Bound: 20 * time.Millisecond, Count: 400},
+		{Bound: 50 * time.Millisecond, Count: 150},
+		{Bound: 100 * time.Millisecond, Count: 35},
+		{Bound: 100 * time.Millisecond, Overflow: true, Count: 5},
+	}, stats.ResponseTimes)
+
+	_, err = metrics.ParseStatistics(strings.NewReader("SuccessfulCall(C);FailedCall(C);\n"))
+	assert.Error(t, err)
+}
+
+func TestPercentile(t *testing.T) {
+	buckets := []metrics.Bucket{
+		{Bound: 10 * time.Millisecond, Count: 50},
+		{Bound: 20 * time.Millisecond, Count: 40},
+		{Bound: 20 * time.Millisecond, Overflow: true, Count: 10},
+	}
+
+	tests := []struct {
+		Percentile float64
+		Expected   time.Duration
+	}{
+		{50, 10 * time.Millisecond},
+		{90, 20 * time.Millisecond},
+		{99, 20 * time.Millisecond},
+	}
+	for _, test := range tests {
+		value, ok := metrics.Percentile(buckets, test.Percentile)
+		assert.True(t, ok)
+		assert.Equal(t, test.Expected, value, "percentile %v", test.Percentile)
+	}
+
+	_, ok := metrics.Percentile(nil, 50)
+	assert.False(t, ok)
+}
+
+func TestCollectDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metrics")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	data, err := ioutil.ReadFile(filepath.Join("testdata", "uac_12_.csv"))
+	assert.NoError(t, err)
+	for _, pod := range []string{"run-job-a", "run-job-b", "run-job-old"} {
+		assert.NoError(t, os.MkdirAll(filepath.Join(dir, pod), 0755))
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, pod, "uac_12_.csv"), data, 0644))
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, pod, "uac_12_counts.csv"), []byte("ignored"), 0644))
+	}
+
+	result, err := metrics.CollectDir(dir, []string{"run-job-a", "run-job-b"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2376), result.SuccessfulCalls)
+	assert.Equal(t, int64(22), result.FailedCalls)
+	assert.Equal(t, "19.98", result.CallRate)
+	assert.Equal(t, 10*time.Millisecond, result.ResponseTimeP50.Duration)
+	assert.Equal(t, 50*time.Millisecond, result.ResponseTimeP90.Duration)
+	assert.Equal(t, 100*time.Millisecond, result.ResponseTimeP99.Duration)
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "empty"), 0755))
+	_, err = metrics.CollectDir(dir, []string{"empty"})
+	assert.Error(t, err)
+}
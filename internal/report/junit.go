@@ -0,0 +1,235 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+)
+
+// JUnitKey is the key of the JUnit report in the report ConfigMap
+const JUnitKey = "junit.xml"
+
+// exitCodes describes the exit codes of sipp
+var exitCodes = map[int32]string{
+	0:   "all calls were successful",
+	1:   "at least one call failed",
+	97:  "exit on internal command",
+	99:  "normal exit without calls processed",
+	255: "fatal error",
+	254: "fatal error binding a socket",
+}
+
+// ExitCodePrefix starts the termination message written by the sipp wrapper,
+// followed by the exit code of sipp and on the next lines the end of its output
+const ExitCodePrefix = "sipp exit code: "
+
+// Instance is the outcome of a sipp instance of the run
+type Instance struct {
+	// Name of the pod
+	Name     string
+	Duration time.Duration
+	// ExitCode is nil when sipp didn't terminate
+	ExitCode *int32
+	// Reason explains why sipp didn't terminate
+	Reason string
+	// Output is the end of the sipp logs, kept by the kubelet when sipp fails
+	Output string
+	// Finished is set once the pod is over
+	Finished bool
+}
+
+// NewInstance returns the outcome of the sipp container of the pod
+func NewInstance(pod *corev1.Pod) Instance {
+	instance := Instance{
+		Name:     pod.Name,
+		Reason:   pod.Status.Reason,
+		Finished: pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed,
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse {
+			instance.Reason = condition.Reason
+		}
+	}
+	if instance.Reason == "" {
+		instance.Reason = fmt.Sprintf("pod %s", pod.Status.Phase)
+	}
+
+	for _, status := range pod.Status.ContainerStatuses {
+		terminated := status.State.Terminated
+		if status.Name != "sipp" || terminated == nil {
+			continue
+		}
+		exitCode := terminated.ExitCode
+		instance.ExitCode = &exitCode
+		instance.Reason = terminated.Reason
+		instance.Output = terminated.Message
+		// The wrapper exits successfully whatever the outcome of sipp
+		if code, output, ok := parseTerminationMessage(terminated.Message); ok {
+			instance.ExitCode = &code
+			instance.Output = output
+		}
+		if !terminated.StartedAt.IsZero() {
+			instance.Duration = terminated.FinishedAt.Sub(terminated.StartedAt.Time)
+		}
+	}
+
+	return instance
+}
+
+// Failed returns whether the instance is over without sipp exiting successfully
+func (instance Instance) Failed() bool {
+	return instance.Finished && (instance.ExitCode == nil || *instance.ExitCode != 0)
+}
+
+// parseTerminationMessage returns the exit code of sipp and the end of its output
+// written by the wrapper, and false when the message isn't written by the wrapper
+func parseTerminationMessage(message string) (int32, string, bool) {
+	if !strings.HasPrefix(message, ExitCodePrefix) {
+		return 0, "", false
+	}
+
+	line := strings.TrimPrefix(message, ExitCodePrefix)
+	output := ""
+	if i := strings.Index(line, "\n"); i >= 0 {
+		line, output = line[:i], line[i+1:]
+	}
+
+	code, err := strconv.ParseInt(strings.TrimSpace(line), 10, 32)
+	if err != nil {
+		return 0, "", false
+	}
+	return int32(code), output, true
+}
+
+type testSuites struct {
+	XMLName    xml.Name    `xml:"testsuites"`
+	TestSuites []testSuite `xml:"testsuite"`
+}
+
+type testSuite struct {
+	Name       string     `xml:"name,attr"`
+	Tests      int        `xml:"tests,attr"`
+	Failures   int        `xml:"failures,attr"`
+	Errors     int        `xml:"errors,attr"`
+	Time       string     `xml:"time,attr"`
+	Timestamp  string     `xml:"timestamp,attr,omitempty"`
+	Properties []property `xml:"properties>property,omitempty"`
+	TestCases  []testCase `xml:"testcase"`
+}
+
+type property struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type testCase struct {
+	ClassName string   `xml:"classname,attr"`
+	Name      string   `xml:"name,attr"`
+	Time      string   `xml:"time,attr"`
+	Failure   *failure `xml:"failure,omitempty"`
+}
+
+type failure struct {
+	Message  string `xml:"message,attr"`
+	Type     string `xml:"type,attr"`
+	Contents string `xml:",chardata"`
+}
+
+func seconds(duration time.Duration) string {
+	return fmt.Sprintf("%.3f", duration.Seconds())
+}
+
+// JUnit returns the JUnit report of the finished run: a testsuite for the run,
+// with a testcase for each sipp instance and for each comparison with the baseline
+func JUnit(run *v1alpha1.SippScenarioRun, instances []Instance) ([]byte, error) {
+	className := fmt.Sprintf("%s.%s", run.Namespace, run.Name)
+	suite := testSuite{
+		Name:      run.Name,
+		TestCases: []testCase{},
+	}
+
+	if run.Spec.ScenarioRef != nil {
+		suite.Properties = append(suite.Properties, property{Name: "scenario", Value: run.Spec.ScenarioRef.Name})
+	}
+	if run.Spec.Destination != "" {
+		suite.Properties = append(suite.Properties, property{Name: "destination", Value: run.Spec.Destination})
+	}
+	if run.Spec.Transport != nil {
+		suite.Properties = append(suite.Properties, property{Name: "transport", Value: run.Spec.Transport.String()})
+	}
+
+	if attempts := run.Status.Attempts; len(attempts) > 0 {
+		last := attempts[len(attempts)-1]
+		if last.StartTime != nil {
+			suite.Timestamp = last.StartTime.UTC().Format("2006-01-02T15:04:05")
+			if last.CompletionTime != nil {
+				suite.Time = seconds(last.CompletionTime.Sub(last.StartTime.Time))
+			}
+		}
+	}
+	if suite.Time == "" {
+		suite.Time = seconds(0)
+	}
+
+	for _, instance := range instances {
+		testCase := testCase{
+			ClassName: className,
+			Name:      instance.Name,
+			Time:      seconds(instance.Duration),
+		}
+
+		switch {
+		case instance.ExitCode == nil:
+			testCase.Failure = &failure{
+				Message: fmt.Sprintf("sipp didn't terminate: %s", instance.Reason),
+				Type:    "NotTerminated",
+			}
+		case *instance.ExitCode != 0:
+			message := fmt.Sprintf("sipp exited with code %d", *instance.ExitCode)
+			if description, ok := exitCodes[*instance.ExitCode]; ok {
+				message += ": " + description
+			}
+			testCase.Failure = &failure{
+				Message:  message,
+				Type:     fmt.Sprintf("ExitCode%d", *instance.ExitCode),
+				Contents: instance.Output,
+			}
+		}
+
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+
+	for _, delta := range run.Status.BaselineDeltas {
+		testCase := testCase{
+			ClassName: className + ".baseline",
+			Name:      delta.Metric,
+			Time:      seconds(0),
+		}
+		if delta.Regressed {
+			testCase.Failure = &failure{
+				Message: fmt.Sprintf("regressed: %s", delta),
+				Type:    "Regressed",
+			}
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+
+	suite.Tests = len(suite.TestCases)
+	for _, testCase := range suite.TestCases {
+		if testCase.Failure != nil {
+			suite.Failures++
+		}
+	}
+
+	data, err := xml.MarshalIndent(testSuites{TestSuites: []testSuite{suite}}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
@@ -0,0 +1,140 @@
+package report_test
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	"github.com/alexandrevilain/sipp-operator/internal/report"
+)
+
+func TestNewInstance(t *testing.T) {
+	start := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "run-job-a"},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodFailed,
+			ContainerStatuses: []corev1.ContainerStatus{
+				{
+					Name: "sipp",
+					State: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{
+							ExitCode:   1,
+							Reason:     "Error",
+							Message:    "Aborting call on unexpected message",
+							StartedAt:  metav1.NewTime(start),
+							FinishedAt: metav1.NewTime(start.Add(90 * time.Second)),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	instance := report.NewInstance(pod)
+	assert.Equal(t, int32(1), *instance.ExitCode)
+	assert.Equal(t, 90*time.Second, instance.Duration)
+	assert.Equal(t, "Aborting call on unexpected message", instance.Output)
+
+	assert.True(t, instance.Failed())
+
+	// The wrapper pod succeeds, sipp exit code is in the termination message
+	terminated := pod.Status.ContainerStatuses[0].State.Terminated
+	terminated.ExitCode = 0
+	terminated.Message = "sipp exit code: 255\nUnable to open socket"
+	instance = report.NewInstance(pod)
+	assert.Equal(t, int32(255), *instance.ExitCode)
+	assert.Equal(t, "Unable to open socket", instance.Output)
+
+	terminated.Message = "sipp exit code: 0\n"
+	pod.Status.Phase = corev1.PodSucceeded
+	instance = report.NewInstance(pod)
+	assert.Equal(t, int32(0), *instance.ExitCode)
+	assert.False(t, instance.Failed())
+
+	pod.Status.Phase = corev1.PodFailed
+
+	pod.Status.ContainerStatuses = nil
+	pod.Status.Reason = "Evicted"
+	instance = report.NewInstance(pod)
+	assert.Nil(t, instance.ExitCode)
+	assert.Equal(t, "Evicted", instance.Reason)
+	assert.True(t, instance.Failed())
+
+	// A pod which can't be scheduled is pending, not failed
+	pod.Status = corev1.PodStatus{
+		Phase:      corev1.PodPending,
+		Conditions: []corev1.PodCondition{{Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Reason: corev1.PodReasonUnschedulable}},
+	}
+	instance = report.NewInstance(pod)
+	assert.Equal(t, corev1.PodReasonUnschedulable, instance.Reason)
+	assert.False(t, instance.Failed())
+}
+
+func TestJUnit(t *testing.T) {
+	start := metav1.NewTime(time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC))
+	end := metav1.NewTime(start.Add(2 * time.Minute))
+	run := &v1alpha1.SippScenarioRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: "qa"},
+		Spec: v1alpha1.SippScenarioRunSpec{
+			ScenarioRef: &v1alpha1.ScenarioReference{Name: "uac"},
+			Destination: "sbc:5060",
+		},
+		Status: v1alpha1.SippScenarioRunStatus{
+			Attempts: []v1alpha1.RunAttempt{{Attempt: 1, StartTime: &start, CompletionTime: &end}},
+			BaselineDeltas: []v1alpha1.MetricDelta{
+				{Metric: "successRatio", Baseline: "99.50%", Value: "99.40%", Delta: "-0.10pp"},
+				{Metric: "responseTimeP99", Baseline: "100ms", Value: "150ms", Delta: "+50.0%", Regressed: true},
+			},
+		},
+	}
+	exitCode := int32(1)
+	success := int32(0)
+	instances := []report.Instance{
+		{Name: "nightly-job-a", Duration: time.Minute, ExitCode: &success},
+		{Name: "nightly-job-b", Duration: 2 * time.Minute, ExitCode: &exitCode, Output: "Aborting call"},
+		{Name: "nightly-job-c", Reason: "Evicted"},
+	}
+
+	data, err := report.JUnit(run, instances)
+	assert.NoError(t, err)
+
+	parsed := struct {
+		TestSuite struct {
+			Name      string `xml:"name,attr"`
+			Tests     int    `xml:"tests,attr"`
+			Failures  int    `xml:"failures,attr"`
+			Time      string `xml:"time,attr"`
+			TestCases []struct {
+				ClassName string `xml:"classname,attr"`
+				Name      string `xml:"name,attr"`
+				Failure   *struct {
+					Message  string `xml:"message,attr"`
+					Type     string `xml:"type,attr"`
+					Contents string `xml:",chardata"`
+				} `xml:"failure"`
+			} `xml:"testcase"`
+		} `xml:"testsuite"`
+	}{}
+	assert.NoError(t, xml.Unmarshal(data, &parsed))
+
+	suite := parsed.TestSuite
+	assert.Equal(t, "nightly", suite.Name)
+	assert.Equal(t, 5, suite.Tests)
+	assert.Equal(t, 3, suite.Failures)
+	assert.Equal(t, "120.000", suite.Time)
+
+	assert.Equal(t, "qa.nightly", suite.TestCases[0].ClassName)
+	assert.Nil(t, suite.TestCases[0].Failure)
+	assert.Equal(t, "sipp exited with code 1: at least one call failed", suite.TestCases[1].Failure.Message)
+	assert.Equal(t, "Aborting call", suite.TestCases[1].Failure.Contents)
+	assert.Equal(t, "NotTerminated", suite.TestCases[2].Failure.Type)
+	assert.Equal(t, "qa.nightly.baseline", suite.TestCases[3].ClassName)
+	assert.Nil(t, suite.TestCases[3].Failure)
+	assert.Equal(t, "Regressed", suite.TestCases[4].Failure.Type)
+}
@@ -0,0 +1,82 @@
+package resource
+
+import (
+	"github.com/pkg/errors"
+
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apiresource "k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// defaultArtifactsClaimSize is the size of the claim created for the run artifacts
+var defaultArtifactsClaimSize = apiresource.MustParse("1Gi")
+
+// ArtifactsClaimBuilder builds the claim keeping the artifacts of a run,
+// when the run doesn't reference an existing one
+type ArtifactsClaimBuilder struct {
+	Instance *v1alpha1.SippScenarioRun
+	Scheme   *runtime.Scheme
+}
+
+func NewArtifactsClaimBuilder(builder *SippResourceBuilder) *ArtifactsClaimBuilder {
+	return &ArtifactsClaimBuilder{
+		Instance: builder.Instance,
+		Scheme:   builder.Scheme,
+	}
+}
+
+func (b *ArtifactsClaimBuilder) getLabels() map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":      b.Instance.ArtifactsClaimName(),
+		"app.kubernetes.io/component": "artifacts",
+		"app.kubernetes.io/part-of":   "sipp-run",
+	}
+}
+
+func (b *ArtifactsClaimBuilder) Build() (runtime.Object, error) {
+	storage := b.Instance.Spec.Traces.Storage
+
+	size := defaultArtifactsClaimSize
+	if storage.Size != nil {
+		size = *storage.Size
+	}
+
+	// Parallel pods may be scheduled on several nodes
+	accessMode := corev1.ReadWriteOnce
+	if b.Instance.GetParallelism() > 1 {
+		accessMode = corev1.ReadWriteMany
+	}
+
+	claim := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      b.Instance.ArtifactsClaimName(),
+			Namespace: b.Instance.Namespace,
+			Labels:    b.getLabels(),
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      []corev1.PersistentVolumeAccessMode{accessMode},
+			StorageClassName: storage.StorageClassName,
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: size,
+				},
+			},
+		},
+	}
+
+	err := controllerutil.SetControllerReference(b.Instance, claim, b.Scheme)
+	if err != nil {
+		return claim, errors.Wrap(err, "failed setting controller reference")
+	}
+
+	return claim, nil
+}
+
+func (b *ArtifactsClaimBuilder) Update(object runtime.Object) error {
+	// The claim spec can't be changed once bound
+	return nil
+}
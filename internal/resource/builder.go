@@ -14,11 +14,30 @@ type SippResourceBuilder struct {
 	Instance *v1alpha1.SippScenarioRun
 	Scenario *v1alpha1.SippScenario
 	Scheme   *runtime.Scheme
+	Layout   *ConfigLayout
+	// SecretFiles are the names of the scenario files loaded from Secrets
+	SecretFiles map[string]bool
 }
 
 func (builder *SippResourceBuilder) ResourceBuilders() ([]ResourceBuilder, error) {
-	return []ResourceBuilder{
-		NewConfigMapBuilder(builder),
-		NewJobBuilder(builder),
-	}, nil
+	layout, err := NewConfigLayout(builder.Scenario, builder.SecretFiles, maxConfigMapDataSize)
+	if err != nil {
+		return nil, err
+	}
+	builder.Layout = layout
+
+	builders := []ResourceBuilder{}
+	for i := range layout.ConfigMaps {
+		builders = append(builders, NewConfigMapBuilder(builder, i))
+	}
+	if layout.HasSecret() {
+		builders = append(builders, NewSecretBuilder(builder))
+	}
+
+	traces := builder.Instance.Spec.Traces
+	if traces != nil && traces.Storage.ClaimName == "" {
+		builders = append(builders, NewArtifactsClaimBuilder(builder))
+	}
+
+	return append(builders, NewJobBuilder(builder)), nil
 }
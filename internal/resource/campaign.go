@@ -0,0 +1,53 @@
+package resource
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// CampaignRunBuilder builds a run of a campaign
+type CampaignRunBuilder struct {
+	Campaign *v1alpha1.SippCampaign
+	Scheme   *runtime.Scheme
+	Index    int
+	Entry    v1alpha1.CampaignEntry
+}
+
+func (b *CampaignRunBuilder) getLabels() map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":      b.Campaign.RunName(b.Index),
+		"app.kubernetes.io/instance":  b.Campaign.Name,
+		"app.kubernetes.io/component": "run",
+		"app.kubernetes.io/part-of":   "sipp-campaign",
+	}
+}
+
+func (b *CampaignRunBuilder) Build() (runtime.Object, error) {
+	return &v1alpha1.SippScenarioRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      b.Campaign.RunName(b.Index),
+			Namespace: b.Campaign.Namespace,
+			Labels:    b.getLabels(),
+		},
+	}, nil
+}
+
+func (b *CampaignRunBuilder) Update(object runtime.Object) error {
+	run := object.(*v1alpha1.SippScenarioRun)
+
+	// The runs keep the spec they were created with, even if the campaign changes
+	if run.CreationTimestamp.IsZero() {
+		run.Spec = *b.Campaign.RunSpec(b.Entry)
+	}
+
+	if err := controllerutil.SetControllerReference(b.Campaign, run, b.Scheme); err != nil {
+		return fmt.Errorf("failed setting controller reference: %v", err)
+	}
+
+	return nil
+}
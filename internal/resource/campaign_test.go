@@ -0,0 +1,40 @@
+package resource_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	"github.com/alexandrevilain/sipp-operator/internal/resource"
+)
+
+func TestCampaignRunDestination(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+
+	campaign := &v1alpha1.SippCampaign{
+		ObjectMeta: metav1.ObjectMeta{Name: "campaign", Namespace: "default", UID: "campaign-uid"},
+		Spec: v1alpha1.SippCampaignSpec{
+			Template: v1alpha1.SippScenarioRunSpec{ScenarioRef: &v1alpha1.ScenarioReference{Name: "uac"}},
+			Matrix:   v1alpha1.CampaignMatrix{Destinations: []string{"sbc-a:5060", "sbc-b:5060"}},
+		},
+	}
+	scenario := &v1alpha1.SippScenario{Spec: v1alpha1.SippScenarioSpec{ScenarioFileContent: `<scenario name="uac"></scenario>`}}
+
+	// Each row of the campaign calls its own destination
+	for i, entry := range campaign.Entries() {
+		builder := &resource.CampaignRunBuilder{Campaign: campaign, Scheme: scheme, Index: i, Entry: entry}
+		obj, err := builder.Build()
+		require.NoError(t, err)
+		require.NoError(t, builder.Update(obj))
+
+		run := obj.(*v1alpha1.SippScenarioRun)
+		args := buildJob(t, run, scenario).Spec.Template.Spec.Containers[0].Args
+		assert.Contains(t, args, campaign.Spec.Matrix.Destinations[i])
+		assert.NotContains(t, args, campaign.Spec.Matrix.Destinations[1-i])
+	}
+}
@@ -15,19 +15,24 @@ type ConfigMapBuilder struct {
 	Instance *v1alpha1.SippScenarioRun
 	Scenario *v1alpha1.SippScenario
 	Scheme   *runtime.Scheme
+	// Index of the ConfigMap in the layout
+	Index   int
+	Content ConfigMapContent
 }
 
-func NewConfigMapBuilder(builder *SippResourceBuilder) *ConfigMapBuilder {
+func NewConfigMapBuilder(builder *SippResourceBuilder, index int) *ConfigMapBuilder {
 	return &ConfigMapBuilder{
 		Instance: builder.Instance,
 		Scenario: builder.Scenario,
 		Scheme:   builder.Scheme,
+		Index:    index,
+		Content:  builder.Layout.ConfigMaps[index],
 	}
 }
 
 func (b *ConfigMapBuilder) getLabelsSelectors() map[string]string {
 	return map[string]string{
-		"app.kubernetes.io/name":      b.Instance.ChildResourceName("configmap"),
+		"app.kubernetes.io/name":      ConfigMapName(b.Instance, b.Index),
 		"app.kubernetes.io/component": "configmap",
 		"app.kubernetes.io/part-of":   "sipp-run",
 	}
@@ -40,7 +45,7 @@ func (b *ConfigMapBuilder) getAnnotations() map[string]string {
 func (b *ConfigMapBuilder) Build() (runtime.Object, error) {
 	return &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:        b.Instance.ChildResourceName("configmap"),
+			Name:        ConfigMapName(b.Instance, b.Index),
 			Namespace:   b.Instance.Namespace,
 			Labels:      b.getLabelsSelectors(),
 			Annotations: b.getAnnotations(),
@@ -51,17 +56,10 @@ func (b *ConfigMapBuilder) Build() (runtime.Object, error) {
 func (b *ConfigMapBuilder) Update(object runtime.Object) error {
 	configMap := object.(*corev1.ConfigMap)
 
-	if configMap.Data == nil {
-		configMap.Data = make(map[string]string)
-	}
-
-	if b.Scenario.Spec.ScenarioFileContent != "" {
-		configMap.Data["scenario.xml"] = b.Scenario.Spec.ScenarioFileContent
-	}
-
-	for i, value := range b.Scenario.Spec.InjectValues {
-		configMap.Data[b.Scenario.GetInjectedValueFilename(i)] = value
-	}
+	// Files may move between the ConfigMaps when their size changes,
+	// stale keys are dropped so that the ConfigMap stays under the size limit
+	configMap.Data = b.Content.Data
+	configMap.BinaryData = b.Content.BinaryData
 
 	if err := controllerutil.SetControllerReference(b.Instance, configMap, b.Scheme); err != nil {
 		return fmt.Errorf("failed setting controller reference: %v", err)
@@ -1,25 +1,62 @@
 package resource
 
 import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
 	"github.com/pkg/errors"
 
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	"github.com/alexandrevilain/sipp-operator/internal/control"
+	"github.com/alexandrevilain/sipp-operator/internal/report"
+	"github.com/alexandrevilain/sipp-operator/internal/shellwords"
+	"github.com/alexandrevilain/sipp-operator/internal/util"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
 )
 
 const (
 	configPath = "/etc/jobconfig"
+	// stagingPath is where the config volume is mounted when
+	// its files must be reassembled before running sipp
+	stagingPath = "/etc/jobconfig-staging"
+	// mediaPath is where the media assets stored in PersistentVolumeClaims are mounted,
+	// outside of the config volume which is read-only, or staged by an init container
+	mediaPath = "/etc/jobmedia"
 )
 
+// sippWrapperScript runs sipp so that its pod always succeeds, as the job would
+// otherwise kill the other instances of the run once one of them fails.
+// The exit code of sipp and the end of its output are written to the
+// termination message instead, and the signals are forwarded to sipp,
+// which doesn't receive them behind the shell running as pid 1.
+var sippWrapperScript = strings.Join([]string{
+	"mkfifo /tmp/sipp-output",
+	"tee /dev/stderr < /tmp/sipp-output | tail -c 2048 > /tmp/sipp-output-tail &",
+	"output_pid=$!",
+	`sipp "$@" > /tmp/sipp-output 2>&1 &`,
+	"sipp_pid=$!",
+	`trap 'kill -TERM $sipp_pid' TERM INT`,
+	"wait $sipp_pid",
+	"code=$?",
+	// wait returns early when a signal is trapped
+	"while kill -0 $sipp_pid 2>/dev/null; do wait $sipp_pid; code=$?; done",
+	"wait $output_pid",
+	fmt.Sprintf(`{ echo "%s$code"; [ "$code" -eq 0 ] || cat /tmp/sipp-output-tail; } > /dev/termination-log`, report.ExitCodePrefix),
+}, "\n")
+
 type JobBuilder struct {
 	Instance *v1alpha1.SippScenarioRun
 	Scenario *v1alpha1.SippScenario
 	Scheme   *runtime.Scheme
+	Layout   *ConfigLayout
 }
 
 func NewJobBuilder(builder *SippResourceBuilder) *JobBuilder {
@@ -27,6 +64,7 @@ func NewJobBuilder(builder *SippResourceBuilder) *JobBuilder {
 		Instance: builder.Instance,
 		Scenario: builder.Scenario,
 		Scheme:   builder.Scheme,
+		Layout:   builder.Layout,
 	}
 }
 
@@ -42,25 +80,300 @@ func (b *JobBuilder) getAnnotations() map[string]string {
 	return b.Instance.Spec.JobAnnotations
 }
 
+// getJobAnnotations returns the annotations of the job itself,
+// which records the attempt of the run it executes
+func (b *JobBuilder) getJobAnnotations() map[string]string {
+	annotations := map[string]string{}
+	for key, value := range b.getAnnotations() {
+		annotations[key] = value
+	}
+	annotations[v1alpha1.AttemptAnnotation] = strconv.Itoa(int(b.Instance.NextAttempt()))
+	return annotations
+}
+
+// JobAttempt returns the number of the run attempt executed by the job
+func JobAttempt(job *batchv1.Job) int32 {
+	attempt, err := strconv.Atoi(job.Annotations[v1alpha1.AttemptAnnotation])
+	if err != nil || attempt < 1 {
+		return 1
+	}
+	return int32(attempt)
+}
+
+// getConfigProjections returns the sources projected in the config volume:
+// the run's ConfigMaps and Secret, and the media assets stored in ConfigMaps or Secrets
+func (b *JobBuilder) getConfigProjections() []corev1.VolumeProjection {
+	projections := []corev1.VolumeProjection{}
+
+	for i := range b.Layout.ConfigMaps {
+		projections = append(projections, corev1.VolumeProjection{
+			ConfigMap: &corev1.ConfigMapProjection{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: ConfigMapName(b.Instance, i),
+				},
+			},
+		})
+	}
+
+	if b.Layout.HasSecret() {
+		projections = append(projections, corev1.VolumeProjection{
+			Secret: &corev1.SecretProjection{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: SecretName(b.Instance),
+				},
+			},
+		})
+	}
+
+	for _, asset := range b.Scenario.Spec.MediaAssets {
+		switch {
+		case asset.ConfigMapKeyRef != nil:
+			projections = append(projections, corev1.VolumeProjection{
+				ConfigMap: &corev1.ConfigMapProjection{
+					LocalObjectReference: asset.ConfigMapKeyRef.LocalObjectReference,
+					Items:                []corev1.KeyToPath{{Key: asset.ConfigMapKeyRef.Key, Path: asset.Name}},
+					Optional:             asset.ConfigMapKeyRef.Optional,
+				},
+			})
+		case asset.SecretKeyRef != nil:
+			projections = append(projections, corev1.VolumeProjection{
+				Secret: &corev1.SecretProjection{
+					LocalObjectReference: asset.SecretKeyRef.LocalObjectReference,
+					Items:                []corev1.KeyToPath{{Key: asset.SecretKeyRef.Key, Path: asset.Name}},
+					Optional:             asset.SecretKeyRef.Optional,
+				},
+			})
+		}
+	}
+
+	return projections
+}
+
+// hasClaimAssets returns whether media assets are stored in PersistentVolumeClaims
+func (b *JobBuilder) hasClaimAssets() bool {
+	for _, asset := range b.Scenario.Spec.MediaAssets {
+		if asset.PersistentVolumeClaim != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// getMediaVolumes returns the volumes and mounts of the media assets
+// stored in PersistentVolumeClaims, one volume is created per claim.
+// They are linked beside the scenario file by the staging script.
+func (b *JobBuilder) getMediaVolumes() ([]corev1.Volume, []corev1.VolumeMount) {
+	volumes := []corev1.Volume{}
+	mounts := []corev1.VolumeMount{}
+	claimVolumes := map[string]string{}
+
+	for _, asset := range b.Scenario.Spec.MediaAssets {
+		if asset.PersistentVolumeClaim == nil {
+			continue
+		}
+
+		claimName := asset.PersistentVolumeClaim.ClaimName
+		volumeName, ok := claimVolumes[claimName]
+		if !ok {
+			volumeName = fmt.Sprintf("sipp-media-%d", len(claimVolumes))
+			claimVolumes[claimName] = volumeName
+			volumes = append(volumes, corev1.Volume{
+				Name: volumeName,
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+						ClaimName: claimName,
+						ReadOnly:  true,
+					},
+				},
+			})
+		}
+
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      volumeName,
+			MountPath: path.Join(mediaPath, asset.Name),
+			SubPath:   asset.PersistentVolumeClaim.Path,
+			ReadOnly:  true,
+		})
+	}
+
+	return volumes, mounts
+}
+
+// getStagingScript returns the shell script copying the config volume files
+// to the directory read by sipp, reassembling the chunked files
+// and linking the media assets mounted from claims
+func (b *JobBuilder) getStagingScript() string {
+	lines := []string{
+		"set -e",
+		fmt.Sprintf(`for f in %s/*; do case "$f" in *.gz.part[0-9][0-9][0-9]) ;; *) cp -L "$f" %s/ ;; esac; done`, stagingPath, configPath),
+	}
+
+	for _, file := range b.Layout.ChunkedFiles {
+		chunks := []string{}
+		for _, chunk := range file.Chunks {
+			chunks = append(chunks, path.Join(stagingPath, chunk))
+		}
+		lines = append(lines, fmt.Sprintf("cat %s | gunzip > %s", strings.Join(chunks, " "), path.Join(configPath, file.Name)))
+	}
+
+	for _, asset := range b.Scenario.Spec.MediaAssets {
+		if asset.PersistentVolumeClaim != nil {
+			lines = append(lines, fmt.Sprintf("ln -sf %s %s", path.Join(mediaPath, asset.Name), path.Join(configPath, asset.Name)))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// SippArgs returns the sipp arguments of the run, the ones of its command override
+// when set, it fails when the command override can't be split into arguments
+func SippArgs(run *v1alpha1.SippScenarioRun) ([]string, error) {
+	if !run.HasCommandOverride() {
+		return run.ToSippArgs(), nil
+	}
+	if len(run.Spec.Args) > 0 {
+		return run.Spec.Args, nil
+	}
+
+	args, err := shellwords.Split(run.Spec.CommandOverride)
+	if err != nil {
+		return nil, fmt.Errorf("invalid commandOverride: %v", err)
+	}
+	return args, nil
+}
+
 func (b *JobBuilder) Build() (runtime.Object, error) {
 	image := b.Instance.Spec.Image
 	if image == "" {
 		image = "ctaloi/sipp"
 	}
 
-	args := append([]string{}, b.Instance.ToSippArgs()...)
-	args = append(args, b.Scenario.ToSippArgs(configPath)...)
+	runArgs, err := SippArgs(b.Instance)
+	if err != nil {
+		return nil, err
+	}
+	args := append([]string{}, runArgs...)
+
+	// A command override referencing the mounted files places them itself
+	placed := false
+	if b.Instance.HasCommandOverride() {
+		args, placed = util.ExpandPlaceholders(args, b.Scenario.PathPlaceholders(configPath))
+	}
+	if !placed {
+		args = append(args, b.Scenario.ToSippArgs(configPath)...)
+	}
+	// Without an explicit port, sipp binds the next free one when the default is taken
+	args = append(args, "-cp", strconv.Itoa(control.DefaultPort))
+	args = append(args, b.Instance.Spec.ExtraArgs...)
+
+	controlPort := corev1.ContainerPort{
+		Name:          control.PortName,
+		ContainerPort: control.DefaultPort,
+		Protocol:      corev1.ProtocolUDP,
+	}
+	dnsPolicy := corev1.DNSClusterFirst
+	if b.Instance.Spec.HostNetwork {
+		dnsPolicy = corev1.DNSClusterFirstWithHostNet
+		// The port is reserved on the node, so that the instances are scheduled on distinct nodes
+		controlPort.HostPort = control.DefaultPort
+	}
+
+	// Media assets are referenced by name from the scenario actions,
+	// which sipp resolves from its working directory.
+	// Trace files are written beside the scenario file or in the working directory.
+	workingDir := ""
+	if len(b.Scenario.Spec.MediaAssets) > 0 || b.Instance.Spec.Traces != nil {
+		workingDir = configPath
+	}
+
+	configVolume := corev1.Volume{
+		Name: "sipp-config",
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{
+				Sources: b.getConfigProjections(),
+			},
+		},
+	}
+	volumes := []corev1.Volume{configVolume}
+	volumeMounts := []corev1.VolumeMount{
+		{
+			Name:      "sipp-config",
+			MountPath: configPath,
+		},
+	}
+
+	// Files too large for a ConfigMap are reassembled by an init container,
+	// into an emptyDir which replaces the config volume for sipp.
+	// When traces are kept, the files are copied to the pod directory of the
+	// artifacts claim instead, where sipp writes the trace files.
+	// Media assets mounted from claims are linked into the emptyDir as well,
+	// since no mount point can be created in the read-only config volume.
+	// It relies on the sipp image to provide sh, cat, gunzip and ln.
+	initContainers := []corev1.Container{}
+	env := []corev1.EnvVar{}
+	if b.Layout.NeedsStaging() || b.Instance.Spec.Traces != nil || b.hasClaimAssets() {
+		stagedVolume := corev1.Volume{
+			Name: "sipp-config",
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		}
+		stagedMount := corev1.VolumeMount{
+			Name:      "sipp-config",
+			MountPath: configPath,
+		}
+
+		if b.Instance.Spec.Traces != nil {
+			stagedVolume.VolumeSource = corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: b.Instance.ArtifactsClaimName(),
+				},
+			}
+			stagedMount.SubPathExpr = path.Join(b.Instance.Name, "$(POD_NAME)")
+			env = append(env, corev1.EnvVar{
+				Name: "POD_NAME",
+				ValueFrom: &corev1.EnvVarSource{
+					FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"},
+				},
+			})
+		}
+
+		configVolume.Name = "sipp-config-staging"
+		volumes = []corev1.Volume{configVolume, stagedVolume}
+		volumeMounts[0] = stagedMount
+
+		initContainers = append(initContainers, corev1.Container{
+			Name:    "stage-config",
+			Image:   image,
+			Command: []string{"sh", "-c", b.getStagingScript()},
+			Env:     env,
+			VolumeMounts: []corev1.VolumeMount{
+				{
+					Name:      "sipp-config-staging",
+					MountPath: stagingPath,
+					ReadOnly:  true,
+				},
+				stagedMount,
+			},
+		})
+	}
+
+	mediaVolumes, mediaVolumeMounts := b.getMediaVolumes()
+	volumes = append(volumes, mediaVolumes...)
+	volumeMounts = append(volumeMounts, mediaVolumeMounts...)
 
 	job := &batchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        b.Instance.ChildResourceName("job"),
 			Namespace:   b.Instance.Namespace,
 			Labels:      b.getLabels(),
-			Annotations: b.getAnnotations(),
+			Annotations: b.getJobAnnotations(),
 		},
 		Spec: batchv1.JobSpec{
 			Parallelism: b.Instance.Spec.Parallelism,
 			Completions: b.Instance.Spec.Parallelism,
+			// Failed runs are retried by the operator according to the run retry policy
+			BackoffLimit: pointer.Int32Ptr(0),
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:        b.Instance.ChildResourceName("job"),
@@ -71,37 +384,30 @@ func (b *JobBuilder) Build() (runtime.Object, error) {
 				Spec: corev1.PodSpec{
 					ImagePullSecrets: b.Instance.Spec.ImagePullSecrets,
 					RestartPolicy:    "Never",
+					HostNetwork:      b.Instance.Spec.HostNetwork,
+					DNSPolicy:        dnsPolicy,
+					InitContainers:   initContainers,
 					Containers: []corev1.Container{
 						{
-							Name:  "sipp",
-							Image: image,
-							Args:  args,
-							VolumeMounts: []corev1.VolumeMount{
-								{
-									Name:      "sipp-config",
-									MountPath: configPath,
-								},
-							},
-						},
-					},
-					Volumes: []corev1.Volume{
-						{
-							Name: "sipp-config",
-							VolumeSource: corev1.VolumeSource{
-								ConfigMap: &corev1.ConfigMapVolumeSource{
-									LocalObjectReference: corev1.LocalObjectReference{
-										Name: b.Instance.ChildResourceName("configmap"),
-									},
-								},
-							},
+							Name:    "sipp",
+							Image:   image,
+							Command: []string{"sh", "-c", sippWrapperScript, "sipp"},
+							Args:    args,
+							Env:     env,
+							Ports:   []corev1.ContainerPort{controlPort},
+							// The end of the sipp logs explains the failures in the run report
+							TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+							WorkingDir:               workingDir,
+							VolumeMounts:             volumeMounts,
 						},
 					},
+					Volumes: volumes,
 				},
 			},
 		},
 	}
 
-	err := controllerutil.SetControllerReference(b.Instance, job, b.Scheme)
+	err = controllerutil.SetControllerReference(b.Instance, job, b.Scheme)
 	if err != nil {
 		return job, errors.Wrap(err, "failed setting controller reference")
 	}
@@ -0,0 +1,318 @@
+package resource_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+
+	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	"github.com/alexandrevilain/sipp-operator/internal/resource"
+	"github.com/alexandrevilain/sipp-operator/internal/upload"
+)
+
+func buildJob(t *testing.T, run *v1alpha1.SippScenarioRun, scenario *v1alpha1.SippScenario) *batchv1.Job {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, v1alpha1.AddToScheme(scheme))
+
+	run.ObjectMeta = metav1.ObjectMeta{Name: "run", Namespace: "default"}
+	builder := &resource.SippResourceBuilder{
+		Instance: run,
+		Scenario: scenario,
+		Scheme:   scheme,
+	}
+
+	builders, err := builder.ResourceBuilders()
+	assert.NoError(t, err)
+
+	obj, err := builders[len(builders)-1].Build()
+	assert.NoError(t, err)
+	return obj.(*batchv1.Job)
+}
+
+func TestSippArgsOverride(t *testing.T) {
+	override := "-sf scenario.xml DEST_IP -s DEST_NUMBER"
+
+	run := &v1alpha1.SippScenarioRun{
+		Spec: v1alpha1.SippScenarioRunSpec{
+			Destination:     "121.0.0.1",
+			CommandOverride: override,
+		},
+	}
+
+	// Transport and destination should be ignored, as command Override is set
+	res, err := resource.SippArgs(run)
+	assert.NoError(t, err)
+	assert.Equal(t, override, strings.Join(res, " "))
+}
+
+func TestSippArgsOverrideQuoting(t *testing.T) {
+	run := &v1alpha1.SippScenarioRun{
+		Spec: v1alpha1.SippScenarioRunSpec{
+			CommandOverride: `-sn uac  -key name "a b" 127.0.0.1`,
+		},
+	}
+	args, err := resource.SippArgs(run)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"-sn", "uac", "-key", "name", "a b", "127.0.0.1"}, args)
+
+	run.Spec.CommandOverride = ""
+	run.Spec.Args = []string{"-key", "name", `say "hi"`}
+	args, err = resource.SippArgs(run)
+	assert.NoError(t, err)
+	assert.Equal(t, run.Spec.Args, args)
+
+	run.Spec.Args = nil
+	run.Spec.CommandOverride = `-key name "a b`
+	_, err = resource.SippArgs(run)
+	assert.EqualError(t, err, "invalid commandOverride: unterminated double quote at offset 10")
+}
+
+func TestJobArgs(t *testing.T) {
+	scenario := &v1alpha1.SippScenario{
+		Spec: v1alpha1.SippScenarioSpec{
+			ScenarioFileContent: `<scenario name="Basic Sipstone UAC"></scenario>`,
+			InjectValues:        []v1alpha1.InjectionFile{{Rows: [][]string{{"Sarah", "sipphone32"}}}},
+		},
+	}
+
+	tests := []struct {
+		Name     string
+		Spec     v1alpha1.SippScenarioRunSpec
+		Expected []string
+	}{
+		{
+			Name:     "without placeholders",
+			Spec:     v1alpha1.SippScenarioRunSpec{CommandOverride: "-m 1 127.0.0.1"},
+			Expected: []string{"-m", "1", "127.0.0.1", "-sf", "/etc/jobconfig/scenario.xml", "-inf", "/etc/jobconfig/values_0.csv", "-cp", "8888"},
+		},
+		{
+			Name:     "with extra args",
+			Spec:     v1alpha1.SippScenarioRunSpec{Parallelism: pointer.Int32Ptr(1), ExtraArgs: []string{"-aa", "-nostdin"}},
+			Expected: []string{"-sf", "/etc/jobconfig/scenario.xml", "-inf", "/etc/jobconfig/values_0.csv", "-cp", "8888", "-aa", "-nostdin"},
+		},
+		{
+			Name:     "with destination",
+			Spec:     v1alpha1.SippScenarioRunSpec{Destination: "sbc:5060", ExtraArgs: []string{"-aa"}},
+			Expected: []string{"sbc:5060", "-sf", "/etc/jobconfig/scenario.xml", "-inf", "/etc/jobconfig/values_0.csv", "-cp", "8888", "-aa"},
+		},
+		{
+			Name: "with placeholders",
+			Spec: v1alpha1.SippScenarioRunSpec{
+				Args: []string{"-sf", "$(SCENARIO_FILE)", "-inf", "$(INJECT_VALUES_FILE_0)", "-infindex", "$(INJECT_VALUES_FILE_0)", "0", "127.0.0.1"},
+			},
+			Expected: []string{"-sf", "/etc/jobconfig/scenario.xml", "-inf", "/etc/jobconfig/values_0.csv", "-infindex", "/etc/jobconfig/values_0.csv", "0", "127.0.0.1", "-cp", "8888"},
+		},
+	}
+
+	for _, test := range tests {
+		job := buildJob(t, &v1alpha1.SippScenarioRun{Spec: test.Spec}, scenario)
+		assert.Equal(t, test.Expected, job.Spec.Template.Spec.Containers[0].Args, test.Name)
+	}
+}
+
+func TestJobControlPort(t *testing.T) {
+	scenario := &v1alpha1.SippScenario{
+		Spec: v1alpha1.SippScenarioSpec{
+			ScenarioFileContent: `<scenario name="Basic Sipstone UAC"></scenario>`,
+		},
+	}
+
+	ports := buildJob(t, &v1alpha1.SippScenarioRun{}, scenario).Spec.Template.Spec.Containers[0].Ports
+	assert.Equal(t, []corev1.ContainerPort{{Name: "sipp-control", ContainerPort: 8888, Protocol: corev1.ProtocolUDP}}, ports)
+
+	// The instances sharing the host network can't bind the same port on a node
+	run := &v1alpha1.SippScenarioRun{Spec: v1alpha1.SippScenarioRunSpec{HostNetwork: true}}
+	ports = buildJob(t, run, scenario).Spec.Template.Spec.Containers[0].Ports
+	assert.Equal(t, int32(8888), ports[0].HostPort)
+}
+
+func TestJobTraces(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, v1alpha1.AddToScheme(scheme))
+
+	builder := &resource.SippResourceBuilder{
+		Instance: &v1alpha1.SippScenarioRun{
+			ObjectMeta: metav1.ObjectMeta{Name: "run", Namespace: "default"},
+			Spec: v1alpha1.SippScenarioRunSpec{
+				Traces: &v1alpha1.TraceOptions{Messages: true},
+			},
+		},
+		Scenario: &v1alpha1.SippScenario{
+			Spec: v1alpha1.SippScenarioSpec{
+				ScenarioFileContent: `<scenario name="Basic Sipstone UAC"></scenario>`,
+			},
+		},
+		Scheme: scheme,
+	}
+
+	builders, err := builder.ResourceBuilders()
+	assert.NoError(t, err)
+	assert.Len(t, builders, 3)
+
+	obj, err := builders[1].Build()
+	assert.NoError(t, err)
+	claim := obj.(*corev1.PersistentVolumeClaim)
+	assert.Equal(t, "run-artifacts", claim.Name)
+	assert.Equal(t, []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}, claim.Spec.AccessModes)
+
+	obj, err = builders[2].Build()
+	assert.NoError(t, err)
+	podSpec := obj.(*batchv1.Job).Spec.Template.Spec
+
+	// The scenario is staged in the pod directory of the claim, where sipp writes the traces
+	assert.Equal(t, "run-artifacts", podSpec.Volumes[1].PersistentVolumeClaim.ClaimName)
+	assert.Len(t, podSpec.InitContainers, 1)
+	for _, container := range []corev1.Container{podSpec.InitContainers[0], podSpec.Containers[0]} {
+		assert.Contains(t, container.VolumeMounts, corev1.VolumeMount{
+			Name:        "sipp-config",
+			MountPath:   "/etc/jobconfig",
+			SubPathExpr: "run/$(POD_NAME)",
+		})
+		assert.Equal(t, "POD_NAME", container.Env[0].Name)
+	}
+
+	sipp := podSpec.Containers[0]
+	assert.Equal(t, "/etc/jobconfig", sipp.WorkingDir)
+	assert.Equal(t, []string{"-trace_msg", "-sf", "/etc/jobconfig/scenario.xml", "-cp", "8888"}, sipp.Args)
+}
+
+func TestJobClaimMediaAssets(t *testing.T) {
+	scenario := &v1alpha1.SippScenario{
+		Spec: v1alpha1.SippScenarioSpec{
+			ScenarioFileContent: `<scenario name="Basic Sipstone UAC"></scenario>`,
+			MediaAssets: []v1alpha1.MediaAsset{
+				{Name: "hello.wav", PersistentVolumeClaim: &v1alpha1.PersistentVolumeClaimFileSelector{ClaimName: "media", Path: "wav/hello.wav"}},
+				{Name: "bye.wav", PersistentVolumeClaim: &v1alpha1.PersistentVolumeClaimFileSelector{ClaimName: "media", Path: "wav/bye.wav"}},
+			},
+		},
+	}
+
+	podSpec := buildJob(t, &v1alpha1.SippScenarioRun{}, scenario).Spec.Template.Spec
+	assert.Len(t, podSpec.Volumes, 3)
+	assert.Equal(t, "media", podSpec.Volumes[2].PersistentVolumeClaim.ClaimName)
+
+	// The kubelet can't create the mount points of the claim files in the read-only
+	// config volume, they are mounted apart and linked into the staged directory
+	sipp := podSpec.Containers[0]
+	assert.NotNil(t, podSpec.Volumes[1].EmptyDir)
+	assert.Contains(t, sipp.VolumeMounts, corev1.VolumeMount{Name: "sipp-config", MountPath: "/etc/jobconfig"})
+	assert.Contains(t, sipp.VolumeMounts, corev1.VolumeMount{Name: "sipp-media-0", MountPath: "/etc/jobmedia/hello.wav", SubPath: "wav/hello.wav", ReadOnly: true})
+	for i, mount := range sipp.VolumeMounts {
+		for j, other := range sipp.VolumeMounts {
+			if i != j {
+				assert.False(t, strings.HasPrefix(mount.MountPath+"/", other.MountPath+"/"), "%s is mounted in %s", mount.MountPath, other.MountPath)
+			}
+		}
+	}
+
+	assert.Len(t, podSpec.InitContainers, 1)
+	script := podSpec.InitContainers[0].Command[2]
+	assert.Contains(t, script, "ln -sf /etc/jobmedia/hello.wav /etc/jobconfig/hello.wav")
+	assert.Contains(t, script, "ln -sf /etc/jobmedia/bye.wav /etc/jobconfig/bye.wav")
+}
+
+func TestUploadJob(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, v1alpha1.AddToScheme(scheme))
+
+	builder := &resource.UploadJobBuilder{
+		Instance: &v1alpha1.SippScenarioRun{
+			ObjectMeta: metav1.ObjectMeta{Name: "run", Namespace: "default"},
+			Spec: v1alpha1.SippScenarioRunSpec{
+				Traces: &v1alpha1.TraceOptions{Statistics: true},
+			},
+		},
+		Scheme:                scheme,
+		Image:                 "sipp-operator:latest",
+		Settings:              upload.Settings{Endpoint: "http://minio:9000", Bucket: "sipp", PathStyle: true},
+		Prefix:                "default/run",
+		CredentialsSecretName: "minio",
+		Summary:               []byte(`{"run":"run"}`),
+	}
+
+	obj, err := builder.Build()
+	assert.NoError(t, err)
+	podSpec := obj.(*batchv1.Job).Spec.Template.Spec
+	container := podSpec.Containers[0]
+
+	assert.Equal(t, []string{"/uploader"}, container.Command)
+	assert.Equal(t, []string{"--endpoint", "http://minio:9000", "--bucket", "sipp", "--prefix", "default/run", "--path-style=true", "--dir", "/artifacts"}, container.Args)
+	assert.Equal(t, `{"run":"run"}`, container.Env[0].Value)
+	assert.Equal(t, "minio", container.Env[1].ValueFrom.SecretKeyRef.Name)
+	assert.Equal(t, "run-artifacts", podSpec.Volumes[0].PersistentVolumeClaim.ClaimName)
+	assert.Equal(t, "run", container.VolumeMounts[0].SubPath)
+}
+
+func TestMetricsJob(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, v1alpha1.AddToScheme(scheme))
+
+	builder := &resource.MetricsJobBuilder{
+		Instance: &v1alpha1.SippScenarioRun{
+			ObjectMeta: metav1.ObjectMeta{Name: "run", Namespace: "default"},
+			Spec: v1alpha1.SippScenarioRunSpec{
+				Traces: &v1alpha1.TraceOptions{Statistics: true},
+			},
+		},
+		Scheme: scheme,
+		Image:  "sipp-operator:latest",
+		Pods:   []string{"run-job-a", "run-job-b"},
+	}
+
+	obj, err := builder.Build()
+	assert.NoError(t, err)
+	podSpec := obj.(*batchv1.Job).Spec.Template.Spec
+	container := podSpec.Containers[0]
+
+	assert.Equal(t, "run-metrics", obj.(*batchv1.Job).Name)
+	assert.Equal(t, []string{"/collector"}, container.Command)
+	assert.Equal(t, []string{"--dir", "/artifacts", "--pods", "run-job-a,run-job-b"}, container.Args)
+	assert.Equal(t, "run-artifacts", podSpec.Volumes[0].PersistentVolumeClaim.ClaimName)
+	assert.Equal(t, "run", container.VolumeMounts[0].SubPath)
+}
+
+func TestJobAttempt(t *testing.T) {
+	scenario := &v1alpha1.SippScenario{
+		Spec: v1alpha1.SippScenarioSpec{
+			ScenarioFileContent: `<scenario name="Basic Sipstone UAC"></scenario>`,
+		},
+	}
+
+	run := &v1alpha1.SippScenarioRun{
+		Spec: v1alpha1.SippScenarioRunSpec{
+			JobAnnotations: map[string]string{"team": "voice"},
+		},
+	}
+	job := buildJob(t, run, scenario)
+	assert.Equal(t, pointer.Int32Ptr(0), job.Spec.BackoffLimit)
+	assert.Equal(t, int32(1), resource.JobAttempt(job))
+	assert.Equal(t, "voice", job.Annotations["team"])
+	assert.NotContains(t, run.Spec.JobAnnotations, v1alpha1.AttemptAnnotation)
+
+	run.Status.Attempts = []v1alpha1.RunAttempt{{Attempt: 1}}
+	job = buildJob(t, run, scenario)
+	assert.Equal(t, int32(2), resource.JobAttempt(job))
+}
+
+func TestJobWrapper(t *testing.T) {
+	scenario := &v1alpha1.SippScenario{
+		Spec: v1alpha1.SippScenarioSpec{
+			ScenarioFileContent: `<scenario name="Basic Sipstone UAC"></scenario>`,
+		},
+	}
+
+	// sipp runs behind a wrapper, so that a failed instance doesn't fail the job
+	sipp := buildJob(t, &v1alpha1.SippScenarioRun{}, scenario).Spec.Template.Spec.Containers[0]
+	assert.Len(t, sipp.Command, 4)
+	assert.Equal(t, []string{"sh", "-c"}, sipp.Command[:2])
+	assert.Contains(t, sipp.Command[2], `sipp "$@"`)
+	assert.Contains(t, sipp.Command[2], `echo "sipp exit code: $code"`)
+	assert.Equal(t, "sipp", sipp.Command[3])
+	assert.Equal(t, []string{"-sf", "/etc/jobconfig/scenario.xml", "-cp", "8888"}, sipp.Args)
+}
@@ -0,0 +1,177 @@
+package resource
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+)
+
+const (
+	// maxConfigMapDataSize is the size budget for the data of a single ConfigMap,
+	// kept under the 1MiB limit to leave room for the keys and the object metadata
+	maxConfigMapDataSize = 900 * 1024
+)
+
+// configFile is a file mounted in the sipp config directory
+type configFile struct {
+	Name    string
+	Content string
+}
+
+// ConfigMapContent is the data stored in one of the run's ConfigMaps
+type ConfigMapContent struct {
+	Data       map[string]string
+	BinaryData map[string][]byte
+}
+
+// ChunkedFile is a file too large for a ConfigMap, stored compressed and split in chunks
+type ChunkedFile struct {
+	// Name of the file once reassembled
+	Name string
+	// Chunks holds the ConfigMap keys of the gzip compressed chunks, in order
+	Chunks []string
+}
+
+// ConfigLayout describes how the scenario files are spread across the run's ConfigMaps
+type ConfigLayout struct {
+	// ConfigMaps holds the content of each ConfigMap. The first one always exists
+	// and holds the files small enough to be mounted as is.
+	ConfigMaps []ConfigMapContent
+	// ChunkedFiles lists the files which must be reassembled before running sipp
+	ChunkedFiles []ChunkedFile
+	// SecretData holds the files loaded from Secrets, which are stored
+	// in the run's Secret instead of its ConfigMaps
+	SecretData map[string][]byte
+}
+
+// NewConfigLayout spreads the scenario files across ConfigMaps holding at most maxSize bytes each.
+// The secretFiles are kept apart in SecretData, they must fit in a single Secret.
+func NewConfigLayout(scenario *v1alpha1.SippScenario, secretFiles map[string]bool, maxSize int) (*ConfigLayout, error) {
+	layout := &ConfigLayout{
+		ConfigMaps: []ConfigMapContent{
+			{Data: map[string]string{}},
+		},
+	}
+
+	all := []configFile{}
+
+	if scenario.Spec.ScenarioFileContent != "" {
+		all = append(all, configFile{Name: v1alpha1.ScenarioFilename, Content: scenario.Spec.ScenarioFileContent})
+	}
+
+	for i := range scenario.Spec.InjectValues {
+		all = append(all, configFile{Name: scenario.GetInjectedValueFilename(i), Content: scenario.Spec.InjectValues[i].Render()})
+	}
+
+	files := []configFile{}
+	secretSize := 0
+	for _, file := range all {
+		if !secretFiles[file.Name] {
+			files = append(files, file)
+			continue
+		}
+
+		if layout.SecretData == nil {
+			layout.SecretData = map[string][]byte{}
+		}
+		layout.SecretData[file.Name] = []byte(file.Content)
+		secretSize += len(file.Content)
+	}
+	if secretSize > maxSize {
+		return nil, fmt.Errorf("the files loaded from Secrets are larger than %d bytes", maxSize)
+	}
+
+	// Files are kept as is in the first ConfigMap while they fit,
+	// the remaining ones are compressed and split across the next ones
+	used := 0
+	binRemaining := 0
+	for _, file := range files {
+		if used+len(file.Content) <= maxSize {
+			layout.ConfigMaps[0].Data[file.Name] = file.Content
+			used += len(file.Content)
+			continue
+		}
+
+		compressed, err := compress([]byte(file.Content))
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't compress %s", file.Name)
+		}
+
+		chunked := ChunkedFile{Name: file.Name}
+		for len(compressed) > 0 {
+			if binRemaining == 0 {
+				layout.ConfigMaps = append(layout.ConfigMaps, ConfigMapContent{BinaryData: map[string][]byte{}})
+				binRemaining = maxSize
+			}
+
+			size := binRemaining
+			if len(compressed) < size {
+				size = len(compressed)
+			}
+
+			key := fmt.Sprintf("%s.gz.part%03d", file.Name, len(chunked.Chunks))
+			layout.ConfigMaps[len(layout.ConfigMaps)-1].BinaryData[key] = compressed[:size]
+			chunked.Chunks = append(chunked.Chunks, key)
+
+			compressed = compressed[size:]
+			binRemaining -= size
+		}
+
+		layout.ChunkedFiles = append(layout.ChunkedFiles, chunked)
+	}
+
+	return layout, nil
+}
+
+// NeedsStaging returns whether files must be reassembled before running sipp
+func (l *ConfigLayout) NeedsStaging() bool {
+	return len(l.ChunkedFiles) > 0
+}
+
+// HasSecret returns whether files are stored in the run's Secret
+func (l *ConfigLayout) HasSecret() bool {
+	return len(l.SecretData) > 0
+}
+
+// SecretName returns the name of the Secret holding the run files loaded from Secrets
+func SecretName(run *v1alpha1.SippScenarioRun) string {
+	return run.ChildResourceName("secret")
+}
+
+// ObjectNames returns the names of the run's ConfigMaps and Secret holding the layout files
+func (l *ConfigLayout) ObjectNames(run *v1alpha1.SippScenarioRun) map[string]bool {
+	names := map[string]bool{}
+	for i := range l.ConfigMaps {
+		names[ConfigMapName(run, i)] = true
+	}
+	if l.HasSecret() {
+		names[SecretName(run)] = true
+	}
+	return names
+}
+
+// ConfigMapName returns the name of the i-th ConfigMap of the run
+func ConfigMapName(run *v1alpha1.SippScenarioRun, i int) string {
+	if i == 0 {
+		return run.ChildResourceName("configmap")
+	}
+	return run.ChildResourceName(fmt.Sprintf("configmap-%d", i))
+}
+
+func compress(content []byte) ([]byte, error) {
+	buf := &bytes.Buffer{}
+
+	writer := gzip.NewWriter(buf)
+	if _, err := writer.Write(content); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,176 @@
+package resource_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	"github.com/alexandrevilain/sipp-operator/internal/resource"
+)
+
+func TestNewConfigLayoutSmallFiles(t *testing.T) {
+	scenario := &v1alpha1.SippScenario{
+		Spec: v1alpha1.SippScenarioSpec{
+			ScenarioFileContent: `<scenario name="Basic Sipstone UAC"></scenario>`,
+			InjectValues:        []v1alpha1.InjectionFile{{Rows: [][]string{{"Sarah", "sipphone32"}}}},
+		},
+	}
+
+	layout, err := resource.NewConfigLayout(scenario, nil, 1024)
+	assert.NoError(t, err)
+	assert.False(t, layout.NeedsStaging())
+	assert.Len(t, layout.ConfigMaps, 1)
+	assert.Equal(t, map[string]string{
+		"scenario.xml": scenario.Spec.ScenarioFileContent,
+		"values_0.csv": "SEQUENTIAL\nSarah;sipphone32\n",
+	}, layout.ConfigMaps[0].Data)
+}
+
+func TestNewConfigLayoutLargeFiles(t *testing.T) {
+	// Random content which doesn't compress well
+	random := rand.New(rand.NewSource(1))
+	users := v1alpha1.InjectionFile{}
+	for i := 0; i < 200; i++ {
+		users.Rows = append(users.Rows, []string{fmt.Sprintf("%x", random.Int63()), fmt.Sprintf("%x", random.Int63())})
+	}
+
+	scenario := &v1alpha1.SippScenario{
+		Spec: v1alpha1.SippScenarioSpec{
+			ScenarioFileContent: `<scenario name="Basic Sipstone UAC"></scenario>`,
+			InjectValues:        []v1alpha1.InjectionFile{users},
+		},
+	}
+
+	maxSize := 1024
+	layout, err := resource.NewConfigLayout(scenario, nil, maxSize)
+	assert.NoError(t, err)
+	assert.True(t, layout.NeedsStaging())
+	assert.Greater(t, len(layout.ConfigMaps), 2)
+
+	// The scenario still fits in the first ConfigMap
+	assert.Equal(t, map[string]string{"scenario.xml": scenario.Spec.ScenarioFileContent}, layout.ConfigMaps[0].Data)
+
+	assert.Len(t, layout.ChunkedFiles, 1)
+	assert.Equal(t, "values_0.csv", layout.ChunkedFiles[0].Name)
+
+	compressed := []byte{}
+	for _, chunk := range layout.ChunkedFiles[0].Chunks {
+		found := false
+		for _, configMap := range layout.ConfigMaps[1:] {
+			size := 0
+			for _, data := range configMap.BinaryData {
+				size += len(data)
+			}
+			assert.LessOrEqual(t, size, maxSize)
+
+			if data, ok := configMap.BinaryData[chunk]; ok {
+				compressed = append(compressed, data...)
+				found = true
+			}
+		}
+		assert.True(t, found, chunk)
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	assert.NoError(t, err)
+	content, err := ioutil.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, users.Render(), string(content))
+}
+
+func TestJobBuilderStaging(t *testing.T) {
+	rows := [][]string{}
+	for i := 0; i < 200000; i++ {
+		rows = append(rows, []string{"Sarah", "sipphone32"})
+	}
+
+	scheme := runtime.NewScheme()
+	assert.NoError(t, v1alpha1.AddToScheme(scheme))
+
+	builder := &resource.SippResourceBuilder{
+		Instance: &v1alpha1.SippScenarioRun{
+			ObjectMeta: metav1.ObjectMeta{Name: "run", Namespace: "default"},
+		},
+		Scenario: &v1alpha1.SippScenario{
+			Spec: v1alpha1.SippScenarioSpec{
+				ScenarioFileContent: `<scenario name="Basic Sipstone UAC"></scenario>`,
+				InjectValues:        []v1alpha1.InjectionFile{{Rows: rows}},
+			},
+		},
+		Scheme: scheme,
+	}
+
+	builders, err := builder.ResourceBuilders()
+	assert.NoError(t, err)
+	assert.Len(t, builders, len(builder.Layout.ConfigMaps)+1)
+
+	object, err := builders[len(builders)-1].Build()
+	assert.NoError(t, err)
+
+	pod := object.(*batchv1.Job).Spec.Template.Spec
+	assert.Len(t, pod.InitContainers, 1)
+	assert.Contains(t, pod.InitContainers[0].Command[2], "| gunzip > /etc/jobconfig/values_0.csv")
+	assert.NotNil(t, pod.Volumes[0].Projected)
+	assert.Len(t, pod.Volumes[0].Projected.Sources, len(builder.Layout.ConfigMaps))
+	assert.NotNil(t, pod.Volumes[1].EmptyDir)
+	assert.Equal(t, "sipp-config", pod.Containers[0].VolumeMounts[0].Name)
+}
+
+func TestSecretFiles(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, v1alpha1.AddToScheme(scheme))
+
+	builder := &resource.SippResourceBuilder{
+		Instance: &v1alpha1.SippScenarioRun{
+			ObjectMeta: metav1.ObjectMeta{Name: "run", Namespace: "default"},
+		},
+		Scenario: &v1alpha1.SippScenario{
+			Spec: v1alpha1.SippScenarioSpec{
+				ScenarioFileContent: `<scenario name="Basic Sipstone UAC"></scenario>`,
+				InjectValues: []v1alpha1.InjectionFile{
+					{Rows: [][]string{{"Sarah"}}},
+					{Rows: [][]string{{"alice", "secret"}}},
+				},
+			},
+		},
+		Scheme:      scheme,
+		SecretFiles: map[string]bool{"values_1.csv": true},
+	}
+
+	builders, err := builder.ResourceBuilders()
+	assert.NoError(t, err)
+	assert.Len(t, builders, 3)
+
+	// The files loaded from Secrets never end up in the ConfigMap
+	assert.NotContains(t, builder.Layout.ConfigMaps[0].Data, "values_1.csv")
+	assert.Contains(t, builder.Layout.ConfigMaps[0].Data, "values_0.csv")
+
+	object, err := builders[1].Build()
+	assert.NoError(t, err)
+	secret := object.(*corev1.Secret)
+	assert.NoError(t, builders[1].Update(secret))
+	assert.Equal(t, "run-secret", secret.Name)
+	assert.Equal(t, map[string][]byte{"values_1.csv": []byte("SEQUENTIAL\nalice;secret\n")}, secret.Data)
+	assert.Equal(t, "run", secret.OwnerReferences[0].Name)
+
+	object, err = builders[2].Build()
+	assert.NoError(t, err)
+	sources := object.(*batchv1.Job).Spec.Template.Spec.Volumes[0].Projected.Sources
+	assert.Len(t, sources, 2)
+	assert.Equal(t, "run-configmap", sources[0].ConfigMap.Name)
+	assert.Equal(t, "run-secret", sources[1].Secret.Name)
+
+	// The Secret has no chunks to spread the files across
+	_, err = resource.NewConfigLayout(builder.Scenario, map[string]bool{"scenario.xml": true, "values_1.csv": true}, 32)
+	assert.Error(t, err)
+}
@@ -0,0 +1,98 @@
+package resource
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+)
+
+// MetricsJobBuilder builds the job reading the metrics of a finished run
+// from the statistics files of its last attempt
+type MetricsJobBuilder struct {
+	Instance *v1alpha1.SippScenarioRun
+	Scheme   *runtime.Scheme
+	// Image of the operator, which provides the collector
+	Image string
+	// Pods are the pods of the last attempt, whose directories hold the statistics files
+	Pods []string
+}
+
+func (b *MetricsJobBuilder) getLabels() map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":      b.Instance.ChildResourceName("metrics"),
+		"app.kubernetes.io/component": "metrics",
+		"app.kubernetes.io/part-of":   "sipp-run",
+	}
+}
+
+func (b *MetricsJobBuilder) Build() (runtime.Object, error) {
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      b.Instance.ChildResourceName("metrics"),
+			Namespace: b.Instance.Namespace,
+			Labels:    b.getLabels(),
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: pointer.Int32Ptr(2),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: b.getLabels(),
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy: "Never",
+					Containers: []corev1.Container{
+						{
+							Name:    "collect",
+							Image:   b.Image,
+							Command: []string{"/collector"},
+							Args: []string{
+								"--dir", artifactsPath,
+								"--pods", strings.Join(b.Pods, ","),
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      "sipp-artifacts",
+									MountPath: artifactsPath,
+									SubPath:   b.Instance.Name,
+									ReadOnly:  true,
+								},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "sipp-artifacts",
+							VolumeSource: corev1.VolumeSource{
+								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+									ClaimName: b.Instance.ArtifactsClaimName(),
+									ReadOnly:  true,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	err := controllerutil.SetControllerReference(b.Instance, job, b.Scheme)
+	if err != nil {
+		return job, errors.Wrap(err, "failed setting controller reference")
+	}
+
+	return job, nil
+}
+
+func (b *MetricsJobBuilder) Update(object runtime.Object) error {
+	// Job should not be updated as its launched when created
+	return nil
+}
@@ -0,0 +1,56 @@
+package resource
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	"github.com/alexandrevilain/sipp-operator/internal/report"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ReportComponent is the component label of the report ConfigMap,
+// which is kept with the results of the runs garbage-collected by the history limits
+const ReportComponent = "report"
+
+// ReportName returns the name of the ConfigMap holding the report of the run
+func ReportName(run *v1alpha1.SippScenarioRun) string {
+	return run.ChildResourceName("report")
+}
+
+// ReportBuilder builds the ConfigMap holding the JUnit report of a finished run
+type ReportBuilder struct {
+	Instance *v1alpha1.SippScenarioRun
+	Scheme   *runtime.Scheme
+	JUnit    []byte
+}
+
+func (b *ReportBuilder) Build() (runtime.Object, error) {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ReportName(b.Instance),
+			Namespace: b.Instance.Namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/name":      ReportName(b.Instance),
+				"app.kubernetes.io/component": ReportComponent,
+				"app.kubernetes.io/part-of":   "sipp-run",
+			},
+		},
+	}, nil
+}
+
+func (b *ReportBuilder) Update(object runtime.Object) error {
+	configMap := object.(*corev1.ConfigMap)
+	configMap.Data = map[string]string{
+		report.JUnitKey: string(b.JUnit),
+	}
+
+	if err := controllerutil.SetControllerReference(b.Instance, configMap, b.Scheme); err != nil {
+		return fmt.Errorf("failed setting controller reference: %v", err)
+	}
+
+	return nil
+}
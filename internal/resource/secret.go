@@ -0,0 +1,59 @@
+package resource
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// SecretBuilder builds the Secret holding the run files loaded from Secrets,
+// so that they are never readable from the run's ConfigMaps
+type SecretBuilder struct {
+	Instance *v1alpha1.SippScenarioRun
+	Scheme   *runtime.Scheme
+	Data     map[string][]byte
+}
+
+func NewSecretBuilder(builder *SippResourceBuilder) *SecretBuilder {
+	return &SecretBuilder{
+		Instance: builder.Instance,
+		Scheme:   builder.Scheme,
+		Data:     builder.Layout.SecretData,
+	}
+}
+
+func (b *SecretBuilder) getLabels() map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":      SecretName(b.Instance),
+		"app.kubernetes.io/component": "secret",
+		"app.kubernetes.io/part-of":   "sipp-run",
+	}
+}
+
+func (b *SecretBuilder) Build() (runtime.Object, error) {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      SecretName(b.Instance),
+			Namespace: b.Instance.Namespace,
+			Labels:    b.getLabels(),
+		},
+	}, nil
+}
+
+func (b *SecretBuilder) Update(object runtime.Object) error {
+	secret := object.(*corev1.Secret)
+
+	secret.Type = corev1.SecretTypeOpaque
+	secret.Data = b.Data
+
+	if err := controllerutil.SetControllerReference(b.Instance, secret, b.Scheme); err != nil {
+		return fmt.Errorf("failed setting controller reference: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,156 @@
+package resource
+
+import (
+	"fmt"
+	"strconv"
+
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	"github.com/alexandrevilain/sipp-operator/internal/util"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// DefaultSIPPort is the port sipp listens on when the run sets no local port
+const DefaultSIPPort = 5060
+
+// StepOutputName returns the placeholder name of an output of a suite step
+func StepOutputName(step, output string) string {
+	return fmt.Sprintf("steps.%s.%s", step, output)
+}
+
+// ExpandStepOutputs returns the run spec with the $(steps.<name>.<output>)
+// placeholders replaced by the outputs of the previous steps
+func ExpandStepOutputs(spec *v1alpha1.SippScenarioRunSpec, outputs map[string]string) *v1alpha1.SippScenarioRunSpec {
+	expanded := spec.DeepCopy()
+	expand := func(value string) string {
+		result, _ := util.ExpandPlaceholders([]string{value}, outputs)
+		return result[0]
+	}
+
+	expanded.Destination = expand(expanded.Destination)
+	expanded.CommandOverride = expand(expanded.CommandOverride)
+	expanded.Args, _ = util.ExpandPlaceholders(expanded.Args, outputs)
+	expanded.ExtraArgs, _ = util.ExpandPlaceholders(expanded.ExtraArgs, outputs)
+	for name, value := range expanded.Parameters {
+		expanded.Parameters[name] = expand(value)
+	}
+
+	return expanded
+}
+
+// UASPort returns the port and protocol the sipp instances of the run answer calls on
+func UASPort(spec *v1alpha1.SippScenarioRunSpec) (int32, corev1.Protocol) {
+	port := int32(DefaultSIPPort)
+	if spec.LocalPort != nil {
+		port = *spec.LocalPort
+	}
+
+	protocol := corev1.ProtocolUDP
+	if spec.Transport != nil && (spec.Transport.Protocol == v1alpha1.ProtocolTCP || spec.Transport.Protocol == v1alpha1.ProtocolTLS) {
+		protocol = corev1.ProtocolTCP
+	}
+
+	return port, protocol
+}
+
+// StepRunBuilder builds the run started by a step of a test suite
+type StepRunBuilder struct {
+	Suite  *v1alpha1.SippTestSuite
+	Scheme *runtime.Scheme
+	Step   string
+	Spec   *v1alpha1.SippScenarioRunSpec
+}
+
+func (b *StepRunBuilder) getLabels() map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":      b.Suite.StepRunName(b.Step),
+		"app.kubernetes.io/component": "step",
+		"app.kubernetes.io/part-of":   "sipp-test-suite",
+	}
+}
+
+func (b *StepRunBuilder) Build() (runtime.Object, error) {
+	return &v1alpha1.SippScenarioRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      b.Suite.StepRunName(b.Step),
+			Namespace: b.Suite.Namespace,
+			Labels:    b.getLabels(),
+		},
+	}, nil
+}
+
+func (b *StepRunBuilder) Update(object runtime.Object) error {
+	run := object.(*v1alpha1.SippScenarioRun)
+
+	// The run is only set up when created, the suite may stop it afterwards
+	if run.CreationTimestamp.IsZero() {
+		run.Spec = *b.Spec.DeepCopy()
+	}
+
+	if err := controllerutil.SetControllerReference(b.Suite, run, b.Scheme); err != nil {
+		return fmt.Errorf("failed setting controller reference: %v", err)
+	}
+
+	return nil
+}
+
+// UASServiceBuilder builds the Service exposing the run of a UAS step
+type UASServiceBuilder struct {
+	Suite  *v1alpha1.SippTestSuite
+	Scheme *runtime.Scheme
+	Step   string
+	Spec   *v1alpha1.SippScenarioRunSpec
+}
+
+func (b *UASServiceBuilder) getLabels() map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":      b.Suite.StepRunName(b.Step),
+		"app.kubernetes.io/component": "uas",
+		"app.kubernetes.io/part-of":   "sipp-test-suite",
+	}
+}
+
+func (b *UASServiceBuilder) Build() (runtime.Object, error) {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      b.Suite.StepRunName(b.Step),
+			Namespace: b.Suite.Namespace,
+			Labels:    b.getLabels(),
+		},
+	}, nil
+}
+
+func (b *UASServiceBuilder) Update(object runtime.Object) error {
+	service := object.(*corev1.Service)
+	port, protocol := UASPort(b.Spec)
+	run := &v1alpha1.SippScenarioRun{ObjectMeta: metav1.ObjectMeta{Name: b.Suite.StepRunName(b.Step)}}
+
+	service.Spec.Selector = map[string]string{"job-name": run.ChildResourceName("job")}
+	service.Spec.Ports = []corev1.ServicePort{
+		{
+			Name:       "sip",
+			Port:       port,
+			Protocol:   protocol,
+			TargetPort: intstr.FromInt(int(port)),
+		},
+	}
+
+	if err := controllerutil.SetControllerReference(b.Suite, service, b.Scheme); err != nil {
+		return fmt.Errorf("failed setting controller reference: %v", err)
+	}
+
+	return nil
+}
+
+// UASOutputs returns the outputs of a UAS step, the address and the port of its Service
+func UASOutputs(suite *v1alpha1.SippTestSuite, step string, spec *v1alpha1.SippScenarioRunSpec) map[string]string {
+	port, _ := UASPort(spec)
+	return map[string]string{
+		"address": fmt.Sprintf("%s.%s.svc", suite.StepRunName(step), suite.Namespace),
+		"port":    strconv.Itoa(int(port)),
+	}
+}
@@ -0,0 +1,64 @@
+package resource_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+
+	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	"github.com/alexandrevilain/sipp-operator/internal/resource"
+)
+
+func TestExpandStepOutputs(t *testing.T) {
+	suite := &v1alpha1.SippTestSuite{ObjectMeta: metav1.ObjectMeta{Name: "call", Namespace: "voice"}}
+	callee := &v1alpha1.SippScenarioRunSpec{
+		LocalPort: pointer.Int32Ptr(5070),
+		Transport: &v1alpha1.Transport{Protocol: v1alpha1.ProtocolTCP, Socket: "One"},
+	}
+
+	outputs := map[string]string{}
+	for name, value := range resource.UASOutputs(suite, "callee", callee) {
+		outputs[resource.StepOutputName("callee", name)] = value
+	}
+
+	caller := &v1alpha1.SippScenarioRunSpec{
+		Destination: "$(steps.callee.address):$(steps.callee.port)",
+		Parameters:  map[string]string{"callee": "$(steps.callee.address)"},
+		ExtraArgs:   []string{"-rsa", "$(steps.callee.address)", "$(steps.unknown.address)"},
+	}
+	expanded := resource.ExpandStepOutputs(caller, outputs)
+
+	assert.Equal(t, "call-callee.voice.svc:5070", expanded.Destination)
+	assert.Equal(t, "call-callee.voice.svc", expanded.Parameters["callee"])
+	assert.Equal(t, []string{"-rsa", "call-callee.voice.svc", "$(steps.unknown.address)"}, expanded.ExtraArgs)
+	// The spec of the suite is left as is
+	assert.Equal(t, "$(steps.callee.address)", caller.Parameters["callee"])
+
+	// The outputs reach the command of the sipp instances
+	scenario := &v1alpha1.SippScenario{Spec: v1alpha1.SippScenarioSpec{ScenarioFileContent: `<scenario name="uac"></scenario>`}}
+	args := buildJob(t, &v1alpha1.SippScenarioRun{Spec: *expanded}, scenario).Spec.Template.Spec.Containers[0].Args
+	assert.Equal(t, "call-callee.voice.svc:5070", args[0])
+	assert.Equal(t, []string{"-rsa", "call-callee.voice.svc"}, args[len(args)-3:len(args)-1])
+}
+
+func TestUASService(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, v1alpha1.AddToScheme(scheme))
+
+	suite := &v1alpha1.SippTestSuite{ObjectMeta: metav1.ObjectMeta{Name: "call", Namespace: "voice"}}
+	builder := &resource.UASServiceBuilder{Suite: suite, Scheme: scheme, Step: "callee", Spec: &v1alpha1.SippScenarioRunSpec{}}
+
+	obj, err := builder.Build()
+	assert.NoError(t, err)
+	assert.NoError(t, builder.Update(obj))
+
+	service := obj.(*corev1.Service)
+	assert.Equal(t, "call-callee", service.Name)
+	assert.Equal(t, map[string]string{"job-name": "call-callee-job"}, service.Spec.Selector)
+	assert.Equal(t, int32(5060), service.Spec.Ports[0].Port)
+	assert.Equal(t, corev1.ProtocolUDP, service.Spec.Ports[0].Protocol)
+}
@@ -0,0 +1,145 @@
+package resource
+
+import (
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	"github.com/alexandrevilain/sipp-operator/internal/upload"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+)
+
+const artifactsPath = "/artifacts"
+
+// UploadJobBuilder builds the job uploading the artifacts of a finished run
+type UploadJobBuilder struct {
+	Instance *v1alpha1.SippScenarioRun
+	Scheme   *runtime.Scheme
+	// Image of the operator, which provides the uploader
+	Image    string
+	Settings upload.Settings
+	Prefix   string
+	// CredentialsSecretName is the name of the credentials Secret in the run namespace
+	CredentialsSecretName string
+	Summary               []byte
+}
+
+func (b *UploadJobBuilder) getLabels() map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":      b.Instance.ChildResourceName("upload"),
+		"app.kubernetes.io/component": "upload",
+		"app.kubernetes.io/part-of":   "sipp-run",
+	}
+}
+
+func (b *UploadJobBuilder) getEnv() []corev1.EnvVar {
+	env := []corev1.EnvVar{
+		{Name: "SIPP_RUN_SUMMARY", Value: string(b.Summary)},
+	}
+
+	if b.CredentialsSecretName == "" {
+		return env
+	}
+
+	secretKey := func(name, key string, optional bool) corev1.EnvVar {
+		return corev1.EnvVar{
+			Name: name,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: b.CredentialsSecretName},
+					Key:                  key,
+					Optional:             pointer.BoolPtr(optional),
+				},
+			},
+		}
+	}
+
+	return append(env,
+		secretKey("AWS_ACCESS_KEY_ID", upload.AccessKeyIDKey, false),
+		secretKey("AWS_SECRET_ACCESS_KEY", upload.SecretAccessKeyKey, false),
+		secretKey("AWS_SESSION_TOKEN", upload.SessionTokenKey, true),
+	)
+}
+
+func (b *UploadJobBuilder) Build() (runtime.Object, error) {
+	args := []string{
+		"--endpoint", b.Settings.Endpoint,
+		"--bucket", b.Settings.Bucket,
+		"--prefix", b.Prefix,
+		"--path-style=" + strconv.FormatBool(b.Settings.PathStyle),
+		"--dir", artifactsPath,
+	}
+	if b.Settings.Region != "" {
+		args = append(args, "--region", b.Settings.Region)
+	}
+
+	// The artifacts of all the pods are kept in the run directory of the claim
+	volumes := []corev1.Volume{}
+	volumeMounts := []corev1.VolumeMount{}
+	if b.Instance.Spec.Traces != nil {
+		volumes = append(volumes, corev1.Volume{
+			Name: "sipp-artifacts",
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: b.Instance.ArtifactsClaimName(),
+					ReadOnly:  true,
+				},
+			},
+		})
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      "sipp-artifacts",
+			MountPath: artifactsPath,
+			SubPath:   b.Instance.Name,
+			ReadOnly:  true,
+		})
+	}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      b.Instance.ChildResourceName("upload"),
+			Namespace: b.Instance.Namespace,
+			Labels:    b.getLabels(),
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: pointer.Int32Ptr(2),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: b.getLabels(),
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy: "Never",
+					Containers: []corev1.Container{
+						{
+							Name:         "upload",
+							Image:        b.Image,
+							Command:      []string{"/uploader"},
+							Args:         args,
+							Env:          b.getEnv(),
+							VolumeMounts: volumeMounts,
+						},
+					},
+					Volumes: volumes,
+				},
+			},
+		},
+	}
+
+	err := controllerutil.SetControllerReference(b.Instance, job, b.Scheme)
+	if err != nil {
+		return job, errors.Wrap(err, "failed setting controller reference")
+	}
+
+	return job, nil
+}
+
+func (b *UploadJobBuilder) Update(object runtime.Object) error {
+	// Job should not be updated as its launched when created
+	return nil
+}
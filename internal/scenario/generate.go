@@ -0,0 +1,399 @@
+package scenario
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alexandrevilain/sipp-operator/internal/capture"
+)
+
+const (
+	// SideCaller generates the scenario of the side which sent the first request
+	SideCaller = "caller"
+	// SideCallee generates the scenario of the side which received the first request
+	SideCallee = "callee"
+
+	// minPause is the shortest delay between a message and the next one sent
+	// which is kept as a pause
+	minPause = 100 * time.Millisecond
+)
+
+var (
+	branchRegexp     = regexp.MustCompile(`(?i);branch=[^;,\s]*`)
+	viaTransport     = regexp.MustCompile(`(?i)^SIP/2\.0/[A-Z]+`)
+	mediaPortRegexp  = regexp.MustCompile(`(?m)^m=audio [0-9]+`)
+	mediaIPTypeRegex = regexp.MustCompile(`IN IP[46] \[media_ip\]`)
+)
+
+// responseHeaders are replaced in the responses sent by the headers of the request
+var responseHeaders = map[string]string{
+	"via":          "[last_Via:]",
+	"from":         "[last_From:]",
+	"to":           "[last_To:]",
+	"call-id":      "[last_Call-ID:]",
+	"cseq":         "[last_CSeq:]",
+	"record-route": "[last_Record-Route:]",
+}
+
+// GenerateOptions define the scenario generated from a dialog
+type GenerateOptions struct {
+	// Name of the scenario, defaults to the Call-ID of the dialog
+	Name string
+	// Side is the side of the dialog played by sipp: caller, callee,
+	// or the address of the side as ip or ip:port. Defaults to the caller.
+	Side string
+}
+
+// generator templates the messages of a dialog
+type generator struct {
+	local, remote capture.Endpoint
+	caller        bool
+	// localTag and remoteTag are the tags of the From and To headers of both sides
+	localTag, remoteTag string
+	// lastRequest is the last request received
+	lastRequest *capture.Message
+	// remoteTagVariable tells whether the remote tag is captured in a variable,
+	// which is needed to send requests from the callee side
+	remoteTagVariable bool
+}
+
+// command is a command of the generated scenario
+type command struct {
+	message *capture.Message
+	pause   time.Duration
+	element string
+}
+
+// Generate returns a sipp scenario playing a side of the dialog captured in the messages.
+// The messages sent by the side become <send> commands, with the addresses, the Call-ID,
+// the branches and the tags replaced by keywords, and the messages it received become
+// <recv> commands. The retransmissions and the messages between other hosts are left out.
+func Generate(messages []*capture.Message, options GenerateOptions) (string, error) {
+	var first *capture.Message
+	for _, message := range messages {
+		if message.IsRequest() {
+			first = message
+			break
+		}
+	}
+	if first == nil {
+		return "", fmt.Errorf("the dialog has no request")
+	}
+
+	g := &generator{}
+	switch options.Side {
+	case "", SideCaller:
+		g.local, g.remote = first.Src, first.Dst
+	case SideCallee:
+		g.local, g.remote = first.Dst, first.Src
+	default:
+		found := false
+		for _, message := range messages {
+			if matchesSide(message.Src, options.Side) {
+				g.local, g.remote, found = message.Src, message.Dst, true
+			} else if matchesSide(message.Dst, options.Side) {
+				g.local, g.remote, found = message.Dst, message.Src, true
+			}
+			if found {
+				break
+			}
+		}
+		if !found {
+			return "", fmt.Errorf("no message was sent or received by %s", options.Side)
+		}
+	}
+	g.caller = first.Src.Equal(g.local)
+	g.findTags(first, messages)
+
+	commands := []*command{}
+	seen := map[string]bool{}
+	var previous *capture.Message
+	for _, message := range messages {
+		sent := message.Src.Equal(g.local) && message.Dst.Equal(g.remote)
+		received := message.Src.Equal(g.remote) && message.Dst.Equal(g.local)
+		if !sent && !received {
+			continue
+		}
+
+		// The retransmissions are identical to the original message
+		key := fmt.Sprintf("%t %s %v %s", sent, message.StartLine, message.Headers, message.Body)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		c := &command{message: message}
+		if sent {
+			if previous != nil && message.Time.Sub(previous.Time) >= minPause {
+				c.pause = message.Time.Sub(previous.Time)
+			}
+			element, err := g.send(message)
+			if err != nil {
+				return "", err
+			}
+			c.element = element
+		} else {
+			c.element = g.recv(message)
+			if message.IsRequest() {
+				g.lastRequest = message
+			}
+		}
+		commands = append(commands, c)
+		previous = message
+	}
+
+	if len(commands) == 0 {
+		return "", fmt.Errorf("no message was sent or received by %s", g.local)
+	}
+
+	name := options.Name
+	if name == "" {
+		name = first.CallID()
+	}
+	return g.render(name, commands), nil
+}
+
+// matchesSide returns whether the endpoint is the side, given as ip or ip:port
+func matchesSide(endpoint capture.Endpoint, side string) bool {
+	if ip := net.ParseIP(side); ip != nil {
+		return endpoint.IP.Equal(ip)
+	}
+	host, port, err := net.SplitHostPort(side)
+	if err != nil {
+		return false
+	}
+	return endpoint.IP.Equal(net.ParseIP(host)) && port == strconv.Itoa(int(endpoint.Port))
+}
+
+// findTags sets the tags of both sides, the caller one is in the From header
+// of the first request and the callee one in the To header of its responses
+func (g *generator) findTags(first *capture.Message, messages []*capture.Message) {
+	callerTag := tagOf(first.Header("from"))
+	calleeTag := ""
+	for _, message := range messages {
+		if !message.IsRequest() && message.Src.Equal(first.Dst) {
+			if calleeTag = tagOf(message.Header("to")); calleeTag != "" {
+				break
+			}
+		}
+	}
+
+	if g.caller {
+		g.localTag, g.remoteTag = callerTag, calleeTag
+	} else {
+		g.localTag, g.remoteTag = calleeTag, callerTag
+	}
+}
+
+// tagOf returns the tag parameter of a From or To header
+func tagOf(header string) string {
+	for _, parameter := range strings.Split(header, ";")[1:] {
+		parts := strings.SplitN(strings.TrimSpace(parameter), "=", 2)
+		if len(parts) == 2 && strings.EqualFold(parts[0], "tag") {
+			return strings.TrimRight(parts[1], "> ")
+		}
+	}
+	return ""
+}
+
+// localTagKeyword returns the keywords of the tag of the side,
+// unique per call like the ones of the sipp built-in scenarios
+func (g *generator) localTagKeyword() string {
+	if g.caller {
+		return "[pid]SIPpTag00[call_number]"
+	}
+	return "[pid]SIPpTag01[call_number]"
+}
+
+// send returns the <send> command of a message sent by the side
+func (g *generator) send(message *capture.Message) (string, error) {
+	lines := []string{g.replaceAddresses(message.StartLine)}
+	request := message.IsRequest()
+
+	replacedResponseHeaders := map[string]bool{}
+	for _, header := range message.Headers {
+		name := header.CanonicalName()
+		value := header.Value
+
+		if keyword, ok := responseHeaders[name]; ok && !request {
+			// The headers repeated in the request, such as Via, are all sent again by the keyword
+			if replacedResponseHeaders[name] {
+				continue
+			}
+			replacedResponseHeaders[name] = true
+			if name == "to" && g.localTag != "" && tagOf(value) == g.localTag &&
+				(g.lastRequest == nil || tagOf(g.lastRequest.Header("to")) != g.localTag) {
+				keyword += ";tag=" + g.localTagKeyword()
+			}
+			lines = append(lines, keyword)
+			continue
+		}
+
+		switch name {
+		case "call-id":
+			value = "[call_id]"
+		case "content-length":
+			value = "[len]"
+		case "via":
+			value = viaTransport.ReplaceAllString(value, "SIP/2.0/[transport]")
+			value = branchRegexp.ReplaceAllString(value, ";branch=[branch]")
+			value = g.replaceAddresses(value)
+		case "from", "to":
+			value = g.replaceTags(name, g.replaceAddresses(value))
+		default:
+			value = g.replaceAddresses(value)
+		}
+		lines = append(lines, header.Name+": "+value)
+	}
+
+	if !request {
+		// sipp needs the headers of the request
+		for _, name := range []string{"via", "from", "to", "call-id", "cseq"} {
+			if !replacedResponseHeaders[name] {
+				lines = append(lines, responseHeaders[name])
+			}
+		}
+	}
+
+	text := strings.Join(lines, "\n") + "\n\n"
+	if message.Body != "" {
+		body := replaceToken(message.Body, g.local.IP.String(), "[media_ip]")
+		body = mediaIPTypeRegex.ReplaceAllString(body, "IN IP[media_ip_type] [media_ip]")
+		body = mediaPortRegexp.ReplaceAllString(body, "m=audio [media_port]")
+		text += strings.Replace(strings.TrimRight(body, "\r\n"), "\r\n", "\n", -1) + "\n"
+	}
+	if strings.Contains(text, "]]>") {
+		return "", fmt.Errorf("the message %q can't be put in a CDATA section", message.StartLine)
+	}
+
+	attributes := ""
+	if request && message.Transport == capture.TransportUDP && message.Method() != "ACK" {
+		attributes = ` retrans="500"`
+	}
+	return fmt.Sprintf("<send%s>\n    <![CDATA[\n%s    ]]>\n  </send>", attributes, indent(text, "      ")), nil
+}
+
+// replaceTags replaces the tags of the From or To header of a request
+func (g *generator) replaceTags(name, value string) string {
+	if g.localTag != "" {
+		value = strings.Replace(value, "tag="+g.localTag, "tag="+g.localTagKeyword(), 1)
+	}
+	if g.remoteTag == "" || !strings.Contains(value, "tag="+g.remoteTag) {
+		return value
+	}
+
+	// sipp keeps the tag of the callee, the callee side captures the tag of the caller
+	if g.caller && name == "to" {
+		return strings.Replace(value, ";tag="+g.remoteTag, "", 1) + "[peer_tag_param]"
+	}
+	g.remoteTagVariable = true
+	return strings.Replace(value, "tag="+g.remoteTag, "tag=[$remote_tag]", 1)
+}
+
+// replaceAddresses replaces the addresses of both sides by keywords
+func (g *generator) replaceAddresses(value string) string {
+	value = replaceToken(value, g.local.String(), "[local_ip]:[local_port]")
+	value = replaceToken(value, g.remote.String(), "[remote_ip]:[remote_port]")
+	value = replaceToken(value, g.local.IP.String(), "[local_ip]")
+	return replaceToken(value, g.remote.IP.String(), "[remote_ip]")
+}
+
+// replaceToken replaces the occurrences of old which are not part of a longer address or number
+func replaceToken(value, old, new string) string {
+	result := &strings.Builder{}
+	for {
+		index := strings.Index(value, old)
+		if index < 0 {
+			result.WriteString(value)
+			return result.String()
+		}
+
+		end := index + len(old)
+		before := index > 0 && isAddressChar(value[index-1])
+		after := end < len(value) && value[end] >= '0' && value[end] <= '9'
+		result.WriteString(value[:index])
+		if before || after {
+			result.WriteString(old)
+		} else {
+			result.WriteString(new)
+		}
+		value = value[end:]
+	}
+}
+
+func isAddressChar(c byte) bool {
+	return c >= '0' && c <= '9' || c == '.'
+}
+
+// recv returns the <recv> command of a message received by the side
+func (g *generator) recv(message *capture.Message) string {
+	if !message.IsRequest() {
+		optional := ""
+		if message.StatusCode() < 200 {
+			optional = ` optional="true"`
+		}
+		return fmt.Sprintf(`<recv response="%d"%s>`+"\n  </recv>", message.StatusCode(), optional)
+	}
+	return fmt.Sprintf(`<recv request="%s">`+"\n  </recv>", message.Method())
+}
+
+// render returns the scenario of the commands
+func (g *generator) render(name string, commands []*command) string {
+	// The tag of the caller is captured from the first request received
+	if g.remoteTagVariable {
+		for _, c := range commands {
+			if !c.message.Src.Equal(g.remote) || !c.message.IsRequest() || tagOf(c.message.Header("from")) != g.remoteTag {
+				continue
+			}
+			c.element = strings.Replace(c.element, "\n  </recv>", `
+    <action>
+      <ereg regexp=";tag=([^;>]*)" search_in="hdr" header="From:" assign_to="remote_tag_match,remote_tag" />
+    </action>
+  </recv>`, 1)
+			break
+		}
+	}
+
+	result := &strings.Builder{}
+	result.WriteString(`<?xml version="1.0" encoding="ISO-8859-1" ?>` + "\n")
+	fmt.Fprintf(result, "<scenario name=\"%s\">\n", xmlEscape(name))
+	for _, c := range commands {
+		if c.pause > 0 {
+			fmt.Fprintf(result, "  <pause milliseconds=\"%d\"/>\n", c.pause.Milliseconds())
+		}
+		fmt.Fprintf(result, "  <!-- %s %s -> %s -->\n", xmlEscape(summaryOf(c.message)), c.message.Src, c.message.Dst)
+		fmt.Fprintf(result, "  %s\n", c.element)
+	}
+	if g.remoteTagVariable {
+		result.WriteString("  <Reference variables=\"remote_tag_match\"/>\n")
+	}
+	result.WriteString("</scenario>\n")
+
+	return result.String()
+}
+
+// summaryOf returns the method or the status of the message
+func summaryOf(message *capture.Message) string {
+	if message.IsRequest() {
+		return message.Method()
+	}
+	return strings.TrimPrefix(message.StartLine, "SIP/2.0 ")
+}
+
+func indent(text, prefix string) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func xmlEscape(value string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "--", "- -").Replace(value)
+}
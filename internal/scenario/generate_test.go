@@ -0,0 +1,143 @@
+package scenario_test
+
+import (
+	"net"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	"github.com/alexandrevilain/sipp-operator/internal/capture"
+	"github.com/alexandrevilain/sipp-operator/internal/scenario"
+)
+
+var (
+	caller = capture.Endpoint{IP: net.ParseIP("10.0.0.1"), Port: 5060}
+	callee = capture.Endpoint{IP: net.ParseIP("10.0.0.2"), Port: 5080}
+	proxy  = capture.Endpoint{IP: net.ParseIP("10.0.0.3"), Port: 5060}
+	start  = time.Unix(1600000000, 0)
+)
+
+// message returns a message of the dialog captured at the offset
+func message(offset time.Duration, src, dst capture.Endpoint, startLine string, headers []string, body string) *capture.Message {
+	m := &capture.Message{Time: start.Add(offset), Transport: capture.TransportUDP, Src: src, Dst: dst, StartLine: startLine, Body: body}
+	for _, header := range headers {
+		parts := strings.SplitN(header, ": ", 2)
+		m.Headers = append(m.Headers, capture.Header{Name: parts[0], Value: parts[1]})
+	}
+	return m
+}
+
+func dialog() []*capture.Message {
+	from := "From: <sip:alice@10.0.0.1>;tag=a1"
+	to := "To: <sip:bob@10.0.0.2:5080>"
+	toTagged := to + ";tag=b2"
+	callID := "Call-ID: 42@10.0.0.1"
+	via := "Via: SIP/2.0/UDP 10.0.0.1:5060;branch=z9hG4bK-inv"
+	sdp := "v=0\r\no=- 1 1 IN IP4 10.0.0.2\r\nc=IN IP4 10.0.0.2\r\nm=audio 30000 RTP/AVP 0\r\n"
+
+	invite := message(0, caller, callee, "INVITE sip:bob@10.0.0.2:5080 SIP/2.0",
+		[]string{via, from, to, callID, "CSeq: 1 INVITE", "Contact: <sip:alice@10.0.0.1:5060>", "Content-Length: 0"}, "")
+	return []*capture.Message{
+		invite,
+		// A retransmission
+		message(500*time.Millisecond, caller, callee, invite.StartLine, []string{via, from, to, callID, "CSeq: 1 INVITE", "Contact: <sip:alice@10.0.0.1:5060>", "Content-Length: 0"}, ""),
+		message(510*time.Millisecond, callee, caller, "SIP/2.0 100 Trying", []string{via, from, to, callID, "CSeq: 1 INVITE", "Content-Length: 0"}, ""),
+		message(time.Second, callee, caller, "SIP/2.0 180 Ringing", []string{via, from, toTagged, callID, "CSeq: 1 INVITE", "Content-Length: 0"}, ""),
+		message(3*time.Second, callee, caller, "SIP/2.0 200 OK",
+			[]string{via, from, toTagged, callID, "CSeq: 1 INVITE", "Contact: <sip:bob@10.0.0.2:5080>", "Content-Type: application/sdp", "Content-Length: 72"}, sdp),
+		message(3010*time.Millisecond, caller, callee, "ACK sip:bob@10.0.0.2:5080 SIP/2.0",
+			[]string{"Via: SIP/2.0/UDP 10.0.0.1:5060;branch=z9hG4bK-ack", from, toTagged, callID, "CSeq: 1 ACK", "Content-Length: 0"}, ""),
+		// A message of another leg of the call
+		message(4*time.Second, proxy, callee, "OPTIONS sip:bob@10.0.0.2:5080 SIP/2.0", []string{callID, "CSeq: 2 OPTIONS"}, ""),
+		message(8*time.Second, callee, caller, "BYE sip:alice@10.0.0.1:5060 SIP/2.0",
+			[]string{"Via: SIP/2.0/UDP 10.0.0.2:5080;branch=z9hG4bK-bye", "From: <sip:bob@10.0.0.2:5080>;tag=b2", "To: <sip:alice@10.0.0.1>;tag=a1", callID, "CSeq: 1 BYE", "Content-Length: 0"}, ""),
+		message(8010*time.Millisecond, caller, callee, "SIP/2.0 200 OK",
+			[]string{"Via: SIP/2.0/UDP 10.0.0.2:5080;branch=z9hG4bK-bye", "To: <sip:alice@10.0.0.1>;tag=a1", "From: <sip:bob@10.0.0.2:5080>;tag=b2", callID, "CSeq: 1 BYE", "Content-Length: 0"}, ""),
+	}
+}
+
+func summarize(t *testing.T, content string) *v1alpha1.ScenarioSummary {
+	assert.NoError(t, scenario.Validate(content))
+	root, err := scenario.Parse(content)
+	require.NoError(t, err)
+	return scenario.Summarize(root)
+}
+
+func TestGenerateCaller(t *testing.T) {
+	content, err := scenario.Generate(dialog(), scenario.GenerateOptions{Name: "customer call"})
+	require.NoError(t, err)
+
+	summary := summarize(t, content)
+	assert.Equal(t, "customer call", summary.Name)
+	assert.Equal(t, []v1alpha1.ScenarioMessage{
+		{Direction: v1alpha1.MessageDirectionSend, Method: "INVITE"},
+		{Direction: v1alpha1.MessageDirectionRecv, ResponseCode: 100, Optional: true},
+		{Direction: v1alpha1.MessageDirectionRecv, ResponseCode: 180, Optional: true},
+		{Direction: v1alpha1.MessageDirectionRecv, ResponseCode: 200},
+		{Direction: v1alpha1.MessageDirectionSend, Method: "ACK"},
+		{Direction: v1alpha1.MessageDirectionRecv, Method: "BYE"},
+		{Direction: v1alpha1.MessageDirectionSend, ResponseCode: 200},
+	}, summary.Messages)
+
+	assert.Contains(t, content, `<send retrans="500">`)
+	assert.Contains(t, content, "INVITE sip:bob@[remote_ip]:[remote_port] SIP/2.0")
+	assert.Contains(t, content, "Via: SIP/2.0/[transport] [local_ip]:[local_port];branch=[branch]")
+	assert.Contains(t, content, "From: <sip:alice@[local_ip]>;tag=[pid]SIPpTag00[call_number]")
+	assert.Contains(t, content, "Call-ID: [call_id]")
+	assert.Contains(t, content, "Content-Length: [len]")
+	assert.Contains(t, content, "To: <sip:bob@[remote_ip]:[remote_port]>[peer_tag_param]")
+	assert.NotContains(t, content, "<pause")
+	assert.Contains(t, content, "[last_Via:]\n      [last_To:]\n      [last_From:]\n      [last_Call-ID:]\n      [last_CSeq:]")
+	assert.NotContains(t, content, "OPTIONS")
+
+	// The comments keep the captured addresses
+	messages := regexp.MustCompile(`<!--.*-->`).ReplaceAllString(content, "")
+	assert.NotContains(t, messages, "10.0.0.")
+	assert.NotContains(t, messages, "z9hG4bK")
+}
+
+func TestGenerateCallee(t *testing.T) {
+	content, err := scenario.Generate(dialog(), scenario.GenerateOptions{Side: "10.0.0.2:5080"})
+	require.NoError(t, err)
+
+	summary := summarize(t, content)
+	assert.Equal(t, "42@10.0.0.1", summary.Name)
+	assert.Equal(t, []v1alpha1.ScenarioMessage{
+		{Direction: v1alpha1.MessageDirectionRecv, Method: "INVITE"},
+		{Direction: v1alpha1.MessageDirectionSend, ResponseCode: 100},
+		{Direction: v1alpha1.MessageDirectionSend, ResponseCode: 180},
+		{Direction: v1alpha1.MessageDirectionSend, ResponseCode: 200},
+		{Direction: v1alpha1.MessageDirectionRecv, Method: "ACK"},
+		{Direction: v1alpha1.MessageDirectionSend, Method: "BYE"},
+		{Direction: v1alpha1.MessageDirectionRecv, ResponseCode: 200},
+	}, summary.Messages)
+
+	assert.Contains(t, content, "[last_To:]\n")
+	assert.Contains(t, content, "[last_To:];tag=[pid]SIPpTag01[call_number]")
+	assert.Contains(t, content, "c=IN IP[media_ip_type] [media_ip]")
+	assert.Contains(t, content, "m=audio [media_port] RTP/AVP 0")
+	assert.Contains(t, content, `<ereg regexp=";tag=([^;>]*)"`)
+	assert.Contains(t, content, "From: <sip:bob@[local_ip]:[local_port]>;tag=[pid]SIPpTag01[call_number]")
+	assert.Contains(t, content, "To: <sip:alice@[remote_ip]>;tag=[$remote_tag]")
+	assert.Contains(t, content, `<Reference variables="remote_tag_match"/>`)
+	// The callee took 2 seconds to answer, and hung up 5 seconds after answering
+	assert.Contains(t, content, `<pause milliseconds="2000"/>`)
+	assert.Contains(t, content, `<pause milliseconds="4990"/>`)
+
+	callee, err := scenario.Generate(dialog(), scenario.GenerateOptions{Side: scenario.SideCallee})
+	require.NoError(t, err)
+	assert.Equal(t, content, callee)
+}
+
+func TestGenerateErrors(t *testing.T) {
+	_, err := scenario.Generate(dialog(), scenario.GenerateOptions{Side: "10.0.0.9"})
+	assert.Error(t, err)
+
+	_, err = scenario.Generate(dialog()[2:3], scenario.GenerateOptions{})
+	assert.Error(t, err)
+}
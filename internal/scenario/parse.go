@@ -0,0 +1,133 @@
+package scenario
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Node is an element of a scenario file
+type Node struct {
+	Name string
+	// Attrs holds the element attributes, in document order
+	Attrs []xml.Attr
+	// Line is the line of the element's start tag
+	Line int
+	// Text holds the character data of the element, including CDATA sections
+	Text     string
+	Children []*Node
+}
+
+// Attr returns the value of the attribute and whether it is set
+func (n *Node) Attr(name string) (string, bool) {
+	for _, attr := range n.Attrs {
+		if attr.Name.Local == name {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
+
+// Error is a problem found in a scenario file
+type Error struct {
+	Line    int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// ErrorList is a list of problems found in a scenario file
+type ErrorList []*Error
+
+func (l ErrorList) Error() string {
+	messages := make([]string, 0, len(l))
+	for _, err := range l {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Parse parses the scenario file content and returns its root element.
+// Syntax errors are returned as *Error.
+func Parse(content string) (*Node, error) {
+	// sipp scenarios are often declared as ISO-8859-1,
+	// which is converted so that the decoder only deals with UTF-8
+	if !utf8.ValidString(content) {
+		content = latin1ToUTF8(content)
+	}
+
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+
+	var root *Node
+	stack := []*Node{}
+
+	for {
+		offset := decoder.InputOffset()
+
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if syntaxErr, ok := err.(*xml.SyntaxError); ok {
+				return nil, &Error{Line: syntaxErr.Line, Message: syntaxErr.Msg}
+			}
+			return nil, &Error{Line: lineAt(content, decoder.InputOffset()), Message: err.Error()}
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &Node{
+				Name:  t.Name.Local,
+				Attrs: t.Attr,
+				Line:  lineAt(content, offset),
+			}
+
+			if len(stack) == 0 {
+				if root != nil {
+					return nil, &Error{Line: node.Line, Message: "unexpected element after the root element"}
+				}
+				root = node
+			} else {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, node)
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].Text += string(t)
+			}
+		}
+	}
+
+	if root == nil {
+		return nil, &Error{Line: 1, Message: "no root element"}
+	}
+
+	return root, nil
+}
+
+// lineAt returns the line of the byte offset in the content
+func lineAt(content string, offset int64) int {
+	if offset > int64(len(content)) {
+		offset = int64(len(content))
+	}
+	return strings.Count(content[:offset], "\n") + 1
+}
+
+func latin1ToUTF8(content string) string {
+	runes := make([]rune, 0, len(content))
+	for i := 0; i < len(content); i++ {
+		runes = append(runes, rune(content[i]))
+	}
+	return string(runes)
+}
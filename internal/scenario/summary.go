@@ -0,0 +1,142 @@
+package scenario
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+)
+
+var (
+	variableRegexp = regexp.MustCompile(`\[\$([A-Za-z0-9_]+)\]`)
+	fieldRegexp    = regexp.MustCompile(`\[field([0-9]+)[\s\]]`)
+	keywordRegexp  = regexp.MustCompile(`\[([A-Za-z_][A-Za-z0-9_-]*)[\s:\]]`)
+)
+
+// Summarize returns a summary of the scenario file
+func Summarize(root *Node) *v1alpha1.ScenarioSummary {
+	summary := &v1alpha1.ScenarioSummary{}
+	summary.Name, _ = root.Attr("name")
+
+	variables := map[string]bool{}
+	fields := map[int32]bool{}
+	keywords := map[string]bool{}
+
+	root.walk(func(node *Node) {
+		for _, attr := range node.Attrs {
+			switch attr.Name.Local {
+			case "assign_to", "variables":
+				for _, name := range strings.Split(attr.Value, ",") {
+					if name = strings.TrimSpace(name); name != "" {
+						variables[name] = true
+					}
+				}
+			case "variable":
+				variables[attr.Value] = true
+			}
+		}
+
+		for _, match := range variableRegexp.FindAllStringSubmatch(node.Text, -1) {
+			variables[match[1]] = true
+		}
+
+		for _, match := range fieldRegexp.FindAllStringSubmatch(node.Text, -1) {
+			index, err := strconv.ParseInt(match[1], 10, 32)
+			if err == nil {
+				fields[int32(index)] = true
+			}
+		}
+
+		for _, match := range keywordRegexp.FindAllStringSubmatch(node.Text, -1) {
+			if !fieldRegexp.MatchString(match[0]) {
+				keywords[match[1]] = true
+			}
+		}
+	})
+
+	for _, child := range root.Children {
+		if message, ok := summarizeMessage(child); ok {
+			summary.Messages = append(summary.Messages, message)
+		}
+	}
+	summary.MessageCount = int32(len(summary.Messages))
+
+	for name := range variables {
+		summary.Variables = append(summary.Variables, name)
+	}
+	sort.Strings(summary.Variables)
+
+	for index := range fields {
+		summary.Fields = append(summary.Fields, index)
+	}
+	sort.Slice(summary.Fields, func(i, j int) bool { return summary.Fields[i] < summary.Fields[j] })
+	summary.FieldCount = int32(len(summary.Fields))
+
+	for keyword := range keywords {
+		summary.Keywords = append(summary.Keywords, keyword)
+	}
+	sort.Strings(summary.Keywords)
+
+	return summary
+}
+
+// summarizeMessage returns the message sent or received by the command,
+// and false if the command doesn't handle a SIP message
+func summarizeMessage(command *Node) (v1alpha1.ScenarioMessage, bool) {
+	message := v1alpha1.ScenarioMessage{}
+
+	switch command.Name {
+	case "send":
+		message.Direction = v1alpha1.MessageDirectionSend
+
+		firstLine := strings.TrimSpace(command.Text)
+		if i := strings.IndexByte(firstLine, '\n'); i >= 0 {
+			firstLine = firstLine[:i]
+		}
+
+		parts := strings.Fields(firstLine)
+		switch {
+		case len(parts) == 0:
+			return message, false
+		case parts[0] == "SIP/2.0" && len(parts) > 1:
+			message.ResponseCode = parseResponseCode(parts[1])
+		default:
+			message.Method = parts[0]
+		}
+	case "recv":
+		message.Direction = v1alpha1.MessageDirectionRecv
+
+		if code, ok := command.Attr("response"); ok {
+			message.ResponseCode = parseResponseCode(code)
+		} else if method, ok := command.Attr("request"); ok {
+			message.Method = method
+		} else {
+			return message, false
+		}
+
+		optional, _ := command.Attr("optional")
+		message.Optional = optional == "true" || optional == "global"
+	default:
+		return message, false
+	}
+
+	return message, true
+}
+
+func parseResponseCode(value string) int32 {
+	code, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		return 0
+	}
+	return int32(code)
+}
+
+// walk calls fn on the node and all its descendants
+func (n *Node) walk(fn func(*Node)) {
+	fn(n)
+	for _, child := range n.Children {
+		child.walk(fn)
+	}
+}
@@ -0,0 +1,64 @@
+package scenario_test
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	"github.com/alexandrevilain/sipp-operator/internal/scenario"
+)
+
+func TestSummarizeSample(t *testing.T) {
+	content, err := ioutil.ReadFile("testdata/uac.xml")
+	assert.NoError(t, err)
+
+	root, err := scenario.Parse(string(content))
+	assert.NoError(t, err)
+
+	summary := scenario.Summarize(root)
+	assert.Equal(t, "Basic Sipstone UAC", summary.Name)
+	assert.Equal(t, []v1alpha1.ScenarioMessage{
+		{Direction: v1alpha1.MessageDirectionSend, Method: "INVITE"},
+		{Direction: v1alpha1.MessageDirectionRecv, ResponseCode: 100, Optional: true},
+		{Direction: v1alpha1.MessageDirectionRecv, ResponseCode: 180, Optional: true},
+		{Direction: v1alpha1.MessageDirectionRecv, ResponseCode: 183, Optional: true},
+		{Direction: v1alpha1.MessageDirectionRecv, ResponseCode: 200},
+		{Direction: v1alpha1.MessageDirectionSend, Method: "ACK"},
+		{Direction: v1alpha1.MessageDirectionSend, Method: "BYE"},
+		{Direction: v1alpha1.MessageDirectionRecv, ResponseCode: 200},
+	}, summary.Messages)
+	assert.Equal(t, int32(8), summary.MessageCount)
+	assert.Contains(t, summary.Keywords, "service")
+	assert.Contains(t, summary.Keywords, "call_id")
+	assert.Empty(t, summary.Fields)
+}
+
+func TestSummarizeFieldsAndVariables(t *testing.T) {
+	content := `<scenario name="register">
+  <send>
+    <![CDATA[
+      REGISTER sip:[remote_ip] SIP/2.0
+      From: <sip:[field0]@[field2 file="users.csv"]>
+      Authorization: [field1] [$nonce]
+      Via: SIP/2.0/[transport] [local_ip]:[local_port];branch=[branch]
+      [last_Via:]
+    ]]>
+  </send>
+  <recv response="401" auth="true">
+    <action>
+      <ereg regexp="nonce=(.*)" search_in="hdr" header="WWW-Authenticate:" assign_to="dummy,nonce" />
+    </action>
+  </recv>
+</scenario>`
+
+	root, err := scenario.Parse(content)
+	assert.NoError(t, err)
+
+	summary := scenario.Summarize(root)
+	assert.Equal(t, []int32{0, 1, 2}, summary.Fields)
+	assert.Equal(t, int32(3), summary.FieldCount)
+	assert.Equal(t, []string{"dummy", "nonce"}, summary.Variables)
+	assert.Equal(t, []string{"branch", "last_Via", "local_ip", "local_port", "remote_ip", "transport"}, summary.Keywords)
+}
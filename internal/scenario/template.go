@@ -0,0 +1,39 @@
+package scenario
+
+import (
+	"bytes"
+	"encoding/xml"
+	"text/template"
+)
+
+// Render executes the scenario file content as a template,
+// the parameter values are referenced as {{ .name }}.
+// String values are XML-escaped, unless their parameter is raw.
+// Referencing an undeclared parameter is an error.
+func Render(content string, values map[string]interface{}, raw map[string]bool) (string, error) {
+	tmpl, err := template.New("scenario").Option("missingkey=error").Parse(content)
+	if err != nil {
+		return "", err
+	}
+
+	escaped := make(map[string]interface{}, len(values))
+	for name, value := range values {
+		text, ok := value.(string)
+		if !ok || raw[name] {
+			escaped[name] = value
+			continue
+		}
+		var buffer bytes.Buffer
+		if err := xml.EscapeText(&buffer, []byte(text)); err != nil {
+			return "", err
+		}
+		escaped[name] = buffer.String()
+	}
+
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, escaped); err != nil {
+		return "", err
+	}
+
+	return rendered.String(), nil
+}
@@ -0,0 +1,45 @@
+package scenario_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alexandrevilain/sipp-operator/internal/scenario"
+)
+
+func TestRender(t *testing.T) {
+	content := `<scenario>
+  <pause milliseconds="{{ .pause_ms }}"/>{{ if .auth }}
+  <recv response="401" auth="true"/>{{ end }}
+  <send><![CDATA[a=rtpmap:8 {{ .codec }}/8000 [call_id]]]></send>
+</scenario>`
+
+	rendered, err := scenario.Render(content, map[string]interface{}{"pause_ms": int64(500), "auth": false, "codec": "PCMA"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, `<scenario>
+  <pause milliseconds="500"/>
+  <send><![CDATA[a=rtpmap:8 PCMA/8000 [call_id]]]></send>
+</scenario>`, rendered)
+
+	_, err = scenario.Render(content, map[string]interface{}{"pause_ms": int64(500), "auth": false}, nil)
+	assert.Error(t, err)
+
+	_, err = scenario.Render("<scenario>{{ .codec </scenario>", nil, nil)
+	assert.Error(t, err)
+}
+
+func TestRenderEscapesValues(t *testing.T) {
+	content := `<scenario name="{{ .name }}">
+  <send><![CDATA[From: {{ .from }}]]></send>
+</scenario>`
+	values := map[string]interface{}{"name": `Q&A "call" <1>`, "from": `"Alice & Bob" <sip:alice@example.com>`}
+
+	// Values are escaped, unless raw
+	rendered, err := scenario.Render(content, values, map[string]bool{"from": true})
+	assert.NoError(t, err)
+	assert.Equal(t, `<scenario name="Q&amp;A &#34;call&#34; &lt;1&gt;">
+  <send><![CDATA[From: "Alice & Bob" <sip:alice@example.com>]]></send>
+</scenario>`, rendered)
+	assert.NoError(t, scenario.Validate(rendered))
+}
@@ -0,0 +1,272 @@
+package scenario
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// commonAttributes are accepted by all the scenario commands
+var commonAttributes = []string{
+	"start_rtd", "rtd", "repeat_rtd", "crlf", "next", "test", "chance",
+	"condexec", "condexec_inverse", "counter", "display", "hide",
+}
+
+// pauseAttributes configure the duration of a pause
+var pauseAttributes = []string{
+	"milliseconds", "variable", "distribution", "sanity_check",
+	"value", "min", "max", "mean", "stdev", "lambda", "k", "theta", "x_m", "n", "p",
+}
+
+// commandAttributes lists the attributes accepted by each scenario command,
+// on top of the common ones
+var commandAttributes = map[string][]string{
+	"send": {
+		"retrans", "lost", "start_txn", "ack_txn", "timeout", "ontimeout",
+	},
+	"recv": {
+		"response", "request", "optional", "rrs", "auth", "lost", "timeout", "ontimeout",
+		"regexp_match", "response_txn", "ignoresdp",
+	},
+	"pause":   pauseAttributes,
+	"nop":     {},
+	"sendCmd": {"dest"},
+	"recvCmd": {"src", "timeout", "ontimeout"},
+}
+
+// globalElements are the scenario children which are not commands
+var globalElements = map[string][]string{
+	"label":                   {"id"},
+	"ResponseTimeRepartition": {"value"},
+	"CallLengthRepartition":   {"value"},
+	"Global":                  {"variables"},
+	"User":                    {"variables"},
+	"Reference":               {"variables"},
+}
+
+// initElements are the elements accepted in the <init> section,
+// which sipp runs once before placing any call
+var initElements = map[string][]string{
+	"label": {"id"},
+	"nop":   commandAttributes["nop"],
+}
+
+// actions are the elements accepted in an <action> block
+var actions = map[string]bool{
+	"ereg": true, "log": true, "warning": true, "error": true, "exec": true,
+	"assign": true, "add": true, "subtract": true, "multiply": true, "divide": true,
+	"sample": true, "todouble": true, "test": true, "strcmp": true, "verifyauth": true,
+	"lookup": true, "insert": true, "replace": true, "setdest": true, "jump": true,
+	"gettimeofday": true, "urldecode": true, "urlencode": true, "trim": true,
+	"index": true, "rtp_echo": true,
+}
+
+// Validate checks that the content is a well-formed sipp scenario.
+// It returns an ErrorList, or nil if no problem was found.
+func Validate(content string) error {
+	root, err := Parse(content)
+	if err != nil {
+		return ErrorList{err.(*Error)}
+	}
+
+	v := &validator{}
+	v.validate(root)
+
+	if len(v.errs) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(v.errs, func(i, j int) bool {
+		return v.errs[i].Line < v.errs[j].Line
+	})
+	return v.errs
+}
+
+type validator struct {
+	errs ErrorList
+}
+
+func (v *validator) addError(node *Node, format string, args ...interface{}) {
+	v.errs = append(v.errs, &Error{Line: node.Line, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) validate(root *Node) {
+	if root.Name != "scenario" {
+		v.addError(root, "root element must be <scenario>, got <%s>", root.Name)
+		return
+	}
+	v.checkAttributes(root, []string{"name"})
+
+	labels := map[string]bool{}
+	for _, child := range root.Children {
+		if child.Name == "label" {
+			if id, ok := child.Attr("id"); ok {
+				labels[id] = true
+			}
+		}
+	}
+
+	sentRequest := false
+	receivedRequest := false
+	commands := 0
+
+	for _, child := range root.Children {
+		if child.Name == "init" {
+			v.checkInit(child)
+			continue
+		}
+
+		if allowed, ok := globalElements[child.Name]; ok {
+			v.checkAttributes(child, allowed)
+			switch child.Name {
+			case "label":
+				if _, ok := child.Attr("id"); !ok {
+					v.addError(child, "<label> must have an id attribute")
+				}
+			case "ResponseTimeRepartition", "CallLengthRepartition":
+				v.checkRepartition(child)
+			}
+			continue
+		}
+
+		allowed, ok := commandAttributes[child.Name]
+		if !ok {
+			v.addError(child, "unknown element <%s>", child.Name)
+			continue
+		}
+		commands++
+
+		v.checkAttributes(child, append(append([]string{}, commonAttributes...), allowed...))
+		v.checkChildren(child)
+
+		if next, ok := child.Attr("next"); ok && !labels[next] {
+			v.addError(child, "next refers to the undefined label %q", next)
+		}
+
+		switch child.Name {
+		case "send":
+			message := strings.TrimSpace(child.Text)
+			if message == "" {
+				v.addError(child, "<send> has no message")
+				continue
+			}
+
+			if strings.HasPrefix(message, "SIP/2.0") {
+				if !receivedRequest {
+					v.addError(child, "<send> of a response before any request was received")
+				}
+			} else {
+				sentRequest = true
+			}
+		case "recv":
+			_, isResponse := child.Attr("response")
+			_, isRequest := child.Attr("request")
+
+			switch {
+			case isResponse && isRequest:
+				v.addError(child, "<recv> can't have both response and request attributes")
+			case isResponse:
+				if !sentRequest {
+					v.addError(child, "<recv> of a response before any request was sent")
+				}
+				if code, _ := child.Attr("response"); !isResponseCode(code) {
+					v.addError(child, "invalid response code %q", code)
+				}
+			case isRequest:
+				receivedRequest = true
+			default:
+				v.addError(child, "<recv> must have a response or a request attribute")
+			}
+		}
+	}
+
+	if commands == 0 {
+		v.addError(root, "scenario has no <send>, <recv>, <pause> or <nop> command")
+	}
+}
+
+// checkAttributes reports the attributes of the node which are not in the allowed list
+func (v *validator) checkAttributes(node *Node, allowed []string) {
+	known := map[string]bool{}
+	for _, name := range allowed {
+		known[name] = true
+	}
+
+	unknown := []string{}
+	for _, attr := range node.Attrs {
+		if attr.Name.Space == "" && !known[attr.Name.Local] {
+			unknown = append(unknown, attr.Name.Local)
+		}
+	}
+	sort.Strings(unknown)
+
+	for _, name := range unknown {
+		v.addError(node, "unknown attribute %q on <%s>", name, node.Name)
+	}
+}
+
+// checkInit reports the unknown elements and attributes of the <init> section
+func (v *validator) checkInit(init *Node) {
+	v.checkAttributes(init, nil)
+
+	for _, child := range init.Children {
+		allowed, ok := initElements[child.Name]
+		if !ok {
+			v.addError(child, "unknown element <%s> in <init>", child.Name)
+			continue
+		}
+
+		if child.Name == "label" {
+			v.checkAttributes(child, allowed)
+			continue
+		}
+		v.checkAttributes(child, append(append([]string{}, commonAttributes...), allowed...))
+		v.checkChildren(child)
+	}
+}
+
+// checkChildren reports the unknown elements nested in a command
+func (v *validator) checkChildren(command *Node) {
+	for _, child := range command.Children {
+		if child.Name != "action" {
+			v.addError(child, "unknown element <%s> in <%s>", child.Name, command.Name)
+			continue
+		}
+
+		for _, action := range child.Children {
+			if !actions[action.Name] {
+				v.addError(action, "unknown action <%s>", action.Name)
+			}
+		}
+	}
+}
+
+// checkRepartition checks that the value is a list of increasing positive integers
+func (v *validator) checkRepartition(node *Node) {
+	value, ok := node.Attr("value")
+	if !ok {
+		v.addError(node, "<%s> must have a value attribute", node.Name)
+		return
+	}
+
+	previous := 0
+	for _, part := range strings.Split(value, ",") {
+		bound, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || bound <= 0 {
+			v.addError(node, "<%s> value %q must be a list of positive integers", node.Name, value)
+			return
+		}
+		if bound <= previous {
+			v.addError(node, "<%s> value %q must be in increasing order", node.Name, value)
+			return
+		}
+		previous = bound
+	}
+}
+
+// isResponseCode returns whether the value is a SIP response code
+func isResponseCode(value string) bool {
+	code, err := strconv.Atoi(value)
+	return err == nil && code >= 100 && code <= 699
+}
@@ -0,0 +1,107 @@
+package scenario_test
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/alexandrevilain/sipp-operator/internal/scenario"
+)
+
+func TestValidateSample(t *testing.T) {
+	content, err := ioutil.ReadFile("testdata/uac.xml")
+	assert.NoError(t, err)
+
+	assert.NoError(t, scenario.Validate(string(content)))
+}
+
+func TestValidateInit(t *testing.T) {
+	content, err := ioutil.ReadFile("testdata/uac_init.xml")
+	assert.NoError(t, err)
+	assert.NoError(t, scenario.Validate(string(content)))
+
+	err = scenario.Validate(`<scenario>
+  <init>
+    <send><![CDATA[OPTIONS sip:[service]@[remote_ip] SIP/2.0]]></send>
+    <nop hidden="true"><action><jump value="1" /></action></nop>
+  </init>
+  <send><![CDATA[OPTIONS sip:[service]@[remote_ip] SIP/2.0]]></send>
+</scenario>`)
+	assert.EqualError(t, err, "line 3: unknown element <send> in <init>; line 4: unknown attribute \"hidden\" on <nop>")
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Content  string
+		Expected string
+	}{
+		{
+			Name:     "malformed xml",
+			Content:  "<scenario>\n<send>\n</scenario>",
+			Expected: "line 3: element <send> closed by </scenario>",
+		},
+		{
+			Name:     "wrong root",
+			Content:  `<scenarios></scenarios>`,
+			Expected: "line 1: root element must be <scenario>, got <scenarios>",
+		},
+		{
+			Name: "unknown element and attribute",
+			Content: `<scenario>
+  <recv request="INVITE" />
+  <sned><![CDATA[SIP/2.0 200 OK]]></sned>
+  <send retrans="500" lots="10"><![CDATA[SIP/2.0 200 OK]]></send>
+</scenario>`,
+			Expected: `line 3: unknown element <sned>; line 4: unknown attribute "lots" on <send>`,
+		},
+		{
+			Name: "response before request",
+			Content: `<scenario>
+  <recv response="200" />
+  <send><![CDATA[ACK sip:[service]@[remote_ip]:[remote_port] SIP/2.0]]></send>
+</scenario>`,
+			Expected: "line 2: <recv> of a response before any request was sent",
+		},
+		{
+			Name: "invalid response time repartition",
+			Content: `<scenario>
+  <send><![CDATA[OPTIONS sip:[service]@[remote_ip]:[remote_port] SIP/2.0]]></send>
+  <recv response="200" />
+  <ResponseTimeRepartition value="10, 50, 20"/>
+</scenario>`,
+			Expected: `line 4: <ResponseTimeRepartition> value "10, 50, 20" must be in increasing order`,
+		},
+		{
+			Name: "undefined label and unknown action",
+			Content: `<scenario>
+  <recv request="INVITE" next="answer">
+    <action>
+      <regexp search_in="msg" regexp=".*" assign_to="1" />
+    </action>
+  </recv>
+</scenario>`,
+			Expected: `line 2: next refers to the undefined label "answer"; line 4: unknown action <regexp>`,
+		},
+	}
+
+	for _, test := range tests {
+		err := scenario.Validate(test.Content)
+		if assert.Error(t, err, test.Name) {
+			assert.Equal(t, test.Expected, err.Error(), test.Name)
+		}
+	}
+}
+
+func TestParseLatin1(t *testing.T) {
+	content := "<?xml version=\"1.0\" encoding=\"ISO-8859-1\" ?>\n<scenario name=\"Appel \xe9mis\">\n<pause/>\n</scenario>"
+
+	root, err := scenario.Parse(content)
+	assert.NoError(t, err)
+
+	name, _ := root.Attr("name")
+	assert.Equal(t, "Appel émis", name)
+	assert.Equal(t, 2, root.Line)
+	assert.Equal(t, 3, root.Children[0].Line)
+}
@@ -0,0 +1,69 @@
+// Package shellwords splits command lines into arguments
+package shellwords
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Split splits the command line into arguments like a POSIX shell does,
+// without any expansion: words are separated by blanks, single quotes preserve
+// their content, double quotes and backslashes escape the next character.
+func Split(line string) ([]string, error) {
+	words := []string{}
+	word := &strings.Builder{}
+	inWord := false
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case c == '\\':
+			if i+1 == len(line) {
+				return nil, fmt.Errorf("trailing backslash")
+			}
+			i++
+			word.WriteByte(line[i])
+			inWord = true
+		case c == '\'':
+			end := strings.IndexByte(line[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated single quote at offset %d", i)
+			}
+			word.WriteString(line[i+1 : i+1+end])
+			i += end + 1
+			inWord = true
+		case c == '"':
+			start := i
+			for i++; ; i++ {
+				if i == len(line) {
+					return nil, fmt.Errorf("unterminated double quote at offset %d", start)
+				}
+				if line[i] == '"' {
+					break
+				}
+				// Inside double quotes, backslashes only escape these characters
+				if line[i] == '\\' && i+1 < len(line) && strings.IndexByte("\"\\$`", line[i+1]) >= 0 {
+					i++
+				}
+				word.WriteByte(line[i])
+			}
+			inWord = true
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+
+	return words, nil
+}
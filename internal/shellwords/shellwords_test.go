@@ -0,0 +1,33 @@
+package shellwords_test
+
+import (
+	"testing"
+
+	"github.com/alexandrevilain/sipp-operator/internal/shellwords"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		Line     string
+		Expected []string
+	}{
+		{Line: "-sf  uac.xml\t-m 1", Expected: []string{"-sf", "uac.xml", "-m", "1"}},
+		{Line: `-key name "a b"`, Expected: []string{"-key", "name", "a b"}},
+		{Line: `-key name 'say "hi"' -set x ''`, Expected: []string{"-key", "name", `say "hi"`, "-set", "x", ""}},
+		{Line: `-key path C:\\sipp\ files "\"quoted\" \n"`, Expected: []string{"-key", "path", `C:\sipp files`, `"quoted" \n`}},
+		{Line: `-key a"b c"d`, Expected: []string{"-key", "ab cd"}},
+		{Line: "", Expected: []string{}},
+	}
+
+	for _, test := range tests {
+		words, err := shellwords.Split(test.Line)
+		assert.NoError(t, err, test.Line)
+		assert.Equal(t, test.Expected, words, test.Line)
+	}
+
+	for _, line := range []string{`-key name "a b`, `-key name 'a b`, `-key name \`} {
+		_, err := shellwords.Split(line)
+		assert.Error(t, err, line)
+	}
+}
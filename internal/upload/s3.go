@@ -0,0 +1,220 @@
+package upload
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultRegion is used when the storage doesn't define one, MinIO accepts it too
+	DefaultRegion = "us-east-1"
+
+	signingAlgorithm = "AWS4-HMAC-SHA256"
+	amzDateFormat    = "20060102T150405Z"
+)
+
+// Credentials authenticate the requests to the object storage
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is only set for temporary credentials
+	SessionToken string
+}
+
+// Client uploads objects to a bucket of an S3 compatible object storage,
+// signing its requests with AWS Signature Version 4
+type Client struct {
+	Endpoint    *url.URL
+	Region      string
+	Bucket      string
+	Credentials Credentials
+	// PathStyle puts the bucket in the URL path instead of the host name,
+	// as most on-premise deployments such as MinIO expect
+	PathStyle  bool
+	HTTPClient *http.Client
+	// Now returns the time the requests are signed at
+	Now func() time.Time
+}
+
+// NewClient returns a client for the bucket served by the endpoint
+func NewClient(endpoint, region, bucket string, pathStyle bool, credentials Credentials) (*Client, error) {
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint: %v", err)
+	}
+	if endpointURL.Scheme != "http" && endpointURL.Scheme != "https" {
+		return nil, fmt.Errorf("invalid endpoint %q: scheme must be http or https", endpoint)
+	}
+
+	if region == "" {
+		region = DefaultRegion
+	}
+
+	return &Client{
+		Endpoint:    endpointURL,
+		Region:      region,
+		Bucket:      bucket,
+		Credentials: credentials,
+		PathStyle:   pathStyle,
+		HTTPClient:  http.DefaultClient,
+		Now:         time.Now,
+	}, nil
+}
+
+// ObjectURL returns the URL of the object
+func (c *Client) ObjectURL(key string) string {
+	objectURL := *c.Endpoint
+	if c.PathStyle {
+		objectURL.Path = "/" + c.Bucket + "/" + key
+	} else {
+		objectURL.Host = c.Bucket + "." + c.Endpoint.Host
+		objectURL.Path = "/" + key
+	}
+	objectURL.RawPath = uriEncode(objectURL.Path, false)
+
+	return objectURL.String()
+}
+
+// PutObject uploads the content to the key, and returns the URL of the object
+func (c *Client) PutObject(ctx context.Context, key string, content io.ReadSeeker) (string, error) {
+	// The payload is hashed first, then sent from the start
+	hash := sha256.New()
+	size, err := io.Copy(hash, content)
+	if err != nil {
+		return "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	objectURL := c.ObjectURL(key)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, objectURL, ioutil.NopCloser(content))
+	if err != nil {
+		return "", err
+	}
+	req.ContentLength = size
+
+	c.Sign(req, hex.EncodeToString(hash.Sum(nil)))
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("can't upload %s: unexpected status %s: %s", key, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	return objectURL, nil
+}
+
+// Sign adds the signature of the request to its headers,
+// all the headers set before are signed
+func (c *Client) Sign(req *http.Request, payloadHash string) {
+	now := c.Now().UTC()
+	amzDate := now.Format(amzDateFormat)
+	date := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if c.Credentials.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", c.Credentials.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	canonicalHeaders := &strings.Builder{}
+	for _, name := range names {
+		fmt.Fprintf(canonicalHeaders, "%s:%s\n", name, headers[name])
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		uriEncode(req.URL.Path, false),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{date, c.Region, "s3", "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		signingAlgorithm,
+		amzDate,
+		scope,
+		hexSHA256(canonicalRequest),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+c.Credentials.SecretAccessKey), date)
+	key = hmacSHA256(key, c.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		signingAlgorithm, c.Credentials.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func canonicalQuery(query url.Values) string {
+	params := []string{}
+	for name, values := range query {
+		for _, value := range values {
+			params = append(params, uriEncode(name, true)+"="+uriEncode(value, true))
+		}
+	}
+	sort.Strings(params)
+
+	return strings.Join(params, "&")
+}
+
+// uriEncode encodes all the characters but the unreserved ones,
+// and the slashes unless encodeSlash is set
+func uriEncode(value string, encodeSlash bool) string {
+	encoded := &strings.Builder{}
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			encoded.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			encoded.WriteByte(c)
+		default:
+			fmt.Fprintf(encoded, "%%%02X", c)
+		}
+	}
+	return encoded.String()
+}
+
+func hexSHA256(value string) string {
+	hash := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(hash[:])
+}
+
+func hmacSHA256(key []byte, value string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,164 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+)
+
+const (
+	// DefaultPrefix is the template of the object keys prefix when none is configured
+	DefaultPrefix = "{{ .Namespace }}/{{ .Run }}"
+	// SummaryKey is the key of the results summary, under the prefix
+	SummaryKey = "summary.json"
+
+	// AccessKeyIDKey is the key of the access key ID in the credentials Secrets
+	AccessKeyIDKey = "accessKeyID"
+	// SecretAccessKeyKey is the key of the secret access key in the credentials Secrets
+	SecretAccessKeyKey = "secretAccessKey"
+	// SessionTokenKey is the optional key of the session token in the credentials Secrets
+	SessionTokenKey = "sessionToken"
+)
+
+// Settings configure where the run artifacts are uploaded
+type Settings struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	Prefix    string
+	PathStyle bool
+	// CredentialsSecret holds the credentials keys
+	CredentialsSecret types.NamespacedName
+}
+
+// Enabled returns whether the artifacts are uploaded
+func (s Settings) Enabled() bool {
+	return s.Bucket != ""
+}
+
+// ForRun returns the settings overridden by the run ones,
+// a run credentials Secret is looked up in the run's namespace
+func (s Settings) ForRun(run *v1alpha1.SippScenarioRun) Settings {
+	storage := run.Spec.Upload
+	if storage == nil {
+		return s
+	}
+
+	if storage.Endpoint != "" {
+		s.Endpoint = storage.Endpoint
+	}
+	if storage.Region != "" {
+		s.Region = storage.Region
+	}
+	if storage.Bucket != "" {
+		s.Bucket = storage.Bucket
+	}
+	if storage.Prefix != "" {
+		s.Prefix = storage.Prefix
+	}
+	if storage.PathStyle != nil {
+		s.PathStyle = *storage.PathStyle
+	}
+	if storage.CredentialsSecret != nil {
+		s.CredentialsSecret = types.NamespacedName{Namespace: run.Namespace, Name: storage.CredentialsSecret.Name}
+	}
+
+	return s
+}
+
+// PrefixData are the fields available in the prefix template
+type PrefixData struct {
+	Namespace string
+	Run       string
+	Scenario  string
+	// Date is the creation date of the run, as YYYY-MM-DD
+	Date string
+}
+
+// RenderPrefix returns the object keys prefix of the run
+func (s Settings) RenderPrefix(run *v1alpha1.SippScenarioRun) (string, error) {
+	prefix := s.Prefix
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+
+	tmpl, err := template.New("prefix").Option("missingkey=error").Parse(prefix)
+	if err != nil {
+		return "", err
+	}
+
+	data := PrefixData{
+		Namespace: run.Namespace,
+		Run:       run.Name,
+		Date:      run.CreationTimestamp.UTC().Format("2006-01-02"),
+	}
+	if run.Spec.ScenarioRef != nil {
+		data.Scenario = run.Spec.ScenarioRef.Name
+	}
+
+	rendered := &bytes.Buffer{}
+	if err := tmpl.Execute(rendered, data); err != nil {
+		return "", err
+	}
+
+	return strings.Trim(rendered.String(), "/"), nil
+}
+
+// Summary is the results summary uploaded beside the artifacts
+type Summary struct {
+	Namespace           string     `json:"namespace"`
+	Run                 string     `json:"run"`
+	Scenario            string     `json:"scenario"`
+	ScenarioDigest      string     `json:"scenarioDigest,omitempty"`
+	InjectValuesDigests []string   `json:"injectValuesDigests,omitempty"`
+	Parallelism         int32      `json:"parallelism"`
+	Succeeded           int32      `json:"succeeded"`
+	Failed              int32      `json:"failed"`
+	StartTime           *time.Time `json:"startTime,omitempty"`
+	CompletionTime      *time.Time `json:"completionTime,omitempty"`
+}
+
+// UploadDir uploads the files of the directory and its subdirectories
+// under the prefix, and returns the URLs of the objects
+func UploadDir(ctx context.Context, client *Client, dir, prefix string) ([]string, error) {
+	urls := []string{}
+
+	err := filepath.Walk(dir, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(dir, filePath)
+		if err != nil {
+			return err
+		}
+
+		file, err := os.Open(filePath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		url, err := client.PutObject(ctx, path.Join(prefix, filepath.ToSlash(relPath)), file)
+		if err != nil {
+			return err
+		}
+		urls = append(urls, url)
+
+		return nil
+	})
+
+	return urls, err
+}
@@ -0,0 +1,68 @@
+package upload_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+
+	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
+	"github.com/alexandrevilain/sipp-operator/internal/upload"
+)
+
+func TestForRun(t *testing.T) {
+	settings := upload.Settings{
+		Endpoint:          "https://s3.eu-west-1.amazonaws.com",
+		Region:            "eu-west-1",
+		Bucket:            "sipp",
+		CredentialsSecret: types.NamespacedName{Namespace: "sipp-operator-system", Name: "s3"},
+	}
+
+	run := &v1alpha1.SippScenarioRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "run", Namespace: "default"},
+	}
+	assert.Equal(t, settings, settings.ForRun(run))
+
+	run.Spec.Upload = &v1alpha1.ObjectStorage{
+		Endpoint:          "http://minio.minio.svc:9000",
+		PathStyle:         pointer.BoolPtr(true),
+		CredentialsSecret: &corev1.LocalObjectReference{Name: "minio"},
+	}
+	assert.Equal(t, upload.Settings{
+		Endpoint:          "http://minio.minio.svc:9000",
+		Region:            "eu-west-1",
+		Bucket:            "sipp",
+		PathStyle:         true,
+		CredentialsSecret: types.NamespacedName{Namespace: "default", Name: "minio"},
+	}, settings.ForRun(run))
+
+	assert.False(t, upload.Settings{}.Enabled())
+}
+
+func TestRenderPrefix(t *testing.T) {
+	run := &v1alpha1.SippScenarioRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "run",
+			Namespace:         "default",
+			CreationTimestamp: metav1.NewTime(time.Date(2020, 9, 14, 23, 0, 0, 0, time.UTC)),
+		},
+		Spec: v1alpha1.SippScenarioRunSpec{
+			ScenarioRef: &v1alpha1.ScenarioReference{Name: "uac"},
+		},
+	}
+
+	prefix, err := upload.Settings{}.RenderPrefix(run)
+	assert.NoError(t, err)
+	assert.Equal(t, "default/run", prefix)
+
+	prefix, err = upload.Settings{Prefix: "/regressions/{{ .Scenario }}/{{ .Date }}/{{ .Run }}/"}.RenderPrefix(run)
+	assert.NoError(t, err)
+	assert.Equal(t, "regressions/uac/2020-09-14/run", prefix)
+
+	_, err = upload.Settings{Prefix: "{{ .Cluster }}"}.RenderPrefix(run)
+	assert.Error(t, err)
+}
@@ -0,0 +1,29 @@
+package util
+
+import (
+	"regexp"
+)
+
+var placeholderRegexp = regexp.MustCompile(`\$\(([A-Za-z_][A-Za-z0-9_.-]*)\)`)
+
+// ExpandPlaceholders replaces the $(NAME) placeholders of the arguments by their value,
+// and returns whether any known placeholder was found.
+// Unknown placeholders are left as is.
+func ExpandPlaceholders(args []string, values map[string]string) ([]string, bool) {
+	result := make([]string, 0, len(args))
+	found := false
+
+	for _, arg := range args {
+		result = append(result, placeholderRegexp.ReplaceAllStringFunc(arg, func(placeholder string) string {
+			name := placeholderRegexp.FindStringSubmatch(placeholder)[1]
+			value, ok := values[name]
+			if !ok {
+				return placeholder
+			}
+			found = true
+			return value
+		}))
+	}
+
+	return result, found
+}
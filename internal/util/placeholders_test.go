@@ -0,0 +1,27 @@
+package util_test
+
+import (
+	"testing"
+
+	"github.com/alexandrevilain/sipp-operator/internal/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandPlaceholders(t *testing.T) {
+	values := map[string]string{"SCENARIO_FILE": "/etc/jobconfig/scenario.xml"}
+
+	args, found := util.ExpandPlaceholders([]string{"-sf", "$(SCENARIO_FILE)", "-key", "home", "$(HOME)"}, values)
+	assert.True(t, found)
+	assert.Equal(t, []string{"-sf", "/etc/jobconfig/scenario.xml", "-key", "home", "$(HOME)"}, args)
+
+	args, found = util.ExpandPlaceholders([]string{"-sn", "uac"}, values)
+	assert.False(t, found)
+	assert.Equal(t, []string{"-sn", "uac"}, args)
+
+	args, found = util.ExpandPlaceholders([]string{"$(steps.callee-1.address):$(steps.callee-1.port)"}, map[string]string{
+		"steps.callee-1.address": "suite-callee-1.default.svc",
+		"steps.callee-1.port":    "5060",
+	})
+	assert.True(t, found)
+	assert.Equal(t, []string{"suite-callee-1.default.svc:5060"}, args)
+}
@@ -19,8 +19,10 @@ package main
 import (
 	"flag"
 	"os"
+	"strings"
 
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -28,6 +30,9 @@ import (
 
 	"github.com/alexandrevilain/sipp-operator/api/v1alpha1"
 	"github.com/alexandrevilain/sipp-operator/controllers"
+	"github.com/alexandrevilain/sipp-operator/internal/content"
+	"github.com/alexandrevilain/sipp-operator/internal/control"
+	"github.com/alexandrevilain/sipp-operator/internal/upload"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -50,8 +55,32 @@ func main() {
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	var uploadSettings upload.Settings
+	var uploadCredentialsSecret, uploaderImage string
+	flag.StringVar(&uploadSettings.Endpoint, "upload-endpoint", "",
+		"The endpoint of the S3 compatible object storage the run artifacts are uploaded to.")
+	flag.StringVar(&uploadSettings.Region, "upload-region", upload.DefaultRegion, "The region of the upload bucket.")
+	flag.StringVar(&uploadSettings.Bucket, "upload-bucket", "",
+		"The bucket the run artifacts are uploaded to. Uploads are disabled when empty, unless a run sets its own bucket.")
+	flag.StringVar(&uploadSettings.Prefix, "upload-prefix", upload.DefaultPrefix, "The template of the uploaded object keys prefix.")
+	flag.BoolVar(&uploadSettings.PathStyle, "upload-path-style", false,
+		"Put the bucket in the URL path instead of the host name, as MinIO expects.")
+	flag.StringVar(&uploadCredentialsSecret, "upload-credentials-secret", "",
+		"The namespace/name of the Secret holding the object storage credentials, only used by the runs of its namespace. "+
+			"The runs of other namespaces set their own credentials Secret.")
+	flag.StringVar(&uploaderImage, "uploader-image", "controller:latest",
+		"The operator image, which runs the upload and metrics jobs.")
 	flag.Parse()
 
+	if uploadCredentialsSecret != "" {
+		parts := strings.SplitN(uploadCredentialsSecret, "/", 2)
+		if len(parts) != 2 {
+			setupLog.Error(nil, "upload-credentials-secret must be namespace/name", "value", uploadCredentialsSecret)
+			os.Exit(1)
+		}
+		uploadSettings.CredentialsSecret = types.NamespacedName{Namespace: parts[0], Name: parts[1]}
+	}
+
 	ctrl.SetLogger(zap.New(zap.UseDevMode(true)))
 
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
@@ -67,11 +96,49 @@ func main() {
 	}
 
 	if err = (&controllers.SippScenarioRunReconciler{
+		Client:          mgr.GetClient(),
+		Log:             ctrl.Log.WithName("controllers").WithName("SippScenarioRun"),
+		Scheme:          mgr.GetScheme(),
+		ContentResolver: content.NewResolver(mgr.GetClient()),
+		UploadSettings:  uploadSettings,
+		UploaderImage:   uploaderImage,
+		RemoteControl:   control.NewUDPSender(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "SippScenarioRun")
+		os.Exit(1)
+	}
+	if err = (&controllers.SippScenarioReconciler{
+		Client:          mgr.GetClient(),
+		Log:             ctrl.Log.WithName("controllers").WithName("SippScenario"),
+		Scheme:          mgr.GetScheme(),
+		ContentResolver: content.NewResolver(mgr.GetClient()),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "SippScenario")
+		os.Exit(1)
+	}
+	if err = (&controllers.ClusterSippScenarioReconciler{
+		Client:          mgr.GetClient(),
+		Log:             ctrl.Log.WithName("controllers").WithName("ClusterSippScenario"),
+		Scheme:          mgr.GetScheme(),
+		ContentResolver: content.NewResolver(mgr.GetClient()),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ClusterSippScenario")
+		os.Exit(1)
+	}
+	if err = (&controllers.SippTestSuiteReconciler{
 		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("SippScenarioRun"),
+		Log:    ctrl.Log.WithName("controllers").WithName("SippTestSuite"),
 		Scheme: mgr.GetScheme(),
 	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "SippScenarioRun")
+		setupLog.Error(err, "unable to create controller", "controller", "SippTestSuite")
+		os.Exit(1)
+	}
+	if err = (&controllers.SippCampaignReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("SippCampaign"),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "SippCampaign")
 		os.Exit(1)
 	}
 	// +kubebuilder:scaffold:builder